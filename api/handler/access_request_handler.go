@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type AccessRequestHandler struct {
+	requestService services.AccessRequestService
+}
+
+func NewAccessRequestHandler(requestService services.AccessRequestService) *AccessRequestHandler {
+	return &AccessRequestHandler{requestService: requestService}
+}
+
+// CreateRequest 提交访问申请
+// @Summary 提交访问申请
+// @Description 为指定组织申请一个角色，审批通过后自动获得从审批时起、按申请时长到期的限时角色分配。系统角色不能申请
+// @Tags 访问申请
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAccessRequestRequest true "申请信息"
+// @Success 200 {object} result.ResponseResult[dto.AccessRequestResponse] "提交成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Router /access-requests [post]
+func (h *AccessRequestHandler) CreateRequest(c *gin.Context) {
+	var req dto.CreateAccessRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	request, err := h.requestService.Create(&req, currentOperatorID(c))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response := toAccessRequestResponse(request)
+	result.SuccessResponse(c, "访问申请已提交", &response)
+}
+
+// ListMyRequests 我的访问申请
+// @Summary 我的访问申请
+// @Description 分页获取当前用户提交的访问申请
+// @Tags 访问申请
+// @Produce json
+// @Param status query string false "状态：pending、approved、rejected、cancelled、expired"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认10，最大100"
+// @Success 200 {object} result.ResponseResult[dto.PaginationResponse] "获取成功"
+// @Router /access-requests/mine [get]
+func (h *AccessRequestHandler) ListMyRequests(c *gin.Context) {
+	var req dto.PaginationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.SetDefaults()
+
+	requests, total, err := h.requestService.ListMine(c.GetUint64("user_id"), c.Query("status"), req.Page, req.PageSize)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "获取访问申请成功", dto.NewPaginationResponse(req.Page, req.PageSize, total, toAccessRequestResponses(requests)))
+}
+
+// ListReviewableRequests 待审批的访问申请
+// @Summary 待审批的访问申请
+// @Description 分页获取当前用户可审批范围内的访问申请，默认只返回待审批的申请；status 传 all 时返回全部状态
+// @Tags 访问申请
+// @Produce json
+// @Param status query string false "状态：pending（默认）、approved、rejected、cancelled、expired、all"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认10，最大100"
+// @Success 200 {object} result.ResponseResult[dto.PaginationResponse] "获取成功"
+// @Router /access-requests [get]
+func (h *AccessRequestHandler) ListReviewableRequests(c *gin.Context) {
+	var req dto.PaginationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.SetDefaults()
+
+	status := c.DefaultQuery("status", entity.AccessRequestPending)
+	if status == "all" {
+		status = ""
+	}
+	requests, total, err := h.requestService.ListReviewable(c.GetUint64("user_id"), status, req.Page, req.PageSize)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "获取访问申请成功", dto.NewPaginationResponse(req.Page, req.PageSize, total, toAccessRequestResponses(requests)))
+}
+
+// ApproveRequest 通过访问申请
+// @Summary 通过访问申请
+// @Description 审批人通过申请，系统自动为申请人创建限时角色分配，到期后由清理任务回收。不能审批本人的申请
+// @Tags 访问申请
+// @Accept json
+// @Produce json
+// @Param id path int true "申请ID"
+// @Param request body dto.ReviewAccessRequestRequest false "审批意见"
+// @Success 200 {object} result.ResponseResult[dto.AccessRequestResponse] "审批成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 403 {object} result.ResponseResult[string] "无权审批"
+// @Failure 409 {object} result.ResponseResult[string] "申请已处理"
+// @Router /access-requests/{id}/approve [post]
+func (h *AccessRequestHandler) ApproveRequest(c *gin.Context) {
+	h.review(c, h.requestService.Approve, "访问申请已通过")
+}
+
+// RejectRequest 驳回访问申请
+// @Summary 驳回访问申请
+// @Description 审批人驳回申请，可附带审批意见
+// @Tags 访问申请
+// @Accept json
+// @Produce json
+// @Param id path int true "申请ID"
+// @Param request body dto.ReviewAccessRequestRequest false "审批意见"
+// @Success 200 {object} result.ResponseResult[dto.AccessRequestResponse] "驳回成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 403 {object} result.ResponseResult[string] "无权审批"
+// @Failure 409 {object} result.ResponseResult[string] "申请已处理"
+// @Router /access-requests/{id}/reject [post]
+func (h *AccessRequestHandler) RejectRequest(c *gin.Context) {
+	h.review(c, h.requestService.Reject, "访问申请已驳回")
+}
+
+// CancelRequest 撤回访问申请
+// @Summary 撤回访问申请
+// @Description 申请人撤回本人待审批的申请
+// @Tags 访问申请
+// @Produce json
+// @Param id path int true "申请ID"
+// @Success 200 {object} result.ResponseResult[dto.AccessRequestResponse] "撤回成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的申请ID"
+// @Failure 409 {object} result.ResponseResult[string] "申请已处理"
+// @Router /access-requests/{id}/cancel [post]
+func (h *AccessRequestHandler) CancelRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的申请ID")
+		return
+	}
+
+	request, err := h.requestService.Cancel(id, currentOperatorID(c))
+	if err != nil {
+		result.ErrorResponse(c, accessRequestErrorStatus(err), err.Error())
+		return
+	}
+	response := toAccessRequestResponse(request)
+	result.SuccessResponse(c, "访问申请已撤回", &response)
+}
+
+// review 通过与驳回共用的参数解析与响应
+func (h *AccessRequestHandler) review(c *gin.Context, action func(id uint64, comment string, operatorID uint64) (*entity.AccessRequest, error), message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的申请ID")
+		return
+	}
+	var req dto.ReviewAccessRequestRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	request, err := action(id, req.Comment, currentOperatorID(c))
+	if err != nil {
+		result.ErrorResponse(c, accessRequestErrorStatus(err), err.Error())
+		return
+	}
+	response := toAccessRequestResponse(request)
+	result.SuccessResponse(c, message, &response)
+}
+
+func accessRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotAccessRequestApprover):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAccessRequestNotPending):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func toAccessRequestResponses(requests []entity.AccessRequest) []dto.AccessRequestResponse {
+	responses := make([]dto.AccessRequestResponse, len(requests))
+	for i := range requests {
+		responses[i] = toAccessRequestResponse(&requests[i])
+	}
+	return responses
+}
+
+func toAccessRequestResponse(r *entity.AccessRequest) dto.AccessRequestResponse {
+	response := dto.AccessRequestResponse{
+		ID:              r.ID,
+		RequesterID:     r.RequesterID,
+		RequesterName:   r.Requester.Name,
+		RoleID:          r.RoleID,
+		RoleName:        r.Role.Name,
+		OrgUnitID:       r.OrgUnitID,
+		OrgUnitName:     r.OrgUnit.Name,
+		Reason:          r.Reason,
+		DurationMinutes: r.DurationMinutes,
+		Status:          r.Status,
+		ReviewerID:      r.ReviewerID,
+		ReviewComment:   r.ReviewComment,
+		CreatedAt:       r.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if r.ReviewedAt != nil {
+		response.ReviewedAt = r.ReviewedAt.Format("2006-01-02 15:04:05")
+	}
+	if r.ValidUntil != nil {
+		response.ValidUntil = r.ValidUntil.Format("2006-01-02 15:04:05")
+	}
+	return response
+}
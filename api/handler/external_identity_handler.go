@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type ExternalIdentityHandler struct {
+	oidcService services.OIDCService
+}
+
+func NewExternalIdentityHandler(oidcService services.OIDCService) *ExternalIdentityHandler {
+	return &ExternalIdentityHandler{oidcService: oidcService}
+}
+
+// ListIdentities 已关联的外部身份
+// @Summary 已关联的外部身份
+// @Description 获取当前用户关联的单点登录外部身份
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[[]dto.ExternalIdentityResponse] "获取成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/identities [get]
+func (h *ExternalIdentityHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.oidcService.ListIdentities(c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	responses := make([]dto.ExternalIdentityResponse, len(identities))
+	for i, identity := range identities {
+		responses[i] = dto.ExternalIdentityResponse{
+			ID:       identity.ID,
+			Provider: identity.Provider,
+			Email:    identity.Email,
+			LinkedAt: identity.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if identity.LastLoginAt != nil {
+			responses[i].LastLoginAt = identity.LastLoginAt.Format("2006-01-02 15:04:05")
+		}
+	}
+	result.SuccessResponse(c, "获取外部身份成功", &responses)
+}
+
+// UnlinkIdentity 解除外部身份关联
+// @Summary 解除外部身份关联
+// @Description 解除当前用户关联的外部身份，未设置密码的账号不能解除最后一个外部身份
+// @Tags 用户
+// @Produce json
+// @Param id path int true "外部身份ID"
+// @Success 200 {object} result.ResponseResult[string] "解除成功"
+// @Failure 400 {object} result.ResponseResult[string] "外部身份不存在或不能解除"
+// @Router /user/identities/{id} [delete]
+func (h *ExternalIdentityHandler) UnlinkIdentity(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的外部身份ID")
+		return
+	}
+
+	if err := h.oidcService.Unlink(c.GetUint64("user_id"), id); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "已解除外部身份关联")
+}
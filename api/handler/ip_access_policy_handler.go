@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type IPAccessPolicyHandler struct {
+	policyService services.IPAccessPolicyService
+}
+
+func NewIPAccessPolicyHandler(policyService services.IPAccessPolicyService) *IPAccessPolicyHandler {
+	return &IPAccessPolicyHandler{policyService: policyService}
+}
+
+// ListPolicies IP 访问策略列表
+// @Summary IP 访问策略列表
+// @Description 获取 IP 访问策略，可按作用对象筛选
+// @Tags IP访问策略
+// @Produce json
+// @Param subject_type query string false "作用对象类型：role、user"
+// @Param subject_id query string false "角色ID或用户ID"
+// @Success 200 {object} result.ResponseResult[[]dto.IPAccessPolicyResponse] "获取成功"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /ip-policies [get]
+func (h *IPAccessPolicyHandler) ListPolicies(c *gin.Context) {
+	subjectID, _ := strconv.ParseUint(c.Query("subject_id"), 10, 64)
+	policies, err := h.policyService.List(c.Query("subject_type"), subjectID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	responses := make([]dto.IPAccessPolicyResponse, len(policies))
+	for i := range policies {
+		responses[i] = toIPAccessPolicyResponse(&policies[i])
+	}
+	result.SuccessResponse(c, "获取策略列表成功", &responses)
+}
+
+// CreatePolicy 创建 IP 访问策略
+// @Summary 创建 IP 访问策略
+// @Description 为角色或用户添加允许或拒绝的网段；命中 deny 即拒绝，存在 allow 时必须命中其一。会导致操作者自身无法访问的策略将被拒绝
+// @Tags IP访问策略
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateIPAccessPolicyRequest true "策略信息"
+// @Success 200 {object} result.ResponseResult[dto.IPAccessPolicyResponse] "创建成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作"
+// @Router /ip-policies [post]
+func (h *IPAccessPolicyHandler) CreatePolicy(c *gin.Context) {
+	var req dto.CreateIPAccessPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy, err := h.policyService.Create(&req, currentOperatorID(c), c.ClientIP())
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response := toIPAccessPolicyResponse(policy)
+	result.SuccessResponse(c, "策略创建成功", &response)
+}
+
+// UpdatePolicy 更新 IP 访问策略
+// @Summary 更新 IP 访问策略
+// @Description 修改策略的效果、网段、说明或启用状态，作用对象不可修改
+// @Tags IP访问策略
+// @Accept json
+// @Produce json
+// @Param id path int true "策略ID"
+// @Param request body dto.UpdateIPAccessPolicyRequest true "策略信息"
+// @Success 200 {object} result.ResponseResult[dto.IPAccessPolicyResponse] "更新成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作"
+// @Router /ip-policies/{id} [put]
+func (h *IPAccessPolicyHandler) UpdatePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的策略ID")
+		return
+	}
+	var req dto.UpdateIPAccessPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy, err := h.policyService.Update(id, &req, currentOperatorID(c), c.ClientIP())
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response := toIPAccessPolicyResponse(policy)
+	result.SuccessResponse(c, "策略更新成功", &response)
+}
+
+// DeletePolicy 删除 IP 访问策略
+// @Summary 删除 IP 访问策略
+// @Description 删除指定的 IP 访问策略
+// @Tags IP访问策略
+// @Produce json
+// @Param id path int true "策略ID"
+// @Success 200 {object} result.ResponseResult[string] "删除成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的策略ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作"
+// @Router /ip-policies/{id} [delete]
+func (h *IPAccessPolicyHandler) DeletePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的策略ID")
+		return
+	}
+	if err := h.policyService.Delete(id, currentOperatorID(c), c.ClientIP()); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "策略删除成功")
+}
+
+func toIPAccessPolicyResponse(p *entity.IPAccessPolicy) dto.IPAccessPolicyResponse {
+	return dto.IPAccessPolicyResponse{
+		ID:          p.ID,
+		SubjectType: p.SubjectType,
+		SubjectID:   p.SubjectID,
+		Effect:      p.Effect,
+		CIDR:        p.CIDR,
+		Description: p.Description,
+		Enabled:     p.Enabled,
+		CreatedAt:   p.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   p.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
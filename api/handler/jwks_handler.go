@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/internal/tokenutil"
+)
+
+type JWKSHandler struct{}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// JWKSResponse JSON Web Key Set
+type JWKSResponse struct {
+	Keys []tokenutil.JWK `json:"keys"`
+}
+
+// GetJWKS 公开访问令牌的验证公钥
+// @Summary JWKS
+// @Description 以 JSON Web Key Set 格式返回访问令牌的全部验证公钥（包含已轮换但仍在有效期内的旧密钥），供网关等服务离线验证令牌
+// @Tags user
+// @Produce json
+// @Success 200 {object} JWKSResponse "公钥集合"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// 标准 JWKS 格式，不使用统一响应包装
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, JWKSResponse{Keys: tokenutil.PublicJWKs()})
+}
@@ -51,7 +51,7 @@ func (h *MenuHandler) CreateMenu(c *gin.Context) {
 		Status:    "enabled",
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.menuService.CreateMenu(menu, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -107,7 +107,7 @@ func (h *MenuHandler) UpdateMenu(c *gin.Context) {
 		menu.IsVisible = *request.IsVisible
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.menuService.UpdateMenu(menu, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -141,7 +141,7 @@ func (h *MenuHandler) UpdateMenu(c *gin.Context) {
 func (h *MenuHandler) DeleteMenu(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.menuService.DeleteMenu(id, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -287,7 +287,7 @@ func (h *MenuHandler) BindResource(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.menuService.BindResource(menuID, req.ResourceID, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -310,7 +310,7 @@ func (h *MenuHandler) UnbindResource(c *gin.Context) {
 	menuID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	resourceID, _ := strconv.ParseUint(c.Param("resourceId"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.menuService.UnbindResource(menuID, resourceID, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
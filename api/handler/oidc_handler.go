@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/result"
+)
+
+// ListOIDCProviders 单点登录身份提供方列表
+// @Summary 单点登录身份提供方
+// @Description 获取已配置的 OIDC 身份提供方，用于登录页展示单点登录入口
+// @Tags user
+// @Produce json
+// @Success 200 {object} result.ResponseResult[[]dto.OIDCProviderResponse] "获取成功"
+// @Router /oidc/providers [get]
+func (u *UserHandler) ListOIDCProviders(c *gin.Context) {
+	providers := u.OIDCService.Providers()
+	result.SuccessResponse(c, "获取身份提供方成功", &providers)
+}
+
+// OIDCLogin 发起单点登录
+// @Summary 发起单点登录
+// @Description 生成 state、nonce 与 PKCE 参数后重定向到身份提供方的授权页面
+// @Tags user
+// @Param provider path string true "身份提供方名称"
+// @Success 302 "重定向到身份提供方"
+// @Failure 400 {object} result.ResponseResult[string] "不支持的身份提供方"
+// @Router /oidc/{provider}/login [get]
+func (u *UserHandler) OIDCLogin(c *gin.Context) {
+	authURL, err := u.OIDCService.AuthURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 单点登录回调
+// @Summary 单点登录回调
+// @Description 校验 state 与 ID Token，关联或创建本地用户后签发访问令牌和刷新令牌；配置了前端回调地址时结果放在 URL fragment 中重定向
+// @Tags user
+// @Produce json
+// @Param provider path string true "身份提供方名称"
+// @Param code query string true "授权码"
+// @Param state query string true "授权请求 state"
+// @Success 200 {object} result.ResponseResult[dto.LoginResponse] "登录成功"
+// @Failure 401 {object} result.ResponseResult[string] "登录失败"
+// @Failure 403 {object} result.ResponseResult[string] "账号不可用"
+// @Router /oidc/{provider}/callback [get]
+func (u *UserHandler) OIDCCallback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		message := errCode
+		if desc := c.Query("error_description"); desc != "" {
+			message += ": " + desc
+		}
+		finishOIDCLogin(c, http.StatusUnauthorized, message, nil)
+		return
+	}
+
+	user, err := u.OIDCService.Callback(c.Request.Context(), c.Param("provider"), c.Query("code"), c.Query("state"))
+	if err != nil {
+		finishOIDCLogin(c, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+	if message := userStatusError(user); message != "" {
+		finishOIDCLogin(c, http.StatusForbidden, message, nil)
+		return
+	}
+
+	// 启用双因素认证的用户同样需要通过 /login/2fa 提交验证码
+	twoFactorEnabled, err := u.TwoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		finishOIDCLogin(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if twoFactorEnabled {
+		challengeToken, err := u.TwoFactorService.CreateChallenge(user)
+		if err != nil {
+			finishOIDCLogin(c, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		finishOIDCLogin(c, http.StatusOK, "Two-factor authentication required", &dto.LoginResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
+
+	loginResponse, err := u.completeLogin(c, user, "")
+	if err != nil {
+		finishOIDCLogin(c, loginErrorStatus(err), err.Error(), nil)
+		return
+	}
+	finishOIDCLogin(c, http.StatusOK, "Login successful", loginResponse)
+}
+
+// finishOIDCLogin 输出单点登录结果：未配置前端回调地址时返回 JSON，否则重定向并将结果放在 URL fragment 中，避免令牌出现在服务器日志和 Referer 里
+func finishOIDCLogin(c *gin.Context, status int, message string, resp *dto.LoginResponse) {
+	callbackURL := config.CfgOIDC.FrontendCallbackURL
+	if callbackURL == "" {
+		if resp == nil {
+			result.ErrorResponse(c, status, message)
+			return
+		}
+		result.SuccessResponse(c, message, resp)
+		return
+	}
+
+	fragment := url.Values{}
+	if resp == nil {
+		fragment.Set("error", message)
+	} else {
+		if resp.AccessToken != "" {
+			fragment.Set("accessToken", resp.AccessToken)
+			fragment.Set("refreshToken", resp.RefreshToken)
+		}
+		if resp.TwoFactorRequired {
+			fragment.Set("twoFactorRequired", strconv.FormatBool(true))
+			fragment.Set("challengeToken", resp.ChallengeToken)
+		}
+		if resp.PasswordChangeRequired {
+			fragment.Set("passwordChangeRequired", strconv.FormatBool(true))
+		}
+	}
+	c.Redirect(http.StatusFound, callbackURL+"#"+fragment.Encode())
+}
@@ -0,0 +1,12 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+// currentOperatorID 返回写操作的实际操作者
+// 模拟登录期间 user_id 为被模拟的用户，审计与组织范围校验应记录到实际操作者 actor_id 名下
+func currentOperatorID(c *gin.Context) uint64 {
+	if actorID := c.GetUint64("actor_id"); actorID != 0 {
+		return actorID
+	}
+	return c.GetUint64("user_id")
+}
@@ -48,7 +48,7 @@ func (h *OrgUnitHandler) CreateOrgUnit(c *gin.Context) {
 		ParentID: request.ParentID,
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.orgService.CreateOrgUnit(orgUnit, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -93,7 +93,7 @@ func (h *OrgUnitHandler) UpdateOrgUnit(c *gin.Context) {
 		ParentID: request.ParentID,
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.orgService.UpdateOrgUnit(orgUnit, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -124,7 +124,7 @@ func (h *OrgUnitHandler) UpdateOrgUnit(c *gin.Context) {
 func (h *OrgUnitHandler) DeleteOrgUnit(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.orgService.DeleteOrgUnit(id, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService services.PasswordResetService
+}
+
+func NewPasswordResetHandler(passwordResetService services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{passwordResetService: passwordResetService}
+}
+
+// ForgotPassword 找回密码
+// @Summary 找回密码
+// @Description 向邮箱发送一次性重置密码链接，无论邮箱是否注册都返回成功
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "找回密码请求"
+// @Success 200 {object} result.ResponseResult[string] "已发送"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /password/forgot [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.passwordResetService.Forgot(req.Email, c.ClientIP()); err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "如果该邮箱已注册，重置密码邮件已发送")
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 使用邮件中的一次性令牌设置新密码，成功后该用户的全部登录会话失效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "重置密码请求"
+// @Success 200 {object} result.ResponseResult[string] "重置成功"
+// @Failure 400 {object} result.ResponseResult[string] "令牌无效或密码不符合安全策略"
+// @Router /password/reset [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.passwordResetService.Reset(req.Token, req.NewPassword); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "密码重置成功，请重新登录")
+}
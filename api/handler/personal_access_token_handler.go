@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type PersonalAccessTokenHandler struct {
+	tokenService services.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(tokenService services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{tokenService: tokenService}
+}
+
+// ListTokens 个人访问令牌列表
+// @Summary 个人访问令牌列表
+// @Description 获取当前用户的个人访问令牌，包含已过期与已撤销的令牌，不返回令牌明文
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[[]dto.PersonalAccessTokenResponse] "获取成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /user/tokens [get]
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	tokens, err := h.tokenService.List(c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	responses := toPersonalAccessTokenResponses(tokens)
+	result.SuccessResponse(c, "获取令牌列表成功", &responses)
+}
+
+// CreateToken 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 创建以 pat_ 开头的个人访问令牌，通过 Authorization: Bearer 调用 API；授权范围必须在当前权限之内，令牌明文只返回一次
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body dto.CreatePersonalAccessTokenRequest true "令牌名称、授权范围与有效天数"
+// @Success 200 {object} result.ResponseResult[dto.CreatePersonalAccessTokenResponse] "创建成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误或授权范围超出当前权限"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	var req dto.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, plain, err := h.tokenService.Create(c.GetUint64("user_id"), &req)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "令牌创建成功，请立即保存，关闭后将无法再次查看", &dto.CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: toPersonalAccessTokenResponse(token),
+		Token:                       plain,
+	})
+}
+
+// RevokeToken 撤销个人访问令牌
+// @Summary 撤销个人访问令牌
+// @Description 撤销当前用户的指定个人访问令牌，立即失效
+// @Tags 用户
+// @Produce json
+// @Param id path int true "令牌ID"
+// @Success 200 {object} result.ResponseResult[string] "撤销成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的令牌ID"
+// @Failure 404 {object} result.ResponseResult[string] "令牌不存在"
+// @Router /user/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的令牌ID")
+		return
+	}
+
+	userID := c.GetUint64("user_id")
+	if err := h.tokenService.Revoke(userID, id, userID); err != nil {
+		result.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "令牌已撤销")
+}
+
+// toPersonalAccessTokenResponses 将令牌实体列表转换为响应
+func toPersonalAccessTokenResponses(tokens []entity.PersonalAccessToken) []dto.PersonalAccessTokenResponse {
+	responses := make([]dto.PersonalAccessTokenResponse, len(tokens))
+	for i := range tokens {
+		responses[i] = toPersonalAccessTokenResponse(&tokens[i])
+	}
+	return responses
+}
+
+// toPersonalAccessTokenResponse 将令牌实体转换为响应，并根据撤销与过期时间计算状态
+func toPersonalAccessTokenResponse(t *entity.PersonalAccessToken) dto.PersonalAccessTokenResponse {
+	resp := dto.PersonalAccessTokenResponse{
+		ID:         t.ID,
+		UserID:     t.UserID,
+		Name:       t.Name,
+		TokenHint:  t.TokenHint,
+		Scopes:     []dto.TokenScope{},
+		Status:     "active",
+		CreatedAt:  t.CreatedAt.Format("2006-01-02 15:04:05"),
+		LastUsedIP: t.LastUsedIP,
+	}
+	json.Unmarshal([]byte(t.Scopes), &resp.Scopes)
+
+	switch {
+	case t.RevokedAt != nil:
+		resp.Status = "revoked"
+	case !t.Active(time.Now()):
+		resp.Status = "expired"
+	}
+	if t.ExpiresAt != nil {
+		resp.ExpiresAt = t.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	if t.LastUsedAt != nil {
+		resp.LastUsedAt = t.LastUsedAt.Format("2006-01-02 15:04:05")
+	}
+	if t.RevokedAt != nil {
+		resp.RevokedAt = t.RevokedAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
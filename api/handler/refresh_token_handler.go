@@ -1,9 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/domain"
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/result"
@@ -22,7 +22,7 @@ func NewRefreshTokenHandler(refreshTokenService domain.RefreshTokenService) *Ref
 }
 
 // @Summary 刷新令牌
-// @Description 使用刷新令牌获取新的访问令牌和刷新令牌
+// @Description 使用刷新令牌获取新的访问令牌和刷新令牌，旧刷新令牌随即失效，重复使用将撤销同一家族的所有令牌
 // @Tags user
 // @Accept json
 // @Produce json
@@ -42,38 +42,22 @@ func (rtc *RefreshTokenHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 通过token获取用户id
-	id, err := rtc.RefreshTokenService.ExtractIDFromToken(request.RefreshToken, config.CfgToken.RefreshTokenSecret)
+	// 轮换刷新令牌：旧令牌立即失效，重复使用将撤销整个令牌家族
+	accessToken, refreshToken, err := rtc.RefreshTokenService.RotateRefreshToken(c, request.RefreshToken)
 	if err != nil {
-		if err.Error() == "token is expired" {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			result.ErrorResponse(c, http.StatusUnauthorized, "Refresh token reuse detected, please login again")
+		case err.Error() == "token is expired":
 			result.ErrorResponse(c, http.StatusUnauthorized, "Token is expired")
-		} else {
-			result.ErrorResponse(c, http.StatusUnauthorized, "User not found")
+		case err.Error() == "token has been revoked":
+			result.ErrorResponse(c, http.StatusUnauthorized, "Token has been revoked")
+		default:
+			result.ErrorResponse(c, http.StatusUnauthorized, "Invalid refresh token")
 		}
 		return
 	}
 
-	// 通过ID获取用户信息
-	user, err := rtc.RefreshTokenService.GetUserByID(c, id)
-	if err != nil {
-		result.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-		return
-	}
-
-	// 创建新的访问token
-	accessToken, err := rtc.RefreshTokenService.CreateAccessToken(&user, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
-	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	// 创建新的刷新token
-	refreshToken, err := rtc.RefreshTokenService.CreateRefreshToken(&user, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
-	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return
-	}
-
 	refreshTokenResponse := dto.RefreshTokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
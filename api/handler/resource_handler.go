@@ -40,7 +40,7 @@ func (h *ResourceHandler) CreateResource(c *gin.Context) {
 		Description: request.Description,
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.resourceService.CreateResource(resource, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -80,7 +80,7 @@ func (h *ResourceHandler) UpdateResource(c *gin.Context) {
 		Description: request.Description,
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.resourceService.UpdateResource(resource, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -103,7 +103,7 @@ func (h *ResourceHandler) UpdateResource(c *gin.Context) {
 func (h *ResourceHandler) DeleteResource(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.resourceService.DeleteResource(id, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/domain/dto"
@@ -10,6 +12,7 @@ import (
 	"github.com/lyj404/gin-api-template/domain/result"
 	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/condition"
 )
 
 type RoleHandler struct {
@@ -44,9 +47,11 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 		Name:        request.Name,
 		Description: request.Description,
 		IsSystem:    false,
+		MaxSessions: request.MaxSessions,
+		IsApprover:  request.IsApprover,
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.roleService.CreateRole(role, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -57,6 +62,8 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 		Name:        role.Name,
 		Description: role.Description,
 		IsSystem:    role.IsSystem,
+		MaxSessions: role.MaxSessions,
+		IsApprover:  role.IsApprover,
 	}
 
 	result.SuccessResponse(c, "角色创建成功", &response)
@@ -90,14 +97,22 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 		Description: request.Description,
 	}
 
-	// 保留 IsSystem 原值，避免 Save 清零
-	operatorID := c.GetUint64("user_id")
+	// 保留 IsSystem 及未传入的 MaxSessions、IsApprover 原值，避免 Save 清零
+	operatorID := currentOperatorID(c)
 	existing, err := h.roleService.GetRoleByID(id, operatorID)
 	if err != nil {
 		result.ErrorResponse(c, http.StatusNotFound, "角色不存在")
 		return
 	}
 	role.IsSystem = existing.IsSystem
+	role.MaxSessions = existing.MaxSessions
+	if request.MaxSessions != nil {
+		role.MaxSessions = *request.MaxSessions
+	}
+	role.IsApprover = existing.IsApprover
+	if request.IsApprover != nil {
+		role.IsApprover = *request.IsApprover
+	}
 
 	if err := h.roleService.UpdateRole(role, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
@@ -109,6 +124,8 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 		Name:        role.Name,
 		Description: role.Description,
 		IsSystem:    role.IsSystem,
+		MaxSessions: role.MaxSessions,
+		IsApprover:  role.IsApprover,
 	}
 
 	result.SuccessResponse(c, "角色更新成功", &response)
@@ -128,7 +145,7 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 func (h *RoleHandler) DeleteRole(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.roleService.DeleteRole(id, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -165,6 +182,9 @@ func (h *RoleHandler) GetRole(c *gin.Context) {
 			ResourceID: rr.ResourceID,
 			IsRead:     rr.IsRead,
 			IsWrite:    rr.IsWrite,
+			Effect:        rr.Effect,
+			Condition:     rr.Condition,
+			ConditionText: conditionText(rr.Condition),
 			Resource: &dto.ResourceBriefResponse{
 				ID:          rr.Resource.ID,
 				Name:        rr.Resource.Name,
@@ -199,6 +219,8 @@ func (h *RoleHandler) GetRole(c *gin.Context) {
 		Name:        role.Name,
 		Description: role.Description,
 		IsSystem:    role.IsSystem,
+		MaxSessions: role.MaxSessions,
+		IsApprover:  role.IsApprover,
 		Resources:   resourceResponses,
 		Menus:       menuResponses,
 	}
@@ -208,14 +230,14 @@ func (h *RoleHandler) GetRole(c *gin.Context) {
 
 // BindResource 角色绑定资源
 // @Summary 角色绑定资源
-// @Description 为角色绑定一个资源
+// @Description 为角色绑定一个资源，effect 为 deny 时拒绝访问且优先于任何允许，可附带生效条件表达式（time、weekday、ip、org_unit）
 // @Tags 角色
 // @Accept json
 // @Produce json
 // @Param id path int true "角色ID"
 // @Param request body dto.BindRoleResourceRequest true "绑定资源请求"
 // @Success 200 {object} result.ResponseResult[string] "绑定成功"
-// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误或条件表达式无效"
 // @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
 // @Router /roles/:id/resources [post]
 func (h *RoleHandler) BindResource(c *gin.Context) {
@@ -227,8 +249,12 @@ func (h *RoleHandler) BindResource(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
-	if err := h.roleService.BindResource(roleID, req.ResourceID, req.IsWrite, operatorID); err != nil {
+	operatorID := currentOperatorID(c)
+	if err := h.roleService.BindResource(roleID, req.ResourceID, req.IsWrite, req.Effect, req.Condition, operatorID); err != nil {
+		if errors.Is(err, services.ErrInvalidCondition) {
+			result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -250,7 +276,7 @@ func (h *RoleHandler) UnbindResource(c *gin.Context) {
 	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	resourceID, _ := strconv.ParseUint(c.Param("resourceId"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.roleService.UnbindResource(roleID, resourceID, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -286,6 +312,9 @@ func (h *RoleHandler) GetRoleResources(c *gin.Context) {
 			ResourceID: rr.ResourceID,
 			IsRead:     rr.IsRead,
 			IsWrite:    rr.IsWrite,
+			Effect:        rr.Effect,
+			Condition:     rr.Condition,
+			ConditionText: conditionText(rr.Condition),
 			Resource: &dto.ResourceBriefResponse{
 				ID:          rr.Resource.ID,
 				Name:        rr.Resource.Name,
@@ -323,7 +352,7 @@ func (h *RoleHandler) BindMenu(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.roleService.BindMenu(roleID, req.MenuID, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -346,7 +375,7 @@ func (h *RoleHandler) UnbindMenu(c *gin.Context) {
 	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	menuID, _ := strconv.ParseUint(c.Param("menuId"), 10, 64)
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if err := h.roleService.UnbindMenu(roleID, menuID, operatorID); err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -392,6 +421,197 @@ func (h *RoleHandler) GetRoleMenus(c *gin.Context) {
 	result.SuccessResponse(c, "获取角色菜单成功", &responses)
 }
 
+// AddParent 角色添加父角色
+// @Summary 角色添加父角色
+// @Description 角色继承父角色的资源、菜单与组织范围绑定，支持多个父角色，不能形成继承环
+// @Tags 角色
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param request body dto.AddRoleParentRequest true "添加父角色请求"
+// @Success 200 {object} result.ResponseResult[string] "添加成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误或形成继承环"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /roles/:id/parents [post]
+func (h *RoleHandler) AddParent(c *gin.Context) {
+	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req dto.AddRoleParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.roleService.AddParent(roleID, req.ParentID, operatorID); err != nil {
+		if errors.Is(err, services.ErrRoleInheritanceCycle) {
+			result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "父角色添加成功")
+}
+
+// RemoveParent 角色移除父角色
+// @Summary 角色移除父角色
+// @Description 取消角色对指定父角色的继承
+// @Tags 角色
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param parentId path int true "父角色ID"
+// @Success 200 {object} result.ResponseResult[string] "移除成功"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /roles/:id/parents/:parentId [delete]
+func (h *RoleHandler) RemoveParent(c *gin.Context) {
+	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	parentID, _ := strconv.ParseUint(c.Param("parentId"), 10, 64)
+
+	operatorID := currentOperatorID(c)
+	if err := h.roleService.RemoveParent(roleID, parentID, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "父角色移除成功")
+}
+
+// GetRoleParents 获取角色的父角色列表
+// @Summary 获取角色的父角色列表
+// @Description 获取指定角色直接继承的父角色
+// @Tags 角色
+// @Produce json
+// @Param id path int true "角色ID"
+// @Success 200 {object} result.ResponseResult[[]dto.RoleResponse] "获取成功"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /roles/:id/parents [get]
+func (h *RoleHandler) GetRoleParents(c *gin.Context) {
+	roleID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	userID := c.GetUint64("user_id")
+	parents, err := h.roleService.GetParents(roleID, userID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	responses := make([]dto.RoleResponse, len(parents))
+	for i, role := range parents {
+		responses[i] = dto.RoleResponse{
+			ID:          role.ID,
+			Name:        role.Name,
+			Description: role.Description,
+			IsSystem:    role.IsSystem,
+			MaxSessions: role.MaxSessions,
+			IsApprover:  role.IsApprover,
+		}
+	}
+
+	result.SuccessResponse(c, "获取父角色成功", &responses)
+}
+
+// AssignUserRole 为用户分配角色
+// @Summary 为用户分配角色
+// @Description 在指定组织下为用户分配角色，可设置生效与失效时间作为限时分配，到期后自动失效并记录审计
+// @Tags 角色
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param request body dto.AssignUserRoleRequest true "分配角色请求"
+// @Success 200 {object} result.ResponseResult[string] "分配成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /users/:id/roles [post]
+func (h *RoleHandler) AssignUserRole(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req dto.AssignUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.roleService.AssignRoleToUser(userID, req.RoleID, req.OrgUnitID, req.ValidFrom, req.ValidUntil, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "角色分配成功")
+}
+
+// RevokeUserRole 撤销用户角色
+// @Summary 撤销用户角色
+// @Description 撤销用户在指定组织下的角色分配（含限时分配）
+// @Tags 角色
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param roleId path int true "角色ID"
+// @Param org_unit_id query int true "组织ID"
+// @Success 200 {object} result.ResponseResult[string] "撤销成功"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /users/:id/roles/:roleId [delete]
+func (h *RoleHandler) RevokeUserRole(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	roleID, _ := strconv.ParseUint(c.Param("roleId"), 10, 64)
+	orgUnitID, err := strconv.ParseUint(c.Query("org_unit_id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的组织ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.roleService.RevokeRoleFromUser(userID, roleID, orgUnitID, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result.SimpleSuccessResponse(c, "角色撤销成功")
+}
+
+// ListUserRoles 获取用户的角色分配
+// @Summary 获取用户的角色分配
+// @Description 获取用户的全部角色分配及有效期，active 表示当前是否生效
+// @Tags 角色
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[[]dto.UserRoleResponse] "获取成功"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /users/:id/roles [get]
+func (h *RoleHandler) ListUserRoles(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	userRoles, err := h.roleService.ListUserRoles(userID, c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	now := time.Now()
+	responses := make([]dto.UserRoleResponse, len(userRoles))
+	for i, ur := range userRoles {
+		responses[i] = dto.UserRoleResponse{
+			ID:          ur.ID,
+			UserID:      ur.UserID,
+			RoleID:      ur.RoleID,
+			RoleName:    ur.Role.Name,
+			OrgUnitID:   ur.OrgUnitID,
+			OrgUnitName: ur.OrgUnit.Name,
+			Active:      ur.ActiveAt(now),
+		}
+		if ur.ValidFrom != nil {
+			responses[i].ValidFrom = ur.ValidFrom.Format("2006-01-02 15:04:05")
+		}
+		if ur.ValidUntil != nil {
+			responses[i].ValidUntil = ur.ValidUntil.Format("2006-01-02 15:04:05")
+		}
+	}
+
+	result.SuccessResponse(c, "获取用户角色成功", &responses)
+}
+
 // ListRoles 获取角色列表（分页）
 // @Summary 获取角色列表
 // @Description 获取角色列表（支持分页、搜索、排序）。系统管理员可查看所有角色，非系统角色用户只能查看非系统角色。
@@ -426,6 +646,8 @@ func (h *RoleHandler) ListRoles(c *gin.Context) {
 			Name:        role.Name,
 			Description: role.Description,
 			IsSystem:    role.IsSystem,
+			MaxSessions: role.MaxSessions,
+			IsApprover:  role.IsApprover,
 		}
 	}
 
@@ -436,3 +658,15 @@ func (h *RoleHandler) ListRoles(c *gin.Context) {
 		responses,
 	))
 }
+
+// conditionText 返回条件表达式的中文说明，无条件时为空
+func conditionText(cond string) string {
+	if cond == "" {
+		return ""
+	}
+	expr, err := condition.Cached(cond)
+	if err != nil {
+		return "条件无效，授权不生效"
+	}
+	return expr.Explain()
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// ListSessions 获取当前用户的登录会话
+// @Summary 登录设备列表
+// @Description 获取当前用户所有有效的登录会话，current 标记当前请求所在会话
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[[]dto.SessionResponse] "获取成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /user/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	sessions, err := h.sessionService.ListSessions(userID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	responses := toSessionResponses(sessions, c.GetString("session_id"))
+	result.SuccessResponse(c, "获取会话列表成功", &responses)
+}
+
+// RevokeSession 撤销当前用户的指定会话
+// @Summary 远程登出设备
+// @Description 撤销当前用户的指定会话，该会话的访问令牌与刷新令牌立即失效
+// @Tags 用户
+// @Produce json
+// @Param sessionId path string true "会话ID"
+// @Success 200 {object} result.ResponseResult[string] "撤销成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Failure 404 {object} result.ResponseResult[string] "会话不存在"
+// @Router /user/sessions/{sessionId} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	if err := h.sessionService.Revoke(userID, c.Param("sessionId"), userID); err != nil {
+		result.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "会话已撤销")
+}
+
+// RevokeOtherSessions 撤销当前用户除当前会话外的全部会话
+// @Summary 登出其他设备
+// @Description 撤销当前用户除当前会话外的全部会话
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[dto.RevokeSessionsResponse] "撤销成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /user/sessions [delete]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.GetUint64("user_id")
+	count, err := h.sessionService.RevokeAll(userID, c.GetString("session_id"), userID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "其他会话已撤销", &dto.RevokeSessionsResponse{Revoked: count})
+}
+
+// toSessionResponses 将会话实体转换为响应，currentSessionID 对应的会话标记为当前会话
+func toSessionResponses(sessions []entity.UserSession, currentSessionID string) []dto.SessionResponse {
+	responses := make([]dto.SessionResponse, len(sessions))
+	for i, s := range sessions {
+		responses[i] = dto.SessionResponse{
+			SessionID:  s.SessionID,
+			Device:     s.Device,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			Current:    s.SessionID == currentSessionID,
+			CreatedAt:  s.CreatedAt.Format("2006-01-02 15:04:05"),
+			LastSeenAt: s.LastSeenAt.Format("2006-01-02 15:04:05"),
+			ExpiresAt:  s.ExpiresAt.Format("2006-01-02 15:04:05"),
+		}
+	}
+	return responses
+}
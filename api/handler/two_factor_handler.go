@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/result"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// GetStatus 获取双因素认证状态
+// @Summary 双因素认证状态
+// @Description 获取当前用户是否启用双因素认证及剩余恢复码数量
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[dto.TwoFactorStatusResponse] "获取成功"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /user/2fa [get]
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	status, err := h.twoFactorService.Status(c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "获取双因素认证状态成功", status)
+}
+
+// Setup 初始化双因素认证
+// @Summary 初始化双因素认证
+// @Description 生成新的 TOTP 密钥并返回 otpauth URI，需调用确认接口提交首个验证码后才会启用
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} result.ResponseResult[dto.TwoFactorSetupResponse] "初始化成功"
+// @Failure 400 {object} result.ResponseResult[string] "双因素认证已启用"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	resp, err := h.twoFactorService.Setup(c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "双因素认证初始化成功", resp)
+}
+
+// QRCode 获取双因素认证二维码
+// @Summary 双因素认证二维码
+// @Description 返回待确认 TOTP 密钥的二维码图片，供身份验证器应用扫描
+// @Tags 用户
+// @Produce png
+// @Success 200 {file} binary "二维码图片"
+// @Failure 400 {object} result.ResponseResult[string] "尚未初始化双因素认证"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/2fa/qrcode [get]
+func (h *TwoFactorHandler) QRCode(c *gin.Context) {
+	imageData, err := h.twoFactorService.QRCode(c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", imageData)
+}
+
+// Confirm 确认并启用双因素认证
+// @Summary 启用双因素认证
+// @Description 提交身份验证器生成的首个验证码以启用双因素认证，返回一次性恢复码（仅显示一次）
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} result.ResponseResult[dto.RecoveryCodesResponse] "启用成功"
+// @Failure 400 {object} result.ResponseResult[string] "验证码错误"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(c.GetUint64("user_id"), req.Code)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "双因素认证已启用", &dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable 关闭双因素认证
+// @Summary 关闭双因素认证
+// @Description 校验登录密码后关闭双因素认证，同时作废全部恢复码
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorDisableRequest true "登录密码"
+// @Success 200 {object} result.ResponseResult[string] "关闭成功"
+// @Failure 400 {object} result.ResponseResult[string] "密码错误"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req dto.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.GetUint64("user_id"), req.Password); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "双因素认证已关闭")
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 校验验证码后重新生成恢复码，原恢复码全部失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} result.ResponseResult[dto.RecoveryCodesResponse] "生成成功"
+// @Failure 400 {object} result.ResponseResult[string] "验证码错误"
+// @Failure 401 {object} result.ResponseResult[string] "未授权"
+// @Router /user/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.GetUint64("user_id"), req.Code)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "恢复码已重新生成", &dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
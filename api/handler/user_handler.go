@@ -1,9 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/lyj404/gin-api-template/config"
@@ -12,8 +12,6 @@ import (
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/result"
 	"github.com/lyj404/gin-api-template/domain/services"
-	"github.com/lyj404/gin-api-template/global"
-	"github.com/lyj404/gin-api-template/pkg/lib/captcha"
 	"github.com/lyj404/gin-api-template/util"
 
 	"github.com/gin-gonic/gin"
@@ -23,26 +21,45 @@ type UserHandler struct {
 	UserService         domain.LoginService
 	RefreshTokenUseCase domain.RefreshTokenService
 	AuditLogService     services.AuditLogService
+	SessionService      services.SessionService
+	TwoFactorService    services.TwoFactorService
+	LoginGuardService   services.LoginGuardService
+	VerificationService services.EmailVerificationService
+	OIDCService         services.OIDCService
+	CaptchaService      services.CaptchaService
+	LoginRiskService    services.LoginRiskService
+	IPPolicyService     services.IPAccessPolicyService
 }
 
-func NewUserHandler(userService domain.LoginService, refreshTokenService domain.RefreshTokenService, auditLogService services.AuditLogService) *UserHandler {
+func NewUserHandler(userService domain.LoginService, refreshTokenService domain.RefreshTokenService, auditLogService services.AuditLogService, sessionService services.SessionService, twoFactorService services.TwoFactorService, loginGuardService services.LoginGuardService, verificationService services.EmailVerificationService, oidcService services.OIDCService, captchaService services.CaptchaService, loginRiskService services.LoginRiskService, ipPolicyService services.IPAccessPolicyService) *UserHandler {
 	return &UserHandler{
 		UserService:         userService,
 		RefreshTokenUseCase: refreshTokenService,
 		AuditLogService:     auditLogService,
+		SessionService:      sessionService,
+		TwoFactorService:    twoFactorService,
+		LoginGuardService:   loginGuardService,
+		VerificationService: verificationService,
+		OIDCService:         oidcService,
+		CaptchaService:      captchaService,
+		LoginRiskService:    loginRiskService,
+		IPPolicyService:     ipPolicyService,
 	}
 }
 
 // @Summary 用户登录
-// @Description 处理用户登录请求，验证凭据并返回访问token和刷新token
+// @Description 处理用户登录请求，验证凭据并返回访问token和刷新token；已启用双因素认证的用户返回挑战令牌。登录风险分达到阈值时才需要验证码，响应中的 captchaRequired 告知下次登录是否需要验证码
 // @Tags user
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginRequest true "登录请求参数"
 // @Success 200 {object} result.ResponseResult[dto.LoginResponse] "登录成功响应"
-// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
-// @Failure 404 {object} result.ResponseResult[string] "用户未找到"
-// @Failure 401 {object} result.ResponseResult[string] "凭据无效"
+// @Failure 400 {object} result.ResponseResult[dto.LoginFailureResponse] "请求参数错误、需要验证码或验证码错误"
+// @Failure 404 {object} result.ResponseResult[dto.LoginFailureResponse] "用户未找到"
+// @Failure 401 {object} result.ResponseResult[dto.LoginFailureResponse] "凭据无效"
+// @Failure 429 {object} result.ResponseResult[string] "登录失败次数过多，账号或 IP 被临时限制"
+// @Failure 403 {object} result.ResponseResult[string] "当前网络不允许登录该账号"
+// @Failure 409 {object} result.ResponseResult[string] "同时登录数已达上限"
 // @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
 // @Router /login [post]
 func (u *UserHandler) Login(c *gin.Context) {
@@ -55,67 +72,210 @@ func (u *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 验证验证码
-	session, err := captcha.Store.Get(c.Request, "captcha-session")
+	// 评估登录风险，风险分达到阈值时才需要验证码
+	clientIP := c.ClientIP()
+	risk, err := u.LoginRiskService.Assess(request.Email, clientIP, c.Request.UserAgent())
 	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, "Session Error")
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
-	captchaData, ok := session.Values[captcha.CaptchaSessionKey].(map[string]interface{})
-	if !ok {
-		result.ErrorResponse(c, http.StatusBadRequest, "Verification code has expired, please refresh")
+	if risk.CaptchaRequired {
+		if request.CaptchaID == "" || request.Captcha == "" {
+			result.ErrorResponseWithData(c, http.StatusBadRequest, "Verification code required", &dto.LoginFailureResponse{CaptchaRequired: true})
+			return
+		}
+		// 答案保存在服务端且只能校验一次
+		if err := u.CaptchaService.Verify(request.CaptchaID, request.Captcha); err != nil {
+			result.ErrorResponseWithData(c, http.StatusBadRequest, err.Error(), &dto.LoginFailureResponse{CaptchaRequired: true})
+			return
+		}
+	}
+
+	// 检查邮箱与客户端 IP 是否处于退避或锁定状态
+	if err := u.LoginGuardService.Check(request.Email, clientIP); err != nil {
+		respondLoginBlocked(c, err)
 		return
 	}
 
-	// 检查时间有效性
-	generateTime, ok := captchaData["time"].(int64)
-	if !ok || time.Now().Unix()-generateTime > 300 {
-		result.ErrorResponse(c, http.StatusBadRequest, "Verification code has expired, please refresh")
+	// 通过邮箱查找用户
+	user, err := u.UserService.GetUserByEmail(c, request.Email)
+	if err != nil {
+		u.LoginGuardService.RecordFailure(request.Email, clientIP)
+		u.respondLoginFailure(c, http.StatusNotFound, "User not found with given email", request.Email)
 		return
 	}
 
-	storedAnswer, ok := captchaData["answer"].(int)
-	if !ok {
-		result.ErrorResponse(c, http.StatusInternalServerError, "Verification code data error")
+	// 验证密码
+	if util.ComparePassword(user.PassWord, request.Password) != nil {
+		u.LoginGuardService.RecordFailure(request.Email, clientIP)
+		u.respondLoginFailure(c, http.StatusUnauthorized, "Invalid credentials", request.Email)
 		return
 	}
+	u.LoginGuardService.RecordSuccess(request.Email, clientIP)
 
-	userAnswer, err := strconv.Atoi(strings.TrimSpace(request.Captcha))
-	if err != nil || userAnswer != storedAnswer {
-		result.ErrorResponse(c, http.StatusBadRequest, "Incorrect verification code")
+	// 待验证邮箱或已禁用的账号不能登录
+	if !checkUserStatus(c, &user) {
 		return
 	}
 
-	// 通过邮箱查找用户
-	user, err := u.UserService.GetUserByEmail(c, request.Email)
+	// 启用双因素认证时仅返回挑战令牌，需通过 /login/2fa 提交验证码换取令牌
+	twoFactorEnabled, err := u.TwoFactorService.IsEnabled(user.ID)
 	if err != nil {
-		result.ErrorResponse(c, http.StatusNotFound, "User not found with given email")
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if twoFactorEnabled {
+		challengeToken, err := u.TwoFactorService.CreateChallenge(&user)
+		if err != nil {
+			result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		result.SuccessResponse(c, "Two-factor authentication required", &dto.LoginResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
 		return
 	}
 
-	// 验证密码
-	if util.ComparePassword(user.PassWord, request.Password) != nil {
-		result.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+	loginResponse, err := u.completeLogin(c, &user, request.Device)
+	if err != nil {
+		result.ErrorResponse(c, loginErrorStatus(err), err.Error())
 		return
 	}
 
-	// 创建访问token
-	accessToken, err := u.RefreshTokenUseCase.CreateAccessToken(&user, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
+	// 返回成功响应
+	result.SuccessResponse(c, "Login successful", loginResponse)
+}
+
+// @Summary 双因素认证登录
+// @Description 使用登录接口返回的挑战令牌与 TOTP 验证码（或恢复码）换取访问token和刷新token
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorLoginRequest true "双因素认证登录请求参数"
+// @Success 200 {object} result.ResponseResult[dto.LoginResponse] "登录成功响应"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 401 {object} result.ResponseResult[string] "挑战令牌无效或验证码错误"
+// @Failure 403 {object} result.ResponseResult[string] "当前网络不允许登录该账号"
+// @Failure 409 {object} result.ResponseResult[string] "同时登录数已达上限"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /login/2fa [post]
+func (u *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var request dto.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := u.TwoFactorService.VerifyChallenge(request.ChallengeToken, request.Code)
 	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		result.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if !checkUserStatus(c, user) {
 		return
 	}
 
-	// 创建刷新token
-	refreshToken, err := u.RefreshTokenUseCase.CreateRefreshToken(&user, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
+	loginResponse, err := u.completeLogin(c, user, request.Device)
 	if err != nil {
+		result.ErrorResponse(c, loginErrorStatus(err), err.Error())
+		return
+	}
+
+	result.SuccessResponse(c, "Login successful", loginResponse)
+}
+
+// respondLoginBlocked 登录被退避或锁定时返回 429 并通过 Retry-After 告知等待秒数
+func respondLoginBlocked(c *gin.Context, err error) {
+	var blocked *services.LoginBlockedError
+	if !errors.As(err, &blocked) {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	c.Header("Retry-After", strconv.Itoa(int(blocked.RetryAfter.Seconds())+1))
+	result.ErrorResponse(c, http.StatusTooManyRequests, blocked.Error())
+}
 
-	loginResponse := dto.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+// respondLoginFailure 返回登录失败响应，并按记录失败后的风险告知下次登录是否需要验证码
+func (u *UserHandler) respondLoginFailure(c *gin.Context, code int, message, email string) {
+	result.ErrorResponseWithData(c, code, message, &dto.LoginFailureResponse{
+		CaptchaRequired: u.captchaRequiredNext(c, email),
+	})
+}
+
+// captchaRequiredNext 重新评估当前设备下次登录是否需要验证码，评估失败时按需要处理
+func (u *UserHandler) captchaRequiredNext(c *gin.Context, email string) bool {
+	risk, err := u.LoginRiskService.Assess(email, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return true
+	}
+	return risk.CaptchaRequired
+}
+
+// checkUserStatus 校验账号状态是否允许登录，不允许时返回 403，返回值表示是否可以继续登录
+func checkUserStatus(c *gin.Context, user *entity.User) bool {
+	if message := userStatusError(user); message != "" {
+		result.ErrorResponse(c, http.StatusForbidden, message)
+		return false
+	}
+	return true
+}
+
+// userStatusError 返回账号状态不允许登录的原因，允许登录时为空
+func userStatusError(user *entity.User) string {
+	switch user.Status {
+	case entity.UserStatusPending:
+		return "Email address has not been verified"
+	case entity.UserStatusDisabled:
+		return "Account has been disabled"
+	}
+	return ""
+}
+
+// respondPasswordPolicy 密码不满足安全策略时返回 400 及全部违规项，返回值表示是否已处理
+func respondPasswordPolicy(c *gin.Context, err error) bool {
+	var policyErr *util.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	result.ErrorResponseWithData(c, http.StatusBadRequest, "密码不符合安全策略", policyErr)
+	return true
+}
+
+// loginErrorStatus 登录收尾失败时的状态码：IP 不满足访问策略返回 403，并发会话数达到上限返回 409
+func loginErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrIPNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrSessionLimitExceeded):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// completeLogin 登记登录会话、签发访问token和刷新token并记录登录审计日志
+func (u *UserHandler) completeLogin(c *gin.Context, user *entity.User, device string) (*dto.LoginResponse, error) {
+	// 校验 IP 访问策略，密码、双因素与单点登录都在此统一拦截
+	if err := u.IPPolicyService.Check(user.ID, c.ClientIP()); err != nil {
+		return nil, err
+	}
+
+	// 登记登录会话
+	userSession, err := u.SessionService.Create(user, c.ClientIP(), c.Request.UserAgent(), device)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建访问token
+	accessToken, err := u.RefreshTokenUseCase.CreateAccessToken(user, userSession.SessionID, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建刷新token
+	refreshToken, err := u.RefreshTokenUseCase.CreateRefreshToken(user, userSession.SessionID, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
+	if err != nil {
+		return nil, err
 	}
 
 	// 记录登录审计日志
@@ -128,8 +288,12 @@ func (u *UserHandler) Login(c *gin.Context) {
 		Description:  "用户登录: " + user.Email,
 	})
 
-	// 返回成功响应
-	result.SuccessResponse(c, "Login successful", &loginResponse)
+	return &dto.LoginResponse{
+		AccessToken:            accessToken,
+		RefreshToken:           refreshToken,
+		PasswordChangeRequired: util.IsPasswordExpired(user.PasswordExpiryBase()),
+		CaptchaRequired:        u.captchaRequiredNext(c, user.Email),
+	}, nil
 }
 
 // @Summary 用户注册
@@ -159,6 +323,12 @@ func (u *UserHandler) Signup(c *gin.Context) {
 		return
 	}
 
+	// 校验密码策略
+	if err := util.ValidatePassword(request.Password, request.Email); err != nil {
+		respondPasswordPolicy(c, err)
+		return
+	}
+
 	// 加密密码
 	encryptedPassword, err := util.HashPassword(request.Password)
 	if err != nil {
@@ -169,10 +339,16 @@ func (u *UserHandler) Signup(c *gin.Context) {
 	// 将加密后的密码赋值给注册信息
 	request.Password = string(encryptedPassword)
 
+	now := time.Now()
 	user := entity.User{
-		Name:     request.Name,
-		Email:    request.Email,
-		PassWord: request.Password,
+		Name:              request.Name,
+		Email:             request.Email,
+		PassWord:          request.Password,
+		PasswordChangedAt: &now,
+		Status:            entity.UserStatusActive,
+	}
+	if config.CfgEmailVerification.Enabled {
+		user.Status = entity.UserStatusPending
 	}
 
 	// 将用户数据插入到数据库
@@ -182,15 +358,35 @@ func (u *UserHandler) Signup(c *gin.Context) {
 		return
 	}
 
+	// 需要验证邮箱时只发送验证邮件，验证后才能登录
+	if user.Status == entity.UserStatusPending {
+		if err := u.VerificationService.Send(&user); err != nil {
+			result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		u.auditSignup(&user)
+		result.SuccessResponse(c, "Signup successful, please check your email to verify your account", &dto.SignupResponse{
+			VerificationRequired: true,
+		})
+		return
+	}
+
+	// 登记登录会话
+	userSession, err := u.SessionService.Create(&user, c.ClientIP(), c.Request.UserAgent(), "")
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	// 创建accessToken
-	accessToken, err := u.RefreshTokenUseCase.CreateAccessToken(&user, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
+	accessToken, err := u.RefreshTokenUseCase.CreateAccessToken(&user, userSession.SessionID, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
 	if err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// 创建refreshToken
-	refreshToken, err := u.RefreshTokenUseCase.CreateRefreshToken(&user, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
+	refreshToken, err := u.RefreshTokenUseCase.CreateRefreshToken(&user, userSession.SessionID, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
 	if err != nil {
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -201,7 +397,13 @@ func (u *UserHandler) Signup(c *gin.Context) {
 		RefreshToken: refreshToken,
 	}
 
-	// 记录注册审计日志
+	u.auditSignup(&user)
+
+	result.SuccessResponse(c, "Signup successful", &signupResponse)
+}
+
+// auditSignup 记录注册审计日志
+func (u *UserHandler) auditSignup(user *entity.User) {
 	u.AuditLogService.Create(&entity.AuditLog{
 		OperatorID:   user.ID,
 		OperatorName: user.Name,
@@ -210,48 +412,57 @@ func (u *UserHandler) Signup(c *gin.Context) {
 		TargetID:     user.ID,
 		Description:  "用户注册: " + user.Email,
 	})
-
-	result.SuccessResponse(c, "Signup successful", &signupResponse)
 }
 
-// @Summary 验证码
-// @Description 生成验证码图片防止反复登录
+// @Summary 验证邮箱
+// @Description 提交注册验证邮件中的一次性令牌，验证成功后账号激活
 // @Tags user
 // @Accept json
-// @Produce png
-// @Success 200 {file} binary "验证码图片"
-// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
-// @Router /captcha [get]
-func (u *UserHandler) GenerateMathCaptcha(c *gin.Context) {
-	session, err := captcha.Store.Get(c.Request, "captcha-session")
-	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, "Session Error")
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "邮箱验证请求参数"
+// @Success 200 {object} result.ResponseResult[string] "验证成功"
+// @Failure 400 {object} result.ResponseResult[string] "验证链接无效或已过期"
+// @Router /email/verify [post]
+func (u *UserHandler) VerifyEmail(c *gin.Context) {
+	var request dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	problem := captcha.GenerateMathProblem()
-	imageData, err := captcha.GenerateCaptchaImageBytes(problem.Question)
-	if err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate verification code image")
+	if err := u.VerificationService.Verify(request.Token); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	session.Values[captcha.CaptchaSessionKey] = map[string]interface{}{
-		"answer": problem.Answer,
-		"type":  int(problem.Type),
-		"time":  time.Now().Unix(),
-	}
-	if err := session.Save(c.Request, c.Writer); err != nil {
-		result.ErrorResponse(c, http.StatusInternalServerError, "Failed to save session")
+	result.SimpleSuccessResponse(c, "Email verified successfully")
+}
+
+// @Summary 验证码
+// @Description 按配置的类型生成验证码（算术题图片、扭曲字母数字图片或 WAV 音频），验证码 ID 通过 X-Captcha-Id 响应头返回，登录时与答案一起提交
+// @Tags user
+// @Produce png
+// @Produce audio/wav
+// @Success 200 {file} binary "验证码图片或音频"
+// @Header 200 {string} X-Captcha-Id "验证码ID"
+// @Header 200 {string} X-Captcha-Type "验证码类型"
+// @Failure 500 {object} result.ResponseResult[string] "服务器内部错误"
+// @Router /captcha [get]
+func (u *UserHandler) GenerateCaptcha(c *gin.Context) {
+	challenge, err := u.CaptchaService.Generate()
+	if err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate verification code")
 		return
 	}
 
-	c.Header("Content-Type", "image/png")
-	c.Data(http.StatusOK, "image/png", imageData)
+	c.Header("X-Captcha-Id", challenge.ID)
+	c.Header("X-Captcha-Type", challenge.Type)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, challenge.ContentType, challenge.Content)
 }
 
 // @Summary 用户登出
-// @Description 用户登出系统，失效当前 token
+// @Description 用户登出系统，撤销当前会话
 // @Tags user
 // @Produce json
 // @Success 200 {object} result.ResponseResult[string] "登出成功"
@@ -267,17 +478,10 @@ func (u *UserHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	// 如果 Redis 启用，将当前 token 加入黑名单
-	authHeader := c.Request.Header.Get("Authorization")
-	if authHeader != "" && config.CfgRedis.Enabled && global.G_REDIS != nil {
-		var authToken string
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			authToken = strings.TrimPrefix(authHeader, "Bearer ")
-		} else {
-			authToken = authHeader
-		}
-		global.G_REDIS.Set(c.Request.Context(), "token_blacklist:"+authToken, true,
-			time.Duration(config.CfgToken.AccessTokenExpiryHour)*time.Hour)
+	// 撤销当前会话，访问令牌与刷新令牌随之失效
+	if err := u.SessionService.Revoke(userID, c.GetString("session_id"), userID); err != nil {
+		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	u.AuditLogService.Create(&entity.AuditLog{
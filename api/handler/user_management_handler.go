@@ -56,6 +56,7 @@ func (h *UserManagementHandler) ListUsers(c *gin.Context) {
 			ID:      u.ID,
 			Name:    u.Name,
 			Email:   u.Email,
+			Status:  u.Status,
 			RoleIDs: roleIDs,
 			Roles:   roleNamesMap[u.ID],
 		}
@@ -96,6 +97,7 @@ func (h *UserManagementHandler) GetUser(c *gin.Context) {
 		ID:      user.ID,
 		Name:    user.Name,
 		Email:   user.Email,
+		Status:  user.Status,
 		RoleIDs: rids,
 		Roles:   roleNames,
 	})
@@ -119,9 +121,12 @@ func (h *UserManagementHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	user, err := h.userMgmt.Create(&req, operatorID)
 	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -130,6 +135,7 @@ func (h *UserManagementHandler) CreateUser(c *gin.Context) {
 		ID:      user.ID,
 		Name:    user.Name,
 		Email:   user.Email,
+		Status:  user.Status,
 		RoleIDs: req.RoleIDs,
 	})
 }
@@ -160,9 +166,17 @@ func (h *UserManagementHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
+	if _, impersonating := c.Get("actor_id"); impersonating && req.Password != "" {
+		result.ErrorResponse(c, http.StatusForbidden, "模拟登录期间不能修改用户密码")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
 	user, err := h.userMgmt.Update(id, &req, operatorID)
 	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		result.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -171,6 +185,7 @@ func (h *UserManagementHandler) UpdateUser(c *gin.Context) {
 		ID:      user.ID,
 		Name:    user.Name,
 		Email:   user.Email,
+		Status:  user.Status,
 		RoleIDs: req.RoleIDs,
 	})
 }
@@ -192,7 +207,7 @@ func (h *UserManagementHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	operatorID := c.GetUint64("user_id")
+	operatorID := currentOperatorID(c)
 	if id == operatorID {
 		result.ErrorResponse(c, http.StatusBadRequest, "不能删除自己")
 		return
@@ -204,3 +219,351 @@ func (h *UserManagementHandler) DeleteUser(c *gin.Context) {
 
 	result.SimpleSuccessResponse(c, "用户删除成功")
 }
+
+// ListUserSessions 用户会话列表
+// @Summary 用户会话列表
+// @Description 获取指定用户所有有效的登录会话
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[[]dto.SessionResponse] "获取成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/sessions [get]
+func (h *UserManagementHandler) ListUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := c.GetUint64("user_id")
+	sessions, err := h.userMgmt.ListSessions(id, operatorID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	responses := toSessionResponses(sessions, c.GetString("session_id"))
+	result.SuccessResponse(c, "获取会话列表成功", &responses)
+}
+
+// RevokeUserSessions 撤销用户全部会话
+// @Summary 撤销用户全部会话
+// @Description 撤销指定用户的全部登录会话，强制其在所有设备上重新登录
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[dto.RevokeSessionsResponse] "撤销成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/sessions [delete]
+func (h *UserManagementHandler) RevokeUserSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	count, err := h.userMgmt.RevokeSessions(id, "", operatorID)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "用户会话已撤销", &dto.RevokeSessionsResponse{Revoked: count})
+}
+
+// RevokeUserSession 撤销用户指定会话
+// @Summary 撤销用户指定会话
+// @Description 撤销指定用户的某个登录会话
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param sessionId path string true "会话ID"
+// @Success 200 {object} result.ResponseResult[string] "撤销成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/sessions/{sessionId} [delete]
+func (h *UserManagementHandler) RevokeUserSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if _, err := h.userMgmt.RevokeSessions(id, c.Param("sessionId"), operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "会话已撤销")
+}
+
+// ListUserTokens 用户个人访问令牌列表
+// @Summary 用户个人访问令牌列表
+// @Description 获取指定用户的个人访问令牌，包含已过期与已撤销的令牌
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[[]dto.PersonalAccessTokenResponse] "获取成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/tokens [get]
+func (h *UserManagementHandler) ListUserTokens(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	tokens, err := h.userMgmt.ListTokens(id, c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	responses := toPersonalAccessTokenResponses(tokens)
+	result.SuccessResponse(c, "获取令牌列表成功", &responses)
+}
+
+// RevokeUserToken 撤销用户个人访问令牌
+// @Summary 撤销用户个人访问令牌
+// @Description 撤销指定用户的某个个人访问令牌，立即失效
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param tokenId path int true "令牌ID"
+// @Success 200 {object} result.ResponseResult[string] "撤销成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID或令牌ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/tokens/{tokenId} [delete]
+func (h *UserManagementHandler) RevokeUserToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+	tokenID, err := strconv.ParseUint(c.Param("tokenId"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的令牌ID")
+		return
+	}
+
+	if err := h.userMgmt.RevokeToken(id, tokenID, currentOperatorID(c)); err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "令牌已撤销")
+}
+
+// ExplainUserPermission 说明用户的权限判定过程
+// @Summary 说明用户的权限判定过程
+// @Description 按与 RBAC 中间件相同的逻辑判定用户能否访问资源、路径或实体，返回生效角色、匹配的授权及读写标记、条件求值、组织范围路径比较与最终结论，用于排查“权限不足”。resource、path、entity_type 三选一
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param resource query string false "资源名，如 role:manage"
+// @Param path query string false "请求路径，如 /roles/1"
+// @Param method query string false "请求方法，默认 GET"
+// @Param entity_type query string false "实体类型"
+// @Param entity_id query string false "实体ID"
+// @Param action query string false "实体操作"
+// @Param ip query string false "用于求值 ip 条件的客户端 IP"
+// @Success 200 {object} result.ResponseResult[services.PermissionTrace] "获取成功"
+// @Failure 400 {object} result.ResponseResult[string] "请求参数错误"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/permissions/explain [get]
+func (h *UserManagementHandler) ExplainUserPermission(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+	var query dto.ExplainPermissionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	given := 0
+	for _, v := range []string{query.Resource, query.Path, query.EntityType} {
+		if v != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		result.ErrorResponse(c, http.StatusBadRequest, "resource、path、entity_type 需且只能指定一个")
+		return
+	}
+	if query.EntityType != "" && query.Action == "" {
+		result.ErrorResponse(c, http.StatusBadRequest, "实体判定需要指定 action")
+		return
+	}
+
+	trace, err := h.userMgmt.ExplainPermission(&services.ExplainRequest{
+		UserID:     id,
+		Resource:   query.Resource,
+		Path:       query.Path,
+		Method:     query.Method,
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Action:     query.Action,
+		Attrs:      &services.AccessAttributes{ClientIP: query.IP},
+	}, c.GetUint64("user_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	result.SuccessResponse(c, "获取权限判定说明成功", trace)
+}
+
+// ImpersonateUser 模拟用户登录
+// @Summary 模拟用户登录
+// @Description 以指定用户身份签发短期访问令牌，用于排查该用户看到的界面与数据；写操作同时要求操作者本人拥有权限，并以操作者身份记录审计
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[dto.ImpersonateResponse] "签发成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权模拟该用户"
+// @Router /users/{id}/impersonate [post]
+func (h *UserManagementHandler) ImpersonateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	token, expiresAt, err := h.userMgmt.Impersonate(id, operatorID, c.GetString("session_id"))
+	if err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	result.SuccessResponse(c, "模拟登录成功", &dto.ImpersonateResponse{
+		AccessToken: token,
+		ExpiresAt:   expiresAt.Format("2006-01-02 15:04:05"),
+		UserID:      id,
+		ActorID:     operatorID,
+	})
+}
+
+// ResetUserTwoFactor 重置用户双因素认证
+// @Summary 重置用户双因素认证
+// @Description 强制关闭指定用户的双因素认证并作废其恢复码，用户可重新绑定身份验证器
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[string] "重置成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID"
+// @Failure 403 {object} result.ResponseResult[string] "无权操作该用户"
+// @Router /users/{id}/2fa [delete]
+func (h *UserManagementHandler) ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.userMgmt.ResetTwoFactor(id, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "双因素认证已重置")
+}
+
+// UnlockUser 解除账号锁定
+// @Summary 解除账号锁定
+// @Description 解除因连续登录失败导致的账号临时锁定，并清除失败计数
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[string] "解锁成功"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID或账号未被锁定"
+// @Router /users/{id}/unlock [post]
+func (h *UserManagementHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.userMgmt.Unlock(id, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "账号已解锁")
+}
+
+// ResendVerification 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 为待验证邮箱的用户重新发送验证邮件，此前的验证链接失效
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[string] "验证邮件已发送"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID或用户不是待验证状态"
+// @Router /users/{id}/verification [post]
+func (h *UserManagementHandler) ResendVerification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.userMgmt.ResendVerification(id, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "验证邮件已发送")
+}
+
+// ActivateUser 激活用户
+// @Summary 激活用户
+// @Description 手动激活待验证邮箱或已禁用的用户
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[string] "用户已激活"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID或用户已是正常状态"
+// @Router /users/{id}/activate [post]
+func (h *UserManagementHandler) ActivateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.userMgmt.Activate(id, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "用户已激活")
+}
+
+// DisableUser 禁用用户
+// @Summary 禁用用户
+// @Description 禁用用户并撤销其全部登录会话，禁用后不能登录
+// @Tags 用户
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} result.ResponseResult[string] "用户已禁用"
+// @Failure 400 {object} result.ResponseResult[string] "无效的用户ID或用户已被禁用"
+// @Router /users/{id}/disable [post]
+func (h *UserManagementHandler) DisableUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	operatorID := currentOperatorID(c)
+	if err := h.userMgmt.Disable(id, operatorID); err != nil {
+		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result.SimpleSuccessResponse(c, "用户已禁用")
+}
@@ -87,6 +87,9 @@ func (h *UserProfileHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.profileService.ChangePassword(userID, &req); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		result.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -21,5 +21,7 @@ func CorsMiddleware() gin.HandlerFunc {
 	corsConfig.AllowCredentials = true
 	// 添加允许的自定义请求头
 	corsConfig.AddAllowHeaders("User-Agent", "Authorization")
+	// 允许前端读取验证码 ID
+	corsConfig.AddExposeHeaders("X-Captcha-Id", "X-Captcha-Type", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After")
 	return cors.New(corsConfig)
 }
@@ -1,19 +1,26 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/result"
-	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/internal/tokenutil"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JwtAuthMiddleware(secret string) gin.HandlerFunc {
+// passwordExpiredAllowed 密码过期后仍允许访问的路由
+var passwordExpiredAllowed = map[string]bool{
+	"/user/password": true,
+	"/logout":        true,
+}
+
+func JwtAuthMiddleware(secret string, sessionService services.SessionService, tokenService services.PersonalAccessTokenService, ipPolicyService services.IPAccessPolicyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头中获取 Authorization 字段
 		authHeader := c.Request.Header.Get("Authorization")
@@ -33,8 +40,26 @@ func JwtAuthMiddleware(secret string) gin.HandlerFunc {
 			authToken = authHeader
 		}
 
+		// pat_ 前缀的个人访问令牌，实际可访问的资源为令牌授权范围与用户当前权限的交集
+		if strings.HasPrefix(authToken, entity.PersonalAccessTokenPrefix) {
+			token, scopes, err := tokenService.Authenticate(authToken, c.ClientIP())
+			if err != nil {
+				result.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+				c.Abort()
+				return
+			}
+			if !checkClientIP(c, ipPolicyService, token.UserID) {
+				return
+			}
+			c.Set("user_id", token.UserID)
+			c.Set("token_id", token.ID)
+			c.Set("token_scopes", scopes)
+			c.Next()
+			return
+		}
+
 		// 验证令牌是否有效及过期
-		idStr, err := tokenutil.ExtractIDFromToken(authToken, secret)
+		claims, err := tokenutil.ParseAccessToken(authToken, secret)
 		if err != nil {
 			// 根据错误类型返回相应的错误信息
 			if err.Error() == "token is expired" {
@@ -46,26 +71,87 @@ func JwtAuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		// 检查 token 是否在黑名单中（登出后失效）
-		if config.CfgRedis.Enabled && global.G_REDIS != nil {
-			exists, err := global.G_REDIS.Exists(c.Request.Context(), "token_blacklist:"+authToken).Result()
-			if err == nil && exists > 0 {
-				result.ErrorResponse(c, http.StatusUnauthorized, "Token has been invalidated")
+		// 将十六进制用户ID解析为 uint64 并注入 context
+		userID, err := strconv.ParseUint(claims.ID, 16, 64)
+		if err != nil {
+			result.ErrorResponse(c, http.StatusUnauthorized, "invalid user id in token")
+			c.Abort()
+			return
+		}
+
+		// 模拟登录令牌的会话属于实际操作者
+		sessionOwnerID := userID
+		var actorID uint64
+		if claims.ActorID != "" {
+			actorID, err = strconv.ParseUint(claims.ActorID, 16, 64)
+			if err != nil {
+				result.ErrorResponse(c, http.StatusUnauthorized, "invalid actor id in token")
 				c.Abort()
 				return
 			}
+			sessionOwnerID = actorID
 		}
 
-		// 将十六进制用户ID解析为 uint64 并注入 context
-		userID, err := strconv.ParseUint(idStr, 16, 64)
-		if err != nil {
-			result.ErrorResponse(c, http.StatusUnauthorized, "invalid user id in token")
+		// 检查令牌所属会话是否仍然有效（登出或远程下线后失效）
+		if err := sessionService.Validate(claims.SessionID, sessionOwnerID); err != nil {
+			result.ErrorResponse(c, http.StatusUnauthorized, "Token has been invalidated")
 			c.Abort()
 			return
 		}
+
+		// 按会话所属用户校验 IP 访问策略，模拟登录时校验实际操作者所在的网络
+		if !checkClientIP(c, ipPolicyService, sessionOwnerID) {
+			return
+		}
+
+		// 密码超过最长有效期时只放行修改密码和登出
+		if claims.PasswordExpired && !passwordExpiredAllowed[c.FullPath()] {
+			result.ErrorResponse(c, http.StatusForbidden, "密码已过期，请先修改密码")
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", uint64(userID))
+		c.Set("session_id", claims.SessionID)
+		if actorID != 0 {
+			// 模拟登录：user_id 为被模拟的用户，actor_id 为实际操作者
+			c.Set("actor_id", actorID)
+		}
 
 		// 继续处理请求
 		c.Next()
 	}
 }
+
+// checkClientIP 校验客户端 IP 是否满足用户的访问策略，不满足时中止请求并返回 false
+func checkClientIP(c *gin.Context, ipPolicyService services.IPAccessPolicyService, userID uint64) bool {
+	err := ipPolicyService.Check(userID, c.ClientIP())
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, services.ErrIPNotAllowed) {
+		result.ErrorResponse(c, http.StatusForbidden, err.Error())
+	} else {
+		result.ErrorResponse(c, http.StatusInternalServerError, "IP 访问策略检查失败")
+	}
+	c.Abort()
+	return false
+}
+
+// SessionOnly 仅允许用户本人的登录会话访问，拒绝个人访问令牌与模拟登录令牌
+// 用于令牌管理、修改密码、双因素认证、模拟登录等账号安全操作，避免令牌泄露或模拟登录被用来扩大权限或接管账号
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("token_id"); exists {
+			result.ErrorResponse(c, http.StatusForbidden, "个人访问令牌不能执行该操作，请使用账号登录")
+			c.Abort()
+			return
+		}
+		if _, exists := c.Get("actor_id"); exists {
+			result.ErrorResponse(c, http.StatusForbidden, "模拟登录期间不能执行该操作")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
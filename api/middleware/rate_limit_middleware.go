@@ -1,109 +1,83 @@
 package middleware
 
 import (
-	"context"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/domain/result"
-	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/ratelimit"
+	"go.uber.org/zap"
 )
 
-// RateLimiter 限流器结构
-type RateLimiter struct {
-	mu      sync.Mutex
-	clients map[string]*ClientInfo
-}
-
-type ClientInfo struct {
-	Tokens     int
-	LastUpdate time.Time
-}
-
-var limiter *RateLimiter
-
-// InitRateLimiter 初始化限流器
-func InitRateLimiter() {
-	limiter = &RateLimiter{
-		clients: make(map[string]*ClientInfo),
-	}
-}
-
-// RateLimitMiddleware 基于内存的限流中间件
-func RateLimitMiddleware(requestsPerMinute int) gin.HandlerFunc {
-	if limiter == nil {
-		InitRateLimiter()
-	}
-
+// RateLimitMiddleware 按规则限流的中间件，同一请求命中多条规则时任意一条超限即拒绝
+// 响应头 X-RateLimit-* 取剩余额度最少的规则；存储不可用时放行请求，避免限流故障导致服务整体不可用
+func RateLimitMiddleware(store ratelimit.Store, rules []ratelimit.Rule, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		limiter.mu.Lock()
-		client, exists := limiter.clients[ip]
-		if !exists {
-			client = &ClientInfo{
-				Tokens:     requestsPerMinute - 1,
-				LastUpdate: time.Now(),
-			}
-			limiter.clients[ip] = client
-			limiter.mu.Unlock()
-			c.Next()
-			return
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
 		}
 
-		now := time.Now()
-		elapsed := now.Sub(client.LastUpdate)
-		client.LastUpdate = now
-
-		tokensToAdd := int(elapsed.Minutes() * float64(requestsPerMinute))
-		client.Tokens += tokensToAdd
-		if client.Tokens > requestsPerMinute {
-			client.Tokens = requestsPerMinute
+		var tightest *ratelimit.Result
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.Matches(path) {
+				continue
+			}
+			res, err := store.Allow(rateLimitKey(c, rule, path), rule.Limit, rule.Window)
+			if err != nil {
+				logger.Warn("rate limit store error", zap.String("rule", rule.Name), zap.Error(err))
+				continue
+			}
+			if !res.Allowed {
+				setRateLimitHeaders(c, res)
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+				result.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please try again later")
+				c.Abort()
+				return
+			}
+			if tightest == nil || res.Remaining < tightest.Remaining {
+				tightest = res
+			}
 		}
 
-		if client.Tokens <= 0 {
-			limiter.mu.Unlock()
-			result.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please try again later")
-			c.Abort()
-			return
+		if tightest != nil {
+			setRateLimitHeaders(c, tightest)
 		}
-
-		client.Tokens--
-		limiter.mu.Unlock()
 		c.Next()
 	}
 }
 
-// RateLimitMiddlewareWithRedis 基于 Redis 的限流中间件
-func RateLimitMiddlewareWithRedis(requestsPerMinute int) gin.HandlerFunc {
-	if global.G_REDIS == nil {
-		return RateLimitMiddleware(requestsPerMinute)
-	}
-
-	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		key := "rate_limit:" + ip
-
-		pipe := global.G_REDIS.Pipeline()
-		current := pipe.Get(context.Background(), key)
-		pipe.Expire(context.Background(), key, time.Minute)
-
-		_, err := pipe.Exec(context.Background())
-		if err != nil {
-			c.Next()
-			return
+// rateLimitKey 按规则的计数维度生成计数键，键中包含规则名，不同规则的计数互不影响
+func rateLimitKey(c *gin.Context, rule *ratelimit.Rule, path string) string {
+	prefix := "rate_limit:" + rule.Name + ":"
+	switch rule.KeyBy {
+	case ratelimit.KeyByRoute:
+		return prefix + "route:" + c.Request.Method + ":" + path
+	case ratelimit.KeyByUser:
+		if userID := c.GetUint64("user_id"); userID != 0 {
+			return prefix + "user:" + strconv.FormatUint(userID, 10)
 		}
+	}
+	return prefix + "ip:" + c.ClientIP()
+}
 
-		count, _ := current.Int()
-		if count >= requestsPerMinute {
-			result.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please try again later")
-			c.Abort()
+// setRateLimitHeaders 写入限流响应头，外层中间件已写入更严格的额度时保留外层的值
+func setRateLimitHeaders(c *gin.Context, res *ratelimit.Result) {
+	if existing := c.Writer.Header().Get("X-RateLimit-Remaining"); existing != "" {
+		if remaining, err := strconv.Atoi(existing); err == nil && remaining <= res.Remaining {
 			return
 		}
-
-		global.G_REDIS.Incr(context.Background(), key)
-		c.Next()
 	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+}
+
+// ceilSeconds 向上取整到秒，避免客户端按 0 秒立即重试
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
@@ -0,0 +1,20 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
+)
+
+// NewAccessRequestRouter 访问申请路由，任何登录用户都可以申请，审批资格由服务层按审批角色与组织范围判断
+// 提交、审批与撤回只允许用户本人的登录会话，避免个人访问令牌或模拟登录代为申请和审批
+func NewAccessRequestRouter(h *handler.AccessRequestHandler, routes *Routes) {
+	requests := routes.Group("/access-requests")
+	{
+		requests.GET("", Authenticated, h.ListReviewableRequests)
+		requests.GET("/mine", Authenticated, h.ListMyRequests)
+		requests.POST("", Authenticated, middleware.SessionOnly(), h.CreateRequest)
+		requests.POST("/:id/approve", Authenticated, middleware.SessionOnly(), h.ApproveRequest)
+		requests.POST("/:id/reject", Authenticated, middleware.SessionOnly(), h.RejectRequest)
+		requests.POST("/:id/cancel", Authenticated, middleware.SessionOnly(), h.CancelRequest)
+	}
+}
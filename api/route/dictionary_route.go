@@ -1,26 +1,25 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
 )
 
-func NewDictionaryRouter(h *handler.DictionaryHandler, group *gin.RouterGroup) {
-	dict := group.Group("/dict")
+func NewDictionaryRouter(h *handler.DictionaryHandler, routes *Routes) {
+	dict := routes.Group("/dict")
 	{
-		dict.GET("", h.ListDict)
-		dict.GET("/:id", h.GetDict)
-		dict.POST("", h.CreateDict)
-		dict.PUT("/:id", h.UpdateDict)
-		dict.DELETE("/:id", h.DeleteDict)
+		dict.GET("", "dict:read", h.ListDict)
+		dict.GET("/:id", "dict:read:detail", h.GetDict)
+		dict.POST("", "dict:create", h.CreateDict)
+		dict.PUT("/:id", "dict:update", h.UpdateDict)
+		dict.DELETE("/:id", "dict:delete", h.DeleteDict)
 
-		dict.GET("/:id/details", h.ListDictDetails)
-		dict.POST("/:id/details", h.CreateDictDetail)
-		dict.PUT("/:id/details/:detailId", h.UpdateDictDetail)
-		dict.DELETE("/:id/details/:detailId", h.DeleteDictDetail)
+		dict.GET("/:id/details", "dict:detail:read", h.ListDictDetails)
+		dict.POST("/:id/details", "dict:detail:create", h.CreateDictDetail)
+		dict.PUT("/:id/details/:detailId", "dict:detail:update", h.UpdateDictDetail)
+		dict.DELETE("/:id/details/:detailId", "dict:detail:delete", h.DeleteDictDetail)
 	}
 }
 
-func NewPublicDictionaryRouter(h *handler.DictionaryHandler, group *gin.RouterGroup) {
-	group.GET("/dict-info/:type", h.GetDictInfoByType)
+func NewPublicDictionaryRouter(h *handler.DictionaryHandler, routes *Routes) {
+	routes.GET("/dict-info/:type", Public, h.GetDictInfoByType)
 }
@@ -0,0 +1,12 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+)
+
+func NewIPAccessPolicyRouter(h *handler.IPAccessPolicyHandler, routes *Routes) {
+	routes.GET("/ip-policies", "ip_policy:read", h.ListPolicies)
+	routes.POST("/ip-policies", "ip_policy:manage", h.CreatePolicy)
+	routes.PUT("/ip-policies/:id", "ip_policy:manage", h.UpdatePolicy)
+	routes.DELETE("/ip-policies/:id", "ip_policy:manage", h.DeletePolicy)
+}
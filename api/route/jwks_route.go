@@ -0,0 +1,10 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+)
+
+// NewJWKSRouter 注册公开的 JWKS 路由
+func NewJWKSRouter(h *handler.JWKSHandler, routes *Routes) {
+	routes.GET("/.well-known/jwks.json", Public, h.GetJWKS)
+}
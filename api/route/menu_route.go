@@ -1,22 +1,20 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
-	"github.com/lyj404/gin-api-template/api/middleware"
 )
 
-func NewMenuRouter(menuHandler *handler.MenuHandler, rbac *middleware.RBACMiddleware, group *gin.RouterGroup) {
-	menus := group.Group("/menus")
+func NewMenuRouter(menuHandler *handler.MenuHandler, routes *Routes) {
+	menus := routes.Group("/menus")
 	{
-		menus.POST("", menuHandler.CreateMenu)
-		menus.GET("", rbac.CheckPermission("menu:read"), menuHandler.ListMenus)
-		menus.GET("/tree", rbac.CheckPermission("menu:read:tree"), menuHandler.GetMenuTree)
-		menus.GET("/:id", rbac.CheckPermission("menu:read:detail"), menuHandler.GetMenu)
-		menus.PUT("/:id", menuHandler.UpdateMenu)
-		menus.DELETE("/:id", menuHandler.DeleteMenu)
-		menus.POST("/:id/resources", menuHandler.BindResource)
-		menus.DELETE("/:id/resources/:resourceId", menuHandler.UnbindResource)
-		menus.GET("/:id/resources", menuHandler.GetMenuResources)
+		menus.POST("", "menu:create", menuHandler.CreateMenu)
+		menus.GET("", "menu:read", menuHandler.ListMenus)
+		menus.GET("/tree", "menu:read:tree", menuHandler.GetMenuTree)
+		menus.GET("/:id", "menu:read:detail", menuHandler.GetMenu)
+		menus.PUT("/:id", "menu:update", menuHandler.UpdateMenu)
+		menus.DELETE("/:id", "menu:delete", menuHandler.DeleteMenu)
+		menus.POST("/:id/resources", "menu:bind-resource", menuHandler.BindResource)
+		menus.DELETE("/:id/resources/:resourceId", "menu:unbind-resource", menuHandler.UnbindResource)
+		menus.GET("/:id/resources", "menu:list-resources", menuHandler.GetMenuResources)
 	}
 }
@@ -0,0 +1,25 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
+)
+
+// NewOIDCRouter 注册公开的单点登录路由
+func NewOIDCRouter(userHdlr *handler.UserHandler, routes *Routes) {
+	oidc := routes.Group("/oidc")
+	{
+		oidc.GET("/providers", Public, userHdlr.ListOIDCProviders)
+		oidc.GET("/:provider/login", Public, userHdlr.OIDCLogin)
+		oidc.GET("/:provider/callback", Public, userHdlr.OIDCCallback)
+	}
+}
+
+// NewExternalIdentityRouter 注册当前用户外部身份路由
+func NewExternalIdentityRouter(h *handler.ExternalIdentityHandler, routes *Routes) {
+	user := routes.Group("/user", middleware.SessionOnly())
+	{
+		user.GET("/identities", Authenticated, h.ListIdentities)
+		user.DELETE("/identities/:id", Authenticated, h.UnlinkIdentity)
+	}
+}
@@ -1,16 +1,14 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
-	"github.com/lyj404/gin-api-template/api/middleware"
 )
 
-func NewOrgUnitRouter(orgHdlr *handler.OrgUnitHandler, rbac *middleware.RBACMiddleware, group *gin.RouterGroup) {
-	group.POST("/org-units", orgHdlr.CreateOrgUnit)
-	group.PUT("/org-units/:id", orgHdlr.UpdateOrgUnit)
-	group.DELETE("/org-units/:id", orgHdlr.DeleteOrgUnit)
-	group.GET("/org-units/:id", rbac.CheckPermission("org:manage"), orgHdlr.GetOrgUnit)
-	group.GET("/org-units", rbac.CheckPermission("org:manage"), orgHdlr.ListOrgUnits)
-	group.GET("/org-units/tree", rbac.CheckPermission("org:manage"), orgHdlr.GetOrgTree)
+func NewOrgUnitRouter(orgHdlr *handler.OrgUnitHandler, routes *Routes) {
+	routes.POST("/org-units", "org:manage", orgHdlr.CreateOrgUnit)
+	routes.PUT("/org-units/:id", "org:manage", orgHdlr.UpdateOrgUnit)
+	routes.DELETE("/org-units/:id", "org:manage", orgHdlr.DeleteOrgUnit)
+	routes.GET("/org-units/:id", "org:manage", orgHdlr.GetOrgUnit)
+	routes.GET("/org-units", "org:manage", orgHdlr.ListOrgUnits)
+	routes.GET("/org-units/tree", "org:manage", orgHdlr.GetOrgTree)
 }
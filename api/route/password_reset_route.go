@@ -0,0 +1,14 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+)
+
+// NewPasswordResetRouter 注册公开的找回密码路由
+func NewPasswordResetRouter(h *handler.PasswordResetHandler, routes *Routes) {
+	password := routes.Group("/password")
+	{
+		password.POST("/forgot", Public, h.ForgotPassword)
+		password.POST("/reset", Public, h.ResetPassword)
+	}
+}
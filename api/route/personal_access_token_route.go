@@ -0,0 +1,16 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
+)
+
+// NewPersonalAccessTokenRouter 注册当前用户个人访问令牌管理路由（只允许登录会话访问）
+func NewPersonalAccessTokenRouter(h *handler.PersonalAccessTokenHandler, routes *Routes) {
+	user := routes.Group("/user", middleware.SessionOnly())
+	{
+		user.GET("/tokens", Authenticated, h.ListTokens)
+		user.POST("/tokens", Authenticated, h.CreateToken)
+		user.DELETE("/tokens/:id", Authenticated, h.RevokeToken)
+	}
+}
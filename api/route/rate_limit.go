@@ -0,0 +1,78 @@
+package route
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/api/middleware"
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/ratelimit"
+	"go.uber.org/zap"
+)
+
+var (
+	rateLimitStoreOnce sync.Once
+	rateLimitStore     ratelimit.Store
+)
+
+// getRateLimitStore 所有分组共享同一个计数存储，启用 Redis 时多实例共享计数
+func getRateLimitStore() ratelimit.Store {
+	rateLimitStoreOnce.Do(func() {
+		useRedis := config.CfgRedis.Enabled && global.G_REDIS != nil
+		if config.CfgRateLimit.Store == "memory" {
+			useRedis = false
+		}
+		if useRedis {
+			rateLimitStore = ratelimit.NewRedisStore(global.G_REDIS)
+		} else {
+			rateLimitStore = ratelimit.NewMemoryStore(config.CfgRateLimit.MaxKeys)
+		}
+	})
+	return rateLimitStore
+}
+
+// rateLimitRules 返回作用于指定分组的限流规则，server.RateLimit 作为全局按 IP 每分钟的默认规则
+// Limit 或 WindowSecond 不大于 0 的规则无法计数，跳过并记录警告日志
+func rateLimitRules(group string, logger *zap.Logger) []ratelimit.Rule {
+	var rules []ratelimit.Rule
+	if group == ratelimit.GroupGlobal && config.CfgServer.RateLimit > 0 {
+		rules = append(rules, ratelimit.Rule{
+			Name:   "default",
+			KeyBy:  ratelimit.KeyByIP,
+			Limit:  config.CfgServer.RateLimit,
+			Window: time.Minute,
+		})
+	}
+	for _, r := range config.CfgRateLimit.Rules {
+		if r.Group != group {
+			continue
+		}
+		if r.Limit <= 0 || r.WindowSecond <= 0 {
+			logger.Warn("限流规则的 Limit 与 WindowSecond 必须大于 0，已跳过",
+				zap.String("name", r.Name),
+				zap.String("group", r.Group),
+				zap.Int("limit", r.Limit),
+				zap.Int("window_second", r.WindowSecond))
+			continue
+		}
+		rules = append(rules, ratelimit.Rule{
+			Name:   r.Name,
+			KeyBy:  r.KeyBy,
+			Limit:  r.Limit,
+			Window: time.Duration(r.WindowSecond) * time.Second,
+			Paths:  r.Paths,
+		})
+	}
+	return rules
+}
+
+// RateLimitMiddleware 指定分组的限流中间件，分组未配置规则时直接放行
+func RateLimitMiddleware(group string, logger *zap.Logger) gin.HandlerFunc {
+	rules := rateLimitRules(group, logger)
+	if len(rules) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RateLimitMiddleware(getRateLimitStore(), rules, logger)
+}
@@ -0,0 +1,67 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/pkg/lib/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Handlers 注册业务路由所需的处理器
+type Handlers struct {
+	User          *handler.UserHandler
+	RefreshToken  *handler.RefreshTokenHandler
+	Role          *handler.RoleHandler
+	OrgUnit       *handler.OrgUnitHandler
+	AuditLog      *handler.AuditLogHandler
+	UserPerm      *handler.UserPermissionHandler
+	UserProfile   *handler.UserProfileHandler
+	Menu          *handler.MenuHandler
+	UserMgmt      *handler.UserManagementHandler
+	Resource      *handler.ResourceHandler
+	Dashboard     *handler.DashboardHandler
+	Dict          *handler.DictionaryHandler
+	Session       *handler.SessionHandler
+	JWKS          *handler.JWKSHandler
+	TwoFactor     *handler.TwoFactorHandler
+	PwdReset      *handler.PasswordResetHandler
+	Identity      *handler.ExternalIdentityHandler
+	Token         *handler.PersonalAccessTokenHandler
+	IPPolicy      *handler.IPAccessPolicyHandler
+	AccessRequest *handler.AccessRequestHandler
+}
+
+// Register 注册全部业务路由，各路由的资源声明记录在 registry 中
+// rbaccli 以零值 Handlers 调用以取得完整的路由表，因此这里只能组装路由，不能调用处理器
+func Register(router *gin.Engine, registry *Registry, h *Handlers, auth gin.HandlerFunc, logger *zap.Logger) {
+	// 注册公共路由（根路径）
+	publicGroup := router.Group("")
+	publicGroup.Use(RateLimitMiddleware(ratelimit.GroupPublic, logger))
+	public := registry.Routes(publicGroup)
+	NewUserRouter(h.User, h.RefreshToken, auth, public)
+	NewPublicDictionaryRouter(h.Dict, public)
+	NewJWKSRouter(h.JWKS, public)
+	NewPasswordResetRouter(h.PwdReset, public)
+	NewOIDCRouter(h.User, public)
+
+	// 注册受保护的路由
+	protectedGroup := router.Group("")
+	// 限流在鉴权之后执行，按用户计数的规则才能取到 user_id
+	protectedGroup.Use(auth, RateLimitMiddleware(ratelimit.GroupProtected, logger))
+	protected := registry.Routes(protectedGroup)
+	NewRoleRouter(h.Role, protected)
+	NewOrgUnitRouter(h.OrgUnit, protected)
+	NewAuditLogRouter(h.AuditLog, protected)
+	NewUserPermissionRouter(h.UserPerm, h.UserProfile, protected)
+	NewSessionRouter(h.Session, protected)
+	NewTwoFactorRouter(h.TwoFactor, protected)
+	NewExternalIdentityRouter(h.Identity, protected)
+	NewPersonalAccessTokenRouter(h.Token, protected)
+	NewMenuRouter(h.Menu, protected)
+	NewUserManagementRouter(h.UserMgmt, protected)
+	NewResourceRouter(h.Resource, protected)
+	NewDashboardRouter(h.Dashboard, protected)
+	NewDictionaryRouter(h.Dict, protected)
+	NewIPAccessPolicyRouter(h.IPPolicy, protected)
+	NewAccessRequestRouter(h.AccessRequest, protected)
+}
@@ -0,0 +1,314 @@
+package route
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lyj404/gin-api-template/api/middleware"
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
+)
+
+const (
+	// Public 公开路由，无需登录
+	Public = "@public"
+	// Authenticated 登录即可访问的路由，只操作本人数据或由业务层校验资格（如访问申请的审批人）
+	Authenticated = "@authenticated"
+	// ByRoute 不声明资源名，按资源表中 Pattern 与 Method 匹配该路由的 API 资源授权
+	ByRoute = "@route"
+)
+
+// RouteDecl 路由注册时声明的访问控制
+type RouteDecl struct {
+	Method   string
+	Path     string
+	Resource string // 资源名；Public、Authenticated 表示不做资源权限检查，ByRoute 表示按路由匹配资源
+}
+
+// RouteIssue 启动检查发现的路由问题
+type RouteIssue struct {
+	Method   string
+	Path     string
+	Resource string
+	Problem  string
+}
+
+// RouteResource 同一资源名对应的全部路由，以及据此推导出的资源 Pattern 与 Method
+type RouteResource struct {
+	Name    string
+	Pattern string
+	Method  string
+	Routes  []RouteDecl
+}
+
+// Registry 记录每条路由声明的资源名，用于启动检查与 rbaccli sync-routes
+type Registry struct {
+	rbac      *middleware.RBACMiddleware
+	routeMode bool // rbac.Mode 为 route 时声明了资源名的路由也按路由匹配资源
+	decls     map[string]RouteDecl
+}
+
+func NewRegistry(rbac *middleware.RBACMiddleware) *Registry {
+	return &Registry{
+		rbac:      rbac,
+		routeMode: config.CfgRBAC.Mode == "route",
+		decls:     make(map[string]RouteDecl),
+	}
+}
+
+// Routes 包装路由分组，通过它注册的路由必须声明资源名
+func (r *Registry) Routes(group *gin.RouterGroup) *Routes {
+	return &Routes{group: group, registry: r}
+}
+
+// Lookup 按方法和完整路由路径查找声明
+func (r *Registry) Lookup(method, fullPath string) (RouteDecl, bool) {
+	decl, ok := r.decls[method+" "+fullPath]
+	return decl, ok
+}
+
+// ResolveRoute 找出与请求路径匹配的已注册路由及 RBAC 中间件对它的检查方式，用于权限判定说明
+// 路径可以是实际请求路径（如 /roles/1）或路由模板；多个路由匹配时与 gin 一致，静态段优先于参数段，参数段优先于通配段
+func (r *Registry) ResolveRoute(method, path string) (*services.ResolvedRoute, bool) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var best RouteDecl
+	var bestRank []int
+	for _, decl := range r.decls {
+		if decl.Method != method {
+			continue
+		}
+		rank, ok := routeRank(decl.Path, path)
+		if !ok {
+			continue
+		}
+		if bestRank == nil || rankLess(rank, bestRank) || (!rankLess(bestRank, rank) && decl.Path < best.Path) {
+			best, bestRank = decl, rank
+		}
+	}
+	if bestRank == nil {
+		return nil, false
+	}
+
+	resolved := &services.ResolvedRoute{Route: best.Path}
+	switch {
+	case best.Resource == Public || best.Resource == Authenticated:
+		resolved.Unchecked = true
+	case best.Resource == ByRoute || r.routeMode:
+	default:
+		resolved.Resource = best.Resource
+	}
+	return resolved, true
+}
+
+// routeRank 路由模板匹配路径时返回每段的类型：0 静态段、1 参数段、2 通配段
+func routeRank(route, path string) ([]int, bool) {
+	routeSegs := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+	rank := make([]int, 0, len(routeSegs))
+	for i, seg := range routeSegs {
+		if strings.HasPrefix(seg, "*") {
+			return append(rank, 2), true
+		}
+		if i >= len(pathSegs) {
+			return nil, false
+		}
+		switch {
+		case seg == pathSegs[i]:
+			rank = append(rank, 0)
+		case strings.HasPrefix(seg, ":") && pathSegs[i] != "":
+			rank = append(rank, 1)
+		default:
+			return nil, false
+		}
+	}
+	return rank, len(routeSegs) == len(pathSegs)
+}
+
+// rankLess 逐段比较，第一个不同的段类型更具体的路由优先
+func rankLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) > len(b)
+}
+
+// Check 对照 gin 路由表检查：未通过 Routes 声明访问控制的路由、声明的资源在资源表中不存在的路由，
+// 以及按路由匹配资源时没有任何 API 资源覆盖的路由（这些路由任何人都无法访问）
+// swagger 文档路由由 SetUp 直接注册，不在检查范围内
+func (r *Registry) Check(routes gin.RoutesInfo, resources []entity.Resource) []RouteIssue {
+	known := make(map[string]bool, len(resources))
+	for _, res := range resources {
+		known[res.Name] = true
+	}
+	covered := func(method, path string) bool {
+		for i := range resources {
+			if resources[i].MatchesRoute(path, method) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var issues []RouteIssue
+	for _, rt := range routes {
+		if strings.HasPrefix(rt.Path, "/swagger/") {
+			continue
+		}
+		decl, ok := r.Lookup(rt.Method, rt.Path)
+		switch {
+		case !ok:
+			issues = append(issues, RouteIssue{Method: rt.Method, Path: rt.Path, Problem: "未声明访问控制"})
+		case decl.Resource == Public || decl.Resource == Authenticated:
+		case decl.Resource == ByRoute || r.routeMode:
+			if !covered(rt.Method, rt.Path) {
+				issues = append(issues, RouteIssue{Method: rt.Method, Path: rt.Path, Resource: decl.Resource, Problem: "没有匹配的资源"})
+			}
+		case !known[decl.Resource]:
+			issues = append(issues, RouteIssue{Method: rt.Method, Path: rt.Path, Resource: decl.Resource, Problem: "资源不存在"})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Method < issues[j].Method
+	})
+	return issues
+}
+
+// Resources 按资源名汇总 gin 路由表中声明了资源的路由，按资源名排序
+func (r *Registry) Resources(routes gin.RoutesInfo) []RouteResource {
+	byName := make(map[string]*RouteResource)
+	var names []string
+	for _, rt := range routes {
+		decl, ok := r.Lookup(rt.Method, rt.Path)
+		if !ok || decl.Resource == Public || decl.Resource == Authenticated || decl.Resource == ByRoute {
+			continue
+		}
+		res := byName[decl.Resource]
+		if res == nil {
+			res = &RouteResource{Name: decl.Resource}
+			byName[decl.Resource] = res
+			names = append(names, decl.Resource)
+		}
+		res.Routes = append(res.Routes, decl)
+	}
+	sort.Strings(names)
+
+	resources := make([]RouteResource, 0, len(names))
+	for _, name := range names {
+		res := byName[name]
+		sort.Slice(res.Routes, func(i, j int) bool {
+			if res.Routes[i].Path != res.Routes[j].Path {
+				return res.Routes[i].Path < res.Routes[j].Path
+			}
+			return res.Routes[i].Method < res.Routes[j].Method
+		})
+		res.Pattern, res.Method = routePattern(res.Routes)
+		resources = append(resources, *res)
+	}
+	return resources
+}
+
+// routePattern 推导覆盖全部路由的资源模式：只有一个路径时取该路径，否则取公共前缀加 /*；方法不一致时为 *
+func routePattern(routes []RouteDecl) (string, string) {
+	pattern := routes[0].Path
+	method := routes[0].Method
+	prefix := strings.Split(strings.Trim(pattern, "/"), "/")
+	for _, rt := range routes[1:] {
+		if rt.Method != method {
+			method = "*"
+		}
+		if rt.Path == pattern {
+			continue
+		}
+		segs := strings.Split(strings.Trim(rt.Path, "/"), "/")
+		n := 0
+		for n < len(prefix) && n < len(segs) && prefix[n] == segs[n] {
+			n++
+		}
+		prefix = prefix[:n]
+		pattern = ""
+	}
+	if pattern != "" {
+		return pattern, method
+	}
+	if len(prefix) == 0 || prefix[0] == "" {
+		return "/*", method
+	}
+	return "/" + strings.Join(prefix, "/") + "/*", method
+}
+
+// Routes 路由分组包装，注册路由时同时声明所需资源
+// 声明资源名时在处理函数前插入 RBAC 资源权限检查，ByRoute 或 route 模式下插入按路由匹配资源的检查
+type Routes struct {
+	group    *gin.RouterGroup
+	registry *Registry
+}
+
+// Group 创建子分组，handlers 作为分组中间件
+func (r *Routes) Group(relativePath string, handlers ...gin.HandlerFunc) *Routes {
+	return &Routes{group: r.group.Group(relativePath, handlers...), registry: r.registry}
+}
+
+func (r *Routes) GET(relativePath, resource string, handlers ...gin.HandlerFunc) {
+	r.handle(http.MethodGet, relativePath, resource, handlers)
+}
+
+func (r *Routes) POST(relativePath, resource string, handlers ...gin.HandlerFunc) {
+	r.handle(http.MethodPost, relativePath, resource, handlers)
+}
+
+func (r *Routes) PUT(relativePath, resource string, handlers ...gin.HandlerFunc) {
+	r.handle(http.MethodPut, relativePath, resource, handlers)
+}
+
+func (r *Routes) DELETE(relativePath, resource string, handlers ...gin.HandlerFunc) {
+	r.handle(http.MethodDelete, relativePath, resource, handlers)
+}
+
+// handle 注册路由并登记声明；权限检查插在最后一个处理函数之前，路由自带的中间件（如 SessionOnly）先执行
+func (r *Routes) handle(method, relativePath, resource string, handlers []gin.HandlerFunc) {
+	if resource == "" {
+		panic("路由 " + method + " " + relativePath + " 未声明资源名")
+	}
+	switch {
+	case resource == Public || resource == Authenticated:
+	case resource == ByRoute || r.registry.routeMode:
+		handlers = beforeLast(handlers, r.registry.rbac.CheckRoute())
+	default:
+		handlers = beforeLast(handlers, r.registry.rbac.CheckPermission(resource))
+	}
+	r.group.Handle(method, relativePath, handlers...)
+
+	fullPath := joinPaths(r.group.BasePath(), relativePath)
+	r.registry.decls[method+" "+fullPath] = RouteDecl{Method: method, Path: fullPath, Resource: resource}
+}
+
+func beforeLast(handlers []gin.HandlerFunc, check gin.HandlerFunc) []gin.HandlerFunc {
+	last := len(handlers) - 1
+	chain := make([]gin.HandlerFunc, 0, len(handlers)+1)
+	chain = append(chain, handlers[:last]...)
+	return append(chain, check, handlers[last])
+}
+
+// joinPaths 与 gin 拼接分组路径的规则一致，保证登记的路径与路由表中的相同
+func joinPaths(basePath, relativePath string) string {
+	if relativePath == "" {
+		return basePath
+	}
+	joined := path.Join(basePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
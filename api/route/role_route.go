@@ -1,21 +1,25 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
-	"github.com/lyj404/gin-api-template/api/middleware"
 )
 
-func NewRoleRouter(roleHdlr *handler.RoleHandler, rbac *middleware.RBACMiddleware, group *gin.RouterGroup) {
-	group.POST("/roles", rbac.CheckPermission("role:manage"), roleHdlr.CreateRole)
-	group.PUT("/roles/:id", rbac.CheckPermission("role:manage"), roleHdlr.UpdateRole)
-	group.DELETE("/roles/:id", rbac.CheckPermission("role:manage"), roleHdlr.DeleteRole)
-	group.GET("/roles/:id", rbac.CheckPermission("role:manage"), roleHdlr.GetRole)
-	group.GET("/roles", rbac.CheckPermission("role:manage"), roleHdlr.ListRoles)
-	group.POST("/roles/:id/resources", rbac.CheckPermission("role:manage"), roleHdlr.BindResource)
-	group.DELETE("/roles/:id/resources/:resourceId", rbac.CheckPermission("role:manage"), roleHdlr.UnbindResource)
-	group.GET("/roles/:id/resources", rbac.CheckPermission("role:list-resources"), roleHdlr.GetRoleResources)
-	group.POST("/roles/:id/menus", rbac.CheckPermission("role:manage"), roleHdlr.BindMenu)
-	group.DELETE("/roles/:id/menus/:menuId", rbac.CheckPermission("role:manage"), roleHdlr.UnbindMenu)
-	group.GET("/roles/:id/menus", rbac.CheckPermission("role:manage"), roleHdlr.GetRoleMenus)
+func NewRoleRouter(roleHdlr *handler.RoleHandler, routes *Routes) {
+	routes.POST("/roles", "role:manage", roleHdlr.CreateRole)
+	routes.PUT("/roles/:id", "role:manage", roleHdlr.UpdateRole)
+	routes.DELETE("/roles/:id", "role:manage", roleHdlr.DeleteRole)
+	routes.GET("/roles/:id", "role:manage", roleHdlr.GetRole)
+	routes.GET("/roles", "role:manage", roleHdlr.ListRoles)
+	routes.POST("/roles/:id/resources", "role:manage", roleHdlr.BindResource)
+	routes.DELETE("/roles/:id/resources/:resourceId", "role:manage", roleHdlr.UnbindResource)
+	routes.GET("/roles/:id/resources", "role:list-resources", roleHdlr.GetRoleResources)
+	routes.POST("/roles/:id/menus", "role:manage", roleHdlr.BindMenu)
+	routes.DELETE("/roles/:id/menus/:menuId", "role:manage", roleHdlr.UnbindMenu)
+	routes.GET("/roles/:id/menus", "role:manage", roleHdlr.GetRoleMenus)
+	routes.POST("/roles/:id/parents", "role:manage", roleHdlr.AddParent)
+	routes.DELETE("/roles/:id/parents/:parentId", "role:manage", roleHdlr.RemoveParent)
+	routes.GET("/roles/:id/parents", "role:manage", roleHdlr.GetRoleParents)
+	routes.POST("/users/:id/roles", "user:assign-role", roleHdlr.AssignUserRole)
+	routes.DELETE("/users/:id/roles/:roleId", "user:revoke-role", roleHdlr.RevokeUserRole)
+	routes.GET("/users/:id/roles", "user:list-roles", roleHdlr.ListUserRoles)
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/lyj404/gin-api-template/api/middleware"
 	"github.com/lyj404/gin-api-template/config"
 	_ "github.com/lyj404/gin-api-template/docs"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/pkg/lib/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,6 +21,11 @@ func SetUp(timeout time.Duration, logger *zap.Logger) *gin.Engine {
 	gin.SetMode(config.CfgServer.Mode)
 	router := gin.New()
 
+	// 只采信受信任代理转发的 X-Forwarded-For，否则客户端可伪造 IP 绕过 IP 访问策略与限流
+	if err := router.SetTrustedProxies(config.CfgServer.TrustedProxies); err != nil {
+		logger.Fatal("受信任代理配置无效", zap.Error(err))
+	}
+
 	// 使用TraceID中间件（必须在最前面）
 	router.Use(middleware.TraceIDMiddleware())
 
@@ -34,14 +41,8 @@ func SetUp(timeout time.Duration, logger *zap.Logger) *gin.Engine {
 	// 使用CORS中间件
 	router.Use(middleware.CorsMiddleware())
 
-	// 使用限流中间件（如果配置了限流）
-	if config.CfgServer.RateLimit > 0 {
-		if config.CfgRedis.Enabled {
-			router.Use(middleware.RateLimitMiddlewareWithRedis(config.CfgServer.RateLimit))
-		} else {
-			router.Use(middleware.RateLimitMiddleware(config.CfgServer.RateLimit))
-		}
-	}
+	// 使用全局限流中间件，公共与受保护分组的规则在注册路由时挂载
+	router.Use(RateLimitMiddleware(ratelimit.GroupGlobal, logger))
 
 	// 设置swagger路由
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -50,8 +51,8 @@ func SetUp(timeout time.Duration, logger *zap.Logger) *gin.Engine {
 }
 
 // JwtAuthMiddleware JWT 鉴权中间件
-func JwtAuthMiddleware() gin.HandlerFunc {
-	return middleware.JwtAuthMiddleware(config.CfgToken.AccessTokenSecret)
+func JwtAuthMiddleware(sessionService services.SessionService, tokenService services.PersonalAccessTokenService, ipPolicyService services.IPAccessPolicyService) gin.HandlerFunc {
+	return middleware.JwtAuthMiddleware(config.CfgToken.AccessTokenSecret, sessionService, tokenService, ipPolicyService)
 }
 
 // SetupFrontend 设置前端静态文件服务与 SPA 回退
@@ -0,0 +1,16 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
+)
+
+// NewSessionRouter 注册当前用户会话管理路由
+func NewSessionRouter(h *handler.SessionHandler, routes *Routes) {
+	user := routes.Group("/user", middleware.SessionOnly())
+	{
+		user.GET("/sessions", Authenticated, h.ListSessions)
+		user.DELETE("/sessions", Authenticated, h.RevokeOtherSessions)
+		user.DELETE("/sessions/:sessionId", Authenticated, h.RevokeSession)
+	}
+}
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
+)
+
+// NewTwoFactorRouter 注册当前用户双因素认证管理路由
+func NewTwoFactorRouter(h *handler.TwoFactorHandler, routes *Routes) {
+	tf := routes.Group("/user/2fa", middleware.SessionOnly())
+	{
+		tf.GET("", Authenticated, h.GetStatus)
+		tf.POST("/setup", Authenticated, h.Setup)
+		tf.GET("/qrcode", Authenticated, h.QRCode)
+		tf.POST("/confirm", Authenticated, h.Confirm)
+		tf.POST("/disable", Authenticated, h.Disable)
+		tf.POST("/recovery-codes", Authenticated, h.RegenerateRecoveryCodes)
+	}
+}
@@ -1,15 +1,27 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
 	"github.com/lyj404/gin-api-template/api/middleware"
 )
 
-func NewUserManagementRouter(h *handler.UserManagementHandler, rbac *middleware.RBACMiddleware, group *gin.RouterGroup) {
-	group.GET("/users", rbac.CheckPermission("user:read"), h.ListUsers)
-	group.GET("/users/:id", rbac.CheckPermission("user:read:detail"), h.GetUser)
-	group.POST("/users", rbac.CheckPermission("user:manage"), h.CreateUser)
-	group.PUT("/users/:id", rbac.CheckPermission("user:manage"), h.UpdateUser)
-	group.DELETE("/users/:id", rbac.CheckPermission("user:manage"), h.DeleteUser)
+func NewUserManagementRouter(h *handler.UserManagementHandler, routes *Routes) {
+	routes.GET("/users", "user:read", h.ListUsers)
+	routes.GET("/users/:id", "user:read:detail", h.GetUser)
+	routes.POST("/users", "user:create", h.CreateUser)
+	routes.PUT("/users/:id", "user:update", h.UpdateUser)
+	routes.DELETE("/users/:id", "user:delete", h.DeleteUser)
+	routes.GET("/users/:id/sessions", "user:sessions", h.ListUserSessions)
+	routes.DELETE("/users/:id/sessions", "user:sessions", h.RevokeUserSessions)
+	routes.DELETE("/users/:id/sessions/:sessionId", "user:sessions", h.RevokeUserSession)
+	routes.DELETE("/users/:id/2fa", "user:2fa:reset", h.ResetUserTwoFactor)
+	routes.POST("/users/:id/unlock", "user:unlock", h.UnlockUser)
+	routes.POST("/users/:id/verification", "user:verification", h.ResendVerification)
+	routes.POST("/users/:id/activate", "user:activate", h.ActivateUser)
+	routes.POST("/users/:id/disable", "user:disable", h.DisableUser)
+	routes.GET("/users/:id/tokens", "user:tokens", h.ListUserTokens)
+	routes.DELETE("/users/:id/tokens/:tokenId", "user:tokens", h.RevokeUserToken)
+	routes.GET("/users/:id/permissions/explain", "user:permissions:explain", h.ExplainUserPermission)
+	// 模拟登录只能由操作者本人的登录会话发起，不允许个人访问令牌或嵌套模拟
+	routes.POST("/users/:id/impersonate", "user:impersonate", middleware.SessionOnly(), h.ImpersonateUser)
 }
@@ -1,18 +1,18 @@
 package route
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
 )
 
 // NewUserPermissionRouter 注册用户权限相关路由
-func NewUserPermissionRouter(userPermHdlr *handler.UserPermissionHandler, userProfileHdlr *handler.UserProfileHandler, group *gin.RouterGroup) {
-	user := group.Group("/user")
+func NewUserPermissionRouter(userPermHdlr *handler.UserPermissionHandler, userProfileHdlr *handler.UserProfileHandler, routes *Routes) {
+	user := routes.Group("/user")
 	{
-		user.GET("/permissions", userPermHdlr.GetUserPermissions)
-		user.GET("/menus", userPermHdlr.GetUserMenus)
-		user.GET("/profile", userProfileHdlr.GetProfile)
-		user.PUT("/profile", userProfileHdlr.UpdateProfile)
-		user.PUT("/password", userProfileHdlr.ChangePassword)
+		user.GET("/permissions", Authenticated, userPermHdlr.GetUserPermissions)
+		user.GET("/menus", Authenticated, userPermHdlr.GetUserMenus)
+		user.GET("/profile", Authenticated, userProfileHdlr.GetProfile)
+		user.PUT("/profile", Authenticated, middleware.SessionOnly(), userProfileHdlr.UpdateProfile)
+		user.PUT("/password", Authenticated, middleware.SessionOnly(), userProfileHdlr.ChangePassword)
 	}
 }
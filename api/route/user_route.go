@@ -2,14 +2,17 @@ package route
 
 import (
 	"github.com/lyj404/gin-api-template/api/handler"
+	"github.com/lyj404/gin-api-template/api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewUserRouter(userHdlr *handler.UserHandler, refreshTokenHdlr *handler.RefreshTokenHandler, group *gin.RouterGroup) {
-	group.POST("/login", userHdlr.Login)
-	group.POST("/signup", userHdlr.Signup)
-	group.POST("/refresh-token", refreshTokenHdlr.RefreshToken)
-	group.GET("/captcha", userHdlr.GenerateMathCaptcha)
-	group.POST("/logout", JwtAuthMiddleware(), userHdlr.Logout)
+func NewUserRouter(userHdlr *handler.UserHandler, refreshTokenHdlr *handler.RefreshTokenHandler, auth gin.HandlerFunc, routes *Routes) {
+	routes.POST("/login", Public, userHdlr.Login)
+	routes.POST("/login/2fa", Public, userHdlr.LoginTwoFactor)
+	routes.POST("/signup", Public, userHdlr.Signup)
+	routes.POST("/email/verify", Public, userHdlr.VerifyEmail)
+	routes.POST("/refresh-token", Public, refreshTokenHdlr.RefreshToken)
+	routes.GET("/captcha", Public, userHdlr.GenerateCaptcha)
+	routes.POST("/logout", Authenticated, auth, middleware.SessionOnly(), userHdlr.Logout)
 }
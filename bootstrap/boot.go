@@ -4,7 +4,9 @@ import (
 	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/global"
 	"github.com/lyj404/gin-api-template/internal/idgen"
+	"github.com/lyj404/gin-api-template/internal/tokenutil"
 	"github.com/lyj404/gin-api-template/pkg/lib"
+	"github.com/lyj404/gin-api-template/util"
 )
 
 func Boot() {
@@ -13,6 +15,16 @@ func Boot() {
 		panic(err)
 	}
 
+	// 加载访问令牌签名密钥
+	if err := tokenutil.InitSigningKeys(); err != nil {
+		panic(err)
+	}
+
+	// 加载禁用密码列表
+	if err := util.LoadBannedPasswords(); err != nil {
+		panic(err)
+	}
+
 	// 初始化数据库
 	global.G_DB = lib.NewDataBase()
 
@@ -4,16 +4,12 @@ import (
 	"github.com/lyj404/gin-api-template/api/route"
 	"github.com/lyj404/gin-api-template/bootstrap"
 	"github.com/lyj404/gin-api-template/config"
-	"github.com/lyj404/gin-api-template/pkg/lib/captcha"
 )
 
 func main() {
 	// 初始化配置
 	config.InitConfig()
 
-	// 初始化验证码 Store
-	captcha.InitStore()
-
 	// 初始化数据库和缓存（用于设置全局变量）
 	bootstrap.Boot()
 
@@ -31,6 +27,9 @@ func main() {
 		bootstrap.CloseConnection()
 	}()
 
+	// 启动到期角色分配清理任务
+	app.ExpirySweeper.Start()
+
 	// 设置swagger文档
 	config.SetUpSwag()
 
@@ -0,0 +1,236 @@
+// mockoidc 本地调试用的模拟 OIDC 身份提供方，授权请求无需登录直接通过，用于联调单点登录流程。
+//
+//	go run ./cmd/mockoidc -addr :9000 -email alice@example.com
+//
+// 授权地址可携带 login_hint 参数临时指定登录邮箱。
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock-1"
+
+// authCode 已签发但尚未换取令牌的授权码
+type authCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	email         string
+	expiresAt     time.Time
+}
+
+type mockProvider struct {
+	issuer        string
+	clientID      string
+	clientSecret  string
+	email         string
+	name          string
+	emailVerified bool
+	key           *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "监听地址")
+	issuer := flag.String("issuer", "http://localhost:9000", "签发方地址，需与服务端配置的 Issuer 一致")
+	clientID := flag.String("client-id", "gin-api-template", "客户端ID")
+	clientSecret := flag.String("client-secret", "", "客户端密钥，为空时不校验")
+	email := flag.String("email", "alice@example.com", "默认登录邮箱")
+	name := flag.String("name", "Alice", "用户姓名")
+	emailVerified := flag.Bool("email-verified", true, "email_verified 声明")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &mockProvider{
+		issuer:        *issuer,
+		clientID:      *clientID,
+		clientSecret:  *clientSecret,
+		email:         *email,
+		name:          *name,
+		emailVerified: *emailVerified,
+		key:           key,
+		codes:         make(map[string]authCode),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", p.jwks)
+
+	log.Printf("mock OIDC provider listening on %s (issuer %s)", *addr, *issuer)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+func (p *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("response_type") != "code" || q.Get("client_id") != p.clientID || redirectURI == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	email := p.email
+	if hint := q.Get("login_hint"); hint != "" {
+		email = hint
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authCode{
+		clientID:      p.clientID,
+		redirectURI:   redirectURI,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		email:         email,
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	rq := target.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	target.RawQuery = rq.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || (p.clientSecret != "" && subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1) {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	ac, found := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+	if !found || time.Now().After(ac.expiresAt) || ac.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != ac.codeChallenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.issuer,
+		"sub":            "mock|" + ac.email,
+		"aud":            ac.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          ac.nonce,
+		"email":          ac.email,
+		"email_verified": p.emailVerified,
+		"name":           p.name,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (p *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
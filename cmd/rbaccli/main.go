@@ -2,24 +2,35 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"golang.org/x/term"
 
+	"github.com/lyj404/gin-api-template/api/middleware"
+	"github.com/lyj404/gin-api-template/api/route"
 	"github.com/lyj404/gin-api-template/bootstrap"
 	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib"
+	"github.com/lyj404/gin-api-template/service"
 	"github.com/lyj404/gin-api-template/util"
 	"gorm.io/gorm"
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("可用命令: create-admin, seed-resources, seed-menus, seed-dict")
+		fmt.Println("可用命令: create-admin, seed-resources, seed-menus, seed-dict, explain, sync-routes")
 		os.Exit(1)
 	}
 
@@ -34,6 +45,10 @@ func main() {
 		seedMenus()
 	case "seed-dict":
 		seedDictData()
+	case "explain":
+		explain(os.Args[2:])
+	case "sync-routes":
+		syncRoutes(os.Args[2:])
 	default:
 		fmt.Printf("未知命令: %s\n", command)
 		os.Exit(1)
@@ -220,6 +235,18 @@ func createDefaultResources(tx *gorm.DB) error {
 		{Name: "user:read:detail", Type: "api", Pattern: "/users/:id", Method: "GET", Description: "查看用户详情"},
 		{Name: "user:update", Type: "api", Pattern: "/users/:id", Method: "PUT", Description: "更新用户"},
 		{Name: "user:delete", Type: "api", Pattern: "/users/:id", Method: "DELETE", Description: "删除用户"},
+		{Name: "user:sessions", Type: "api", Pattern: "/users/:id/sessions", Method: "*", Description: "管理用户会话"},
+		{Name: "user:2fa:reset", Type: "api", Pattern: "/users/:id/2fa", Method: "DELETE", Description: "重置用户双因素认证"},
+		{Name: "user:unlock", Type: "api", Pattern: "/users/:id/unlock", Method: "POST", Description: "解除账号锁定"},
+		{Name: "user:verification", Type: "api", Pattern: "/users/:id/verification", Method: "POST", Description: "重发邮箱验证邮件"},
+		{Name: "user:activate", Type: "api", Pattern: "/users/:id/activate", Method: "POST", Description: "激活用户"},
+		{Name: "user:disable", Type: "api", Pattern: "/users/:id/disable", Method: "POST", Description: "禁用用户"},
+		{Name: "user:tokens", Type: "api", Pattern: "/users/:id/tokens", Method: "*", Description: "管理用户个人访问令牌"},
+		{Name: "user:impersonate", Type: "api", Pattern: "/users/:id/impersonate", Method: "POST", Description: "模拟用户登录"},
+		{Name: "user:permissions:explain", Type: "api", Pattern: "/users/:id/permissions/explain", Method: "GET", Description: "查看用户权限判定说明"},
+		{Name: "user:assign-role", Type: "api", Pattern: "/users/:id/roles", Method: "POST", Description: "为用户分配角色"},
+		{Name: "user:revoke-role", Type: "api", Pattern: "/users/:id/roles/:roleId", Method: "DELETE", Description: "撤销用户角色"},
+		{Name: "user:list-roles", Type: "api", Pattern: "/users/:id/roles", Method: "GET", Description: "查看用户角色列表"},
 
 		// API 资源 - 角色管理
 		{Name: "role:manage", Type: "api", Pattern: "/roles/*", Method: "*", Description: "角色管理"},
@@ -233,6 +260,10 @@ func createDefaultResources(tx *gorm.DB) error {
 		// API 资源 - 组织管理
 		{Name: "org:manage", Type: "api", Pattern: "/org-units/*", Method: "*", Description: "组织管理"},
 
+		// API 资源 - IP 访问策略
+		{Name: "ip_policy:read", Type: "api", Pattern: "/ip-policies", Method: "GET", Description: "查看 IP 访问策略"},
+		{Name: "ip_policy:manage", Type: "api", Pattern: "/ip-policies/*", Method: "*", Description: "管理 IP 访问策略"},
+
 		// API 资源 - 审计日志
 		{Name: "audit:read", Type: "api", Pattern: "/audit-logs", Method: "GET", Description: "查看审计日志"},
 		{Name: "audit:read:target", Type: "api", Pattern: "/audit-logs/target", Method: "GET", Description: "按目标查询审计日志"},
@@ -245,6 +276,9 @@ func createDefaultResources(tx *gorm.DB) error {
 		{Name: "menu:create", Type: "api", Pattern: "/menus", Method: "POST", Description: "创建菜单"},
 		{Name: "menu:update", Type: "api", Pattern: "/menus/:id", Method: "PUT", Description: "更新菜单"},
 		{Name: "menu:delete", Type: "api", Pattern: "/menus/:id", Method: "DELETE", Description: "删除菜单"},
+		{Name: "menu:bind-resource", Type: "api", Pattern: "/menus/:id/resources", Method: "POST", Description: "菜单绑定资源"},
+		{Name: "menu:unbind-resource", Type: "api", Pattern: "/menus/:id/resources/:resourceId", Method: "DELETE", Description: "菜单解绑资源"},
+		{Name: "menu:list-resources", Type: "api", Pattern: "/menus/:id/resources", Method: "GET", Description: "查看菜单资源列表"},
 
 		// API 资源 - 用户权限与菜单
 		{Name: "user:permissions", Type: "api", Pattern: "/user/permissions", Method: "GET", Description: "获取用户权限"},
@@ -372,11 +406,28 @@ func seedResources() {
 		os.Exit(1)
 	}
 
+	clearPermissionCaches()
+
 	var count int64
 	global.G_DB.Model(&entity.Resource{}).Count(&count)
 	fmt.Printf("资源初始化成功，当前资源总数: %d\n", count)
 }
 
+// clearPermissionCaches 直接修改了资源或角色绑定后，清除运行中的服务在 Redis 中缓存的 API 资源与用户权限
+func clearPermissionCaches() {
+	if !config.CfgRedis.Enabled {
+		return
+	}
+	if global.G_REDIS == nil {
+		global.G_REDIS = lib.InitRedis()
+	}
+	if err := service.ClearAllPermissionCaches(); err != nil {
+		fmt.Printf("清除权限缓存失败: %v，请手动删除 Redis 中的 permission:route-resources 与 user:permissions:* 键\n", err)
+		return
+	}
+	fmt.Println("已清除权限缓存")
+}
+
 func bindOrgScopeToRole(tx *gorm.DB, roleID, orgUnitID uint64, includeDescendants bool) error {
 	roleOrgScope := entity.RoleOrgScope{
 		RoleID:             roleID,
@@ -411,6 +462,7 @@ func seedMenus() {
 		fmt.Printf("菜单初始化失败: %v\n", err)
 		os.Exit(1)
 	}
+	clearPermissionCaches()
 
 	var count int64
 	global.G_DB.Model(&entity.Menu{}).Count(&count)
@@ -498,6 +550,14 @@ func seedDictData() {
 				{Label: "禁用", Value: "disabled", Sort: 2},
 			},
 		},
+		{
+			Name: "用户状态", Type: "user_status", Desc: "用户账号状态",
+			Items: []dictItem{
+				{Label: "待验证", Value: "pending", Sort: 1},
+				{Label: "正常", Value: "active", Sort: 2},
+				{Label: "禁用", Value: "disabled", Sort: 3},
+			},
+		},
 		{
 			Name: "资源类型", Type: "resource_type", Desc: "资源的类型分类",
 			Items: []dictItem{
@@ -520,6 +580,26 @@ func seedDictData() {
 				{Label: "撤销", Value: "revoke", Sort: 10},
 				{Label: "更新个人信息", Value: "update_profile", Sort: 11},
 				{Label: "修改密码", Value: "change_password", Sort: 12},
+				{Label: "启用双因素认证", Value: "enable_2fa", Sort: 13},
+				{Label: "关闭双因素认证", Value: "disable_2fa", Sort: 14},
+				{Label: "重置双因素认证", Value: "reset_2fa", Sort: 15},
+				{Label: "重新生成恢复码", Value: "regenerate_recovery_codes", Sort: 16},
+				{Label: "使用恢复码", Value: "use_recovery_code", Sort: 17},
+				{Label: "锁定", Value: "lock", Sort: 18},
+				{Label: "解锁", Value: "unlock", Sort: 19},
+				{Label: "重置密码", Value: "reset_password", Sort: 20},
+				{Label: "验证邮箱", Value: "verify_email", Sort: 21},
+				{Label: "重发验证邮件", Value: "resend_verification", Sort: 22},
+				{Label: "激活", Value: "activate", Sort: 23},
+				{Label: "禁用", Value: "disable", Sort: 24},
+				{Label: "关联外部身份", Value: "link_identity", Sort: 25},
+				{Label: "解除外部身份", Value: "unlink_identity", Sort: 26},
+				{Label: "模拟登录", Value: "impersonate", Sort: 27},
+				{Label: "到期", Value: "expire", Sort: 28},
+				{Label: "提交申请", Value: "request", Sort: 29},
+				{Label: "审批通过", Value: "approve", Sort: 30},
+				{Label: "审批驳回", Value: "reject", Sort: 31},
+				{Label: "撤回申请", Value: "cancel", Sort: 32},
 			},
 		},
 		{
@@ -535,6 +615,22 @@ func seedDictData() {
 				{Label: "用户角色", Value: "user_role", Sort: 8},
 				{Label: "角色菜单", Value: "role_menu", Sort: 9},
 				{Label: "菜单资源", Value: "menu_resource", Sort: 10},
+				{Label: "刷新令牌家族", Value: "refresh_token_family", Sort: 11},
+				{Label: "用户会话", Value: "user_session", Sort: 12},
+				{Label: "个人访问令牌", Value: "personal_access_token", Sort: 13},
+				{Label: "IP 访问策略", Value: "ip_access_policy", Sort: 14},
+				{Label: "角色继承", Value: "role_parent", Sort: 15},
+				{Label: "访问申请", Value: "access_request", Sort: 16},
+			},
+		},
+		{
+			Name: "访问申请状态", Type: "access_request_status", Desc: "临时访问申请的审批状态",
+			Items: []dictItem{
+				{Label: "待审批", Value: "pending", Sort: 1},
+				{Label: "已通过", Value: "approved", Sort: 2},
+				{Label: "已驳回", Value: "rejected", Sort: 3},
+				{Label: "已撤回", Value: "cancelled", Sort: 4},
+				{Label: "已到期", Value: "expired", Sort: 5},
 			},
 		},
 	}
@@ -577,3 +673,275 @@ func seedDictData() {
 	global.G_DB.Model(&entity.SysDictionary{}).Count(&count)
 	fmt.Printf("字典数据初始化成功，当前字典总数: %d\n", count)
 }
+
+// explain 输出用户权限判定过程，用法：
+//
+//	rbaccli explain -user admin@example.com -resource role:manage -method POST
+//	rbaccli explain -user 1 -path /roles/1/parents -method POST
+//	rbaccli explain -user 1 -entity-type project -entity-id 7 -action update
+func explain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	user := fs.String("user", "", "用户ID或邮箱")
+	resource := fs.String("resource", "", "资源名，如 role:manage")
+	path := fs.String("path", "", "请求路径，如 /roles/1")
+	method := fs.String("method", "GET", "请求方法")
+	entityType := fs.String("entity-type", "", "实体类型")
+	entityID := fs.Uint64("entity-id", 0, "实体ID")
+	action := fs.String("action", "", "实体操作")
+	ip := fs.String("ip", "", "用于求值 ip 条件的客户端 IP")
+	asJSON := fs.Bool("json", false, "以 JSON 输出")
+	fs.Parse(args)
+
+	if *user == "" {
+		fmt.Println("请通过 -user 指定用户ID或邮箱")
+		os.Exit(1)
+	}
+
+	config.InitConfig()
+	// 直接按数据库计算，不读取权限缓存
+	config.CfgRedis.Enabled = false
+	bootstrap.BootDBOnly()
+
+	userID, err := lookupUserID(*user)
+	if err != nil {
+		fmt.Printf("查找用户失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	var resolved *services.ResolvedRoute
+	if *path != "" {
+		_, registry := buildRoutes()
+		var ok bool
+		if resolved, ok = registry.ResolveRoute(*method, *path); !ok {
+			fmt.Printf("没有与 %s %s 对应的路由\n", *method, *path)
+			os.Exit(1)
+		}
+	}
+
+	trace, err := service.NewPermissionService().ExplainPermission(&services.ExplainRequest{
+		UserID:     userID,
+		Resource:   *resource,
+		Path:       *path,
+		Route:      resolved,
+		Method:     *method,
+		EntityType: *entityType,
+		EntityID:   *entityID,
+		Action:     *action,
+		Attrs:      &services.AccessAttributes{ClientIP: *ip},
+	})
+	if err != nil {
+		fmt.Printf("判定失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(trace, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printTrace(trace)
+	}
+	if !trace.Allowed {
+		os.Exit(2)
+	}
+}
+
+// buildRoutes 组装与服务端相同的路由表，处理器与鉴权中间件只在请求时调用，组装时可以为空
+func buildRoutes() (*gin.Engine, *route.Registry) {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	registry := route.NewRegistry(middleware.NewRBACMiddleware(nil))
+	route.Register(router, registry, &route.Handlers{}, func(c *gin.Context) { c.Next() }, zap.NewNop())
+	return router, registry
+}
+
+func lookupUserID(value string) (uint64, error) {
+	if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return id, nil
+	}
+	var user entity.User
+	if err := global.G_DB.Where("email = ?", value).First(&user).Error; err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+func printTrace(trace *services.PermissionTrace) {
+	target := ""
+	switch {
+	case trace.EntityType != "":
+		target = fmt.Sprintf("实体 %s:%d 操作 %s", trace.EntityType, trace.EntityID, trace.Action)
+	case trace.Path != "":
+		target = fmt.Sprintf("%s %s（路由 %s）", trace.Method, trace.Path, trace.Route)
+	case len(trace.Resources) > 0:
+		target = fmt.Sprintf("%s %s", trace.Method, trace.Resources[0].Resource)
+	}
+	fmt.Printf("用户 %d → %s（时间 %s", trace.UserID, target, trace.Time)
+	if trace.ClientIP != "" {
+		fmt.Printf("，IP %s", trace.ClientIP)
+	}
+	fmt.Println("）")
+
+	fmt.Println("\n生效角色:")
+	if len(trace.Roles) == 0 {
+		fmt.Println("  （无）")
+	}
+	for _, role := range trace.Roles {
+		if role.InheritedVia != "" {
+			fmt.Printf("  %s（经由 %s 继承）\n", role.Name, role.InheritedVia)
+		} else {
+			fmt.Printf("  %s\n", role.Name)
+		}
+	}
+
+	for _, res := range trace.Resources {
+		fmt.Printf("\n资源 %s", res.Resource)
+		if res.Pattern != "" {
+			fmt.Printf("（路径模式 %s）", res.Pattern)
+		}
+		fmt.Println(":")
+		if len(res.Bindings) == 0 {
+			fmt.Println("  没有匹配的授权")
+		}
+		for _, b := range res.Bindings {
+			mark := " "
+			if b.Decisive {
+				mark = "*"
+			}
+			fmt.Printf(" %s %-6s %-24s 读=%-5v 写=%-5v 适用=%-5v", mark, b.Effect, b.ResourceName, b.IsRead, b.IsWrite, b.Applies)
+			if b.Condition != "" {
+				holds := "未求值"
+				if b.ConditionHolds != nil {
+					holds = strconv.FormatBool(*b.ConditionHolds)
+				}
+				fmt.Printf(" 条件[%s]=%s", b.ConditionText, holds)
+			}
+			roles := make([]string, 0, len(b.Sources))
+			for _, src := range b.Sources {
+				if src.InheritedVia != "" {
+					roles = append(roles, src.InheritedVia+" 继承的 "+src.Role)
+				} else {
+					roles = append(roles, src.Role)
+				}
+			}
+			fmt.Printf(" 来自 %s\n", strings.Join(roles, "、"))
+		}
+		fmt.Printf("  → %s\n", res.Reason)
+	}
+
+	if len(trace.OrgChecks) > 0 {
+		fmt.Println("\n组织范围比较:")
+		for _, check := range trace.OrgChecks {
+			fmt.Printf("  范围 %s（含下级=%v） vs 实体组织 %s: %v，%s\n",
+				check.ScopePath, check.IncludeDescendants, check.EntityPath, check.Matched, check.Reason)
+		}
+	}
+
+	decision := "拒绝"
+	if trace.Allowed {
+		decision = "允许"
+	}
+	fmt.Printf("\n结论: %s — %s\n", decision, trace.Reason)
+}
+
+// syncRoutes 按 gin 路由表中各路由声明的资源名创建或更新 API 资源，用法：
+//
+//	rbaccli sync-routes            # 写入数据库
+//	rbaccli sync-routes -dry-run   # 只打印将要进行的修改
+//
+// 资源的 Pattern 与 Method 由引用它的路由推导；未被任何路由引用的资源只列出，不会删除
+func syncRoutes(args []string) {
+	fs := flag.NewFlagSet("sync-routes", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "只打印将要进行的修改，不写入数据库")
+	fs.Parse(args)
+
+	fmt.Println("=== 按路由同步 API 资源 ===")
+
+	config.InitConfig()
+	bootstrap.BootDBOnly()
+
+	router, registry := buildRoutes()
+	declared := registry.Resources(router.Routes())
+
+	var created, updated int
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
+		for _, res := range declared {
+			var existing entity.Resource
+			err := tx.Where("name = ?", res.Name).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("新增 %-28s %-6s %s\n", res.Name, res.Method, res.Pattern)
+				created++
+				if *dryRun {
+					continue
+				}
+				resource := entity.Resource{Name: res.Name, Type: "api", Pattern: res.Pattern, Method: res.Method, Description: routeDescription(res)}
+				if err := tx.Create(&resource).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if existing.Type == "api" && existing.Pattern == res.Pattern && existing.Method == res.Method {
+				continue
+			}
+			fmt.Printf("更新 %-28s %s %s %s → %s %s\n", res.Name, existing.Type, existing.Method, existing.Pattern, res.Method, res.Pattern)
+			updated++
+			if *dryRun {
+				continue
+			}
+			if err := tx.Model(&existing).Updates(map[string]interface{}{"type": "api", "pattern": res.Pattern, "method": res.Method}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 与 seed-resources 一致，新增的资源同步绑定到 super_admin
+		if created > 0 && !*dryRun {
+			var superAdminRole entity.Role
+			if err := tx.Where("name = ?", "super_admin").First(&superAdminRole).Error; err == nil {
+				if err := syncAllResourcesToRole(tx, superAdminRole.ID); err != nil {
+					return fmt.Errorf("同步资源到 super_admin 角色失败: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("同步失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	referenced := make(map[string]bool, len(declared))
+	for _, res := range declared {
+		referenced[res.Name] = true
+	}
+	var apiResources []entity.Resource
+	global.G_DB.Where("type = ?", "api").Order("name").Find(&apiResources)
+	for _, res := range apiResources {
+		if !referenced[res.Name] {
+			fmt.Printf("未被路由引用 %-28s %-6s %s\n", res.Name, res.Method, res.Pattern)
+		}
+	}
+	// 声明的资源均已同步，这里只剩未声明访问控制或没有资源覆盖的路由
+	for _, issue := range registry.Check(router.Routes(), apiResources) {
+		fmt.Printf("%s %-6s %s\n", issue.Problem, issue.Method, issue.Path)
+	}
+
+	if *dryRun {
+		fmt.Printf("预览完成：将新增 %d 个、更新 %d 个资源\n", created, updated)
+		return
+	}
+	if created > 0 || updated > 0 {
+		clearPermissionCaches()
+	}
+	fmt.Printf("同步完成：新增 %d 个、更新 %d 个资源\n", created, updated)
+}
+
+// routeDescription 新增资源的默认描述，列出引用它的第一个路由
+func routeDescription(res route.RouteResource) string {
+	first := res.Routes[0].Method + " " + res.Routes[0].Path
+	if len(res.Routes) == 1 {
+		return first
+	}
+	return fmt.Sprintf("%s 等 %d 个接口", first, len(res.Routes))
+}
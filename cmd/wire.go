@@ -15,6 +15,7 @@ import (
 	domainservices "github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
 	"github.com/lyj404/gin-api-template/pkg/lib/logger"
+	"github.com/lyj404/gin-api-template/pkg/lib/mailer"
 	"github.com/lyj404/gin-api-template/repository"
 	"github.com/lyj404/gin-api-template/service"
 	"github.com/redis/go-redis/v9"
@@ -40,11 +41,20 @@ type App struct {
 	UserProfileHdlr *handler.UserProfileHandler
 	MenuHdlr        *handler.MenuHandler
 	UserMgmtHdlr    *handler.UserManagementHandler
+	SessionHdlr     *handler.SessionHandler
+	JWKSHdlr        *handler.JWKSHandler
+	TwoFactorHdlr   *handler.TwoFactorHandler
+	PwdResetHdlr    *handler.PasswordResetHandler
+	IdentityHdlr    *handler.ExternalIdentityHandler
+	TokenHdlr       *handler.PersonalAccessTokenHandler
+	IPPolicyHdlr    *handler.IPAccessPolicyHandler
 	ResourceHdlr    *handler.ResourceHandler
 	DashboardHdlr   *handler.DashboardHandler
 	DictHdlr        *handler.DictionaryHandler
+	AccessReqHdlr   *handler.AccessRequestHandler
 	RBACMiddleware  *middleware.RBACMiddleware
 	PermSvc         domainservices.PermissionService
+	ExpirySweeper   *service.RoleExpirySweeper
 	RegisterRoutes  func()
 }
 
@@ -92,26 +102,63 @@ func provideRouteRegistration(
 	resourceHdlr *handler.ResourceHandler,
 	dashboardHdlr *handler.DashboardHandler,
 	dictHdlr *handler.DictionaryHandler,
-	rbac *middleware.RBACMiddleware,
+	sessionHdlr *handler.SessionHandler,
+	jwksHdlr *handler.JWKSHandler,
+	twoFactorHdlr *handler.TwoFactorHandler,
+	pwdResetHdlr *handler.PasswordResetHandler,
+	identityHdlr *handler.ExternalIdentityHandler,
+	tokenHdlr *handler.PersonalAccessTokenHandler,
+	ipPolicyHdlr *handler.IPAccessPolicyHandler,
+	accessReqHdlr *handler.AccessRequestHandler,
+	sessionSvc domainservices.SessionService,
+	tokenSvc domainservices.PersonalAccessTokenService,
+	ipPolicySvc domainservices.IPAccessPolicyService,
+	resourceSvc domainservices.ResourceService,
+	registry *route.Registry,
+	logger *zap.Logger,
 ) func() {
 	return func() {
-		// 注册公共路由（根路径）
-		publicGroup := router.Group("")
-		route.NewUserRouter(userHdlr, refreshTokenHdlr, publicGroup)
-		route.NewPublicDictionaryRouter(dictHdlr, publicGroup)
-
-		// 注册受保护的路由
-		protectedGroup := router.Group("")
-		protectedGroup.Use(route.JwtAuthMiddleware())
-		route.NewRoleRouter(roleHdlr, rbac, protectedGroup)
-		route.NewOrgUnitRouter(orgHdlr, rbac, protectedGroup)
-		route.NewAuditLogRouter(auditHdlr, rbac, protectedGroup)
-		route.NewUserPermissionRouter(userPermHdlr, userProfileHdlr, protectedGroup)
-		route.NewMenuRouter(menuHdlr, rbac, protectedGroup)
-		route.NewUserManagementRouter(userMgmtHdlr, rbac, protectedGroup)
-		route.NewResourceRouter(resourceHdlr, rbac, protectedGroup)
-		route.NewDashboardRouter(dashboardHdlr, rbac, protectedGroup)
-		route.NewDictionaryRouter(dictHdlr, protectedGroup)
+		auth := route.JwtAuthMiddleware(sessionSvc, tokenSvc, ipPolicySvc)
+		route.Register(router, registry, &route.Handlers{
+			User:          userHdlr,
+			RefreshToken:  refreshTokenHdlr,
+			Role:          roleHdlr,
+			OrgUnit:       orgHdlr,
+			AuditLog:      auditHdlr,
+			UserPerm:      userPermHdlr,
+			UserProfile:   userProfileHdlr,
+			Menu:          menuHdlr,
+			UserMgmt:      userMgmtHdlr,
+			Resource:      resourceHdlr,
+			Dashboard:     dashboardHdlr,
+			Dict:          dictHdlr,
+			Session:       sessionHdlr,
+			JWKS:          jwksHdlr,
+			TwoFactor:     twoFactorHdlr,
+			PwdReset:      pwdResetHdlr,
+			Identity:      identityHdlr,
+			Token:         tokenHdlr,
+			IPPolicy:      ipPolicyHdlr,
+			AccessRequest: accessReqHdlr,
+		}, auth, logger)
+
+		checkRoutes(router, registry, resourceSvc, logger)
+	}
+}
+
+// checkRoutes 启动时列出未声明访问控制、资源不存在或没有资源覆盖的路由，可用 rbaccli sync-routes 补齐资源
+func checkRoutes(router *gin.Engine, registry *route.Registry, resourceSvc domainservices.ResourceService, logger *zap.Logger) {
+	resources, err := resourceSvc.GetAllResources()
+	if err != nil {
+		logger.Warn("路由检查读取资源失败", zap.Error(err))
+		return
+	}
+	for _, issue := range registry.Check(router.Routes(), resources) {
+		logger.Warn("路由访问控制检查未通过",
+			zap.String("method", issue.Method),
+			zap.String("path", issue.Path),
+			zap.String("resource", issue.Resource),
+			zap.String("problem", issue.Problem))
 	}
 }
 
@@ -128,6 +175,8 @@ var providerSet = wire.NewSet(
 	provideRouter,
 	provideRouteRegistration,
 	provideTimeout,
+	route.NewRegistry,
+	wire.Bind(new(domainservices.RouteResolver), new(*route.Registry)),
 
 	// Repository 层
 	repository.NewUserRepo,
@@ -138,6 +187,15 @@ var providerSet = wire.NewSet(
 	repository.NewUserManagementRepository,
 	repository.NewResourceRepository,
 	repository.NewDictionaryRepo,
+	repository.NewRefreshTokenFamilyRepository,
+	repository.NewSessionRepository,
+	repository.NewTwoFactorRepository,
+	repository.NewPasswordResetRepository,
+	repository.NewEmailVerificationRepository,
+	repository.NewExternalIdentityRepository,
+	repository.NewPersonalAccessTokenRepository,
+	repository.NewIPAccessPolicyRepository,
+	repository.NewAccessRequestRepository,
 
 	// Service 层
 	service.NewUserService,
@@ -152,6 +210,19 @@ var providerSet = wire.NewSet(
 	service.NewResourceService,
 	service.NewDictionaryService,
 	service.NewDashboardService,
+	service.NewSessionService,
+	service.NewTwoFactorService,
+	service.NewLoginGuardService,
+	service.NewPasswordResetService,
+	service.NewEmailVerificationService,
+	service.NewOIDCService,
+	service.NewPersonalAccessTokenService,
+	service.NewCaptchaService,
+	service.NewLoginRiskService,
+	service.NewIPAccessPolicyService,
+	service.NewAccessRequestService,
+	service.NewRoleExpirySweeper,
+	mailer.NewMailer,
 	middleware.NewRBACMiddleware,
 
 	// Handler 层
@@ -167,4 +238,12 @@ var providerSet = wire.NewSet(
 	handler.NewResourceHandler,
 	handler.NewDashboardHandler,
 	handler.NewDictionaryHandler,
+	handler.NewSessionHandler,
+	handler.NewJWKSHandler,
+	handler.NewTwoFactorHandler,
+	handler.NewPasswordResetHandler,
+	handler.NewExternalIdentityHandler,
+	handler.NewPersonalAccessTokenHandler,
+	handler.NewIPAccessPolicyHandler,
+	handler.NewAccessRequestHandler,
 )
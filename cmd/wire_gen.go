@@ -16,6 +16,7 @@ import (
 	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
 	"github.com/lyj404/gin-api-template/pkg/lib/logger"
+	"github.com/lyj404/gin-api-template/pkg/lib/mailer"
 	"github.com/lyj404/gin-api-template/repository"
 	"github.com/lyj404/gin-api-template/service"
 	"github.com/redis/go-redis/v9"
@@ -35,13 +36,32 @@ func InitializeApp() (*App, error) {
 	engine := provideRouter(duration, logger)
 	userRepo := repository.NewUserRepo(db)
 	loginService := service.NewUserService(userRepo, duration)
-	refreshTokenService := service.NewRefreshTokenService(userRepo, duration)
+	refreshTokenFamilyRepository := repository.NewRefreshTokenFamilyRepository()
+	sessionRepository := repository.NewSessionRepository()
 	auditLogRepository := repository.NewAuditLogRepository()
 	permissionService := service.NewPermissionService()
 	auditLogService := service.NewAuditLogService(auditLogRepository, permissionService)
-	userHandler := handler.NewUserHandler(loginService, refreshTokenService, auditLogService)
-	refreshTokenHandler := handler.NewRefreshTokenHandler(refreshTokenService)
+	refreshTokenService := service.NewRefreshTokenService(userRepo, refreshTokenFamilyRepository, sessionRepository, auditLogService, duration)
+	sessionService := service.NewSessionService(sessionRepository, refreshTokenFamilyRepository)
+	twoFactorRepository := repository.NewTwoFactorRepository()
+	userRepository := repository.NewUserManagementRepository()
+	twoFactorService := service.NewTwoFactorService(twoFactorRepository, userRepository, auditLogService)
+	loginGuardService := service.NewLoginGuardService(userRepository, auditLogService)
+	emailVerificationRepository := repository.NewEmailVerificationRepository()
+	mailerMailer := mailer.NewMailer()
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, userRepository, auditLogService, mailerMailer)
+	externalIdentityRepository := repository.NewExternalIdentityRepository()
+	oidcService := service.NewOIDCService(externalIdentityRepository, userRepository, auditLogService)
+	captchaService, err := service.NewCaptchaService()
+	if err != nil {
+		return nil, err
+	}
+	loginRiskService := service.NewLoginRiskService(loginGuardService, sessionRepository)
+	ipAccessPolicyRepository := repository.NewIPAccessPolicyRepository()
 	roleRepository := repository.NewRoleRepository()
+	ipAccessPolicyService := service.NewIPAccessPolicyService(ipAccessPolicyRepository, userRepository, roleRepository)
+	userHandler := handler.NewUserHandler(loginService, refreshTokenService, auditLogService, sessionService, twoFactorService, loginGuardService, emailVerificationService, oidcService, captchaService, loginRiskService, ipAccessPolicyService)
+	refreshTokenHandler := handler.NewRefreshTokenHandler(refreshTokenService)
 	roleService := service.NewRoleService(roleRepository, permissionService)
 	roleHandler := handler.NewRoleHandler(roleService)
 	orgUnitRepository := repository.NewOrgUnitRepository()
@@ -52,11 +72,23 @@ func InitializeApp() (*App, error) {
 	profileService := service.NewUserProfileService()
 	userProfileHandler := handler.NewUserProfileHandler(profileService)
 	menuRepository := repository.NewMenuRepository()
-	menuService := service.NewMenuService(menuRepository)
+	menuService := service.NewMenuService(menuRepository, permissionService)
 	menuHandler := handler.NewMenuHandler(menuService)
-	userRepository := repository.NewUserManagementRepository()
-	userManagementService := service.NewUserManagementService(userRepository, permissionService)
+	personalAccessTokenRepository := repository.NewPersonalAccessTokenRepository()
+	personalAccessTokenService := service.NewPersonalAccessTokenService(personalAccessTokenRepository, userRepository, permissionService, auditLogService)
+	rbacMiddleware := middleware.NewRBACMiddleware(permissionService)
+	registry := route.NewRegistry(rbacMiddleware)
+	userManagementService := service.NewUserManagementService(userRepository, permissionService, sessionService, twoFactorService, loginGuardService, emailVerificationService, personalAccessTokenService, registry)
 	userManagementHandler := handler.NewUserManagementHandler(userManagementService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	jwksHandler := handler.NewJWKSHandler()
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	passwordResetRepository := repository.NewPasswordResetRepository()
+	passwordResetService := service.NewPasswordResetService(passwordResetRepository, userRepository, sessionService, mailerMailer)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService)
+	externalIdentityHandler := handler.NewExternalIdentityHandler(oidcService)
+	personalAccessTokenHandler := handler.NewPersonalAccessTokenHandler(personalAccessTokenService)
+	ipAccessPolicyHandler := handler.NewIPAccessPolicyHandler(ipAccessPolicyService)
 	resourceRepository := repository.NewResourceRepository()
 	resourceService := service.NewResourceService(resourceRepository)
 	resourceHandler := handler.NewResourceHandler(resourceService)
@@ -65,8 +97,11 @@ func InitializeApp() (*App, error) {
 	dictionaryRepo := repository.NewDictionaryRepo(db)
 	dictionaryService := service.NewDictionaryService(dictionaryRepo)
 	dictionaryHandler := handler.NewDictionaryHandler(dictionaryService)
-	rbacMiddleware := middleware.NewRBACMiddleware(permissionService)
-	v := provideRouteRegistration(engine, userHandler, refreshTokenHandler, roleHandler, orgUnitHandler, auditLogHandler, userPermissionHandler, userProfileHandler, menuHandler, userManagementHandler, resourceHandler, dashboardHandler, dictionaryHandler, rbacMiddleware)
+	accessRequestRepository := repository.NewAccessRequestRepository()
+	accessRequestService := service.NewAccessRequestService(accessRequestRepository, roleRepository, orgUnitRepository, permissionService)
+	accessRequestHandler := handler.NewAccessRequestHandler(accessRequestService)
+	roleExpirySweeper := service.NewRoleExpirySweeper(permissionService, logger)
+	v := provideRouteRegistration(engine, userHandler, refreshTokenHandler, roleHandler, orgUnitHandler, auditLogHandler, userPermissionHandler, userProfileHandler, menuHandler, userManagementHandler, resourceHandler, dashboardHandler, dictionaryHandler, sessionHandler, jwksHandler, twoFactorHandler, passwordResetHandler, externalIdentityHandler, personalAccessTokenHandler, ipAccessPolicyHandler, accessRequestHandler, sessionService, personalAccessTokenService, ipAccessPolicyService, resourceService, registry, logger)
 	app := &App{
 		DB:              db,
 		Redis:           client,
@@ -84,11 +119,20 @@ func InitializeApp() (*App, error) {
 		UserProfileHdlr: userProfileHandler,
 		MenuHdlr:        menuHandler,
 		UserMgmtHdlr:    userManagementHandler,
+		SessionHdlr:     sessionHandler,
+		JWKSHdlr:        jwksHandler,
+		TwoFactorHdlr:   twoFactorHandler,
+		PwdResetHdlr:    passwordResetHandler,
+		IdentityHdlr:    externalIdentityHandler,
+		TokenHdlr:       personalAccessTokenHandler,
+		IPPolicyHdlr:    ipAccessPolicyHandler,
 		ResourceHdlr:    resourceHandler,
 		DashboardHdlr:   dashboardHandler,
 		DictHdlr:        dictionaryHandler,
+		AccessReqHdlr:   accessRequestHandler,
 		RBACMiddleware:  rbacMiddleware,
 		PermSvc:         permissionService,
+		ExpirySweeper:   roleExpirySweeper,
 		RegisterRoutes:  v,
 	}
 	return app, nil
@@ -114,11 +158,20 @@ type App struct {
 	UserProfileHdlr *handler.UserProfileHandler
 	MenuHdlr        *handler.MenuHandler
 	UserMgmtHdlr    *handler.UserManagementHandler
+	SessionHdlr     *handler.SessionHandler
+	JWKSHdlr        *handler.JWKSHandler
+	TwoFactorHdlr   *handler.TwoFactorHandler
+	PwdResetHdlr    *handler.PasswordResetHandler
+	IdentityHdlr    *handler.ExternalIdentityHandler
+	TokenHdlr       *handler.PersonalAccessTokenHandler
+	IPPolicyHdlr    *handler.IPAccessPolicyHandler
 	ResourceHdlr    *handler.ResourceHandler
 	DashboardHdlr   *handler.DashboardHandler
 	DictHdlr        *handler.DictionaryHandler
+	AccessReqHdlr   *handler.AccessRequestHandler
 	RBACMiddleware  *middleware.RBACMiddleware
 	PermSvc         services.PermissionService
+	ExpirySweeper   *service.RoleExpirySweeper
 	RegisterRoutes  func()
 }
 
@@ -157,25 +210,60 @@ func provideRouteRegistration(
 	resourceHdlr *handler.ResourceHandler,
 	dashboardHdlr *handler.DashboardHandler,
 	dictHdlr *handler.DictionaryHandler,
-	rbac *middleware.RBACMiddleware,
+	sessionHdlr *handler.SessionHandler,
+	jwksHdlr *handler.JWKSHandler,
+	twoFactorHdlr *handler.TwoFactorHandler,
+	pwdResetHdlr *handler.PasswordResetHandler,
+	identityHdlr *handler.ExternalIdentityHandler,
+	tokenHdlr *handler.PersonalAccessTokenHandler,
+	ipPolicyHdlr *handler.IPAccessPolicyHandler,
+	accessReqHdlr *handler.AccessRequestHandler,
+	sessionSvc services.SessionService,
+	tokenSvc services.PersonalAccessTokenService,
+	ipPolicySvc services.IPAccessPolicyService,
+	resourceSvc services.ResourceService,
+	registry *route.Registry, logger2 *zap.Logger,
 ) func() {
 	return func() {
+		auth := route.JwtAuthMiddleware(sessionSvc, tokenSvc, ipPolicySvc)
+		route.Register(router, registry, &route.Handlers{
+			User:          userHdlr,
+			RefreshToken:  refreshTokenHdlr,
+			Role:          roleHdlr,
+			OrgUnit:       orgHdlr,
+			AuditLog:      auditHdlr,
+			UserPerm:      userPermHdlr,
+			UserProfile:   userProfileHdlr,
+			Menu:          menuHdlr,
+			UserMgmt:      userMgmtHdlr,
+			Resource:      resourceHdlr,
+			Dashboard:     dashboardHdlr,
+			Dict:          dictHdlr,
+			Session:       sessionHdlr,
+			JWKS:          jwksHdlr,
+			TwoFactor:     twoFactorHdlr,
+			PwdReset:      pwdResetHdlr,
+			Identity:      identityHdlr,
+			Token:         tokenHdlr,
+			IPPolicy:      ipPolicyHdlr,
+			AccessRequest: accessReqHdlr,
+		}, auth, logger2)
 
-		publicGroup := router.Group("")
-		route.NewUserRouter(userHdlr, refreshTokenHdlr, publicGroup)
-		route.NewPublicDictionaryRouter(dictHdlr, publicGroup)
-
-		protectedGroup := router.Group("")
-		protectedGroup.Use(route.JwtAuthMiddleware())
-		route.NewRoleRouter(roleHdlr, rbac, protectedGroup)
-		route.NewOrgUnitRouter(orgHdlr, rbac, protectedGroup)
-		route.NewAuditLogRouter(auditHdlr, rbac, protectedGroup)
-		route.NewUserPermissionRouter(userPermHdlr, userProfileHdlr, protectedGroup)
-		route.NewMenuRouter(menuHdlr, rbac, protectedGroup)
-		route.NewUserManagementRouter(userMgmtHdlr, rbac, protectedGroup)
-		route.NewResourceRouter(resourceHdlr, rbac, protectedGroup)
-		route.NewDashboardRouter(dashboardHdlr, rbac, protectedGroup)
-		route.NewDictionaryRouter(dictHdlr, protectedGroup)
+		checkRoutes(router, registry, resourceSvc, logger2)
+	}
+}
+
+// checkRoutes 启动时列出未声明访问控制、资源不存在或没有资源覆盖的路由，可用 rbaccli sync-routes 补齐资源
+func checkRoutes(router *gin.Engine, registry *route.Registry, resourceSvc services.ResourceService, logger2 *zap.Logger) {
+	resources, err := resourceSvc.GetAllResources()
+	if err != nil {
+		logger2.
+			Warn("路由检查读取资源失败", zap.Error(err))
+		return
+	}
+	for _, issue := range registry.Check(router.Routes(), resources) {
+		logger2.
+			Warn("路由访问控制检查未通过", zap.String("method", issue.Method), zap.String("path", issue.Path), zap.String("resource", issue.Resource), zap.String("problem", issue.Problem))
 	}
 }
 
@@ -191,5 +279,5 @@ var providerSet = wire.NewSet(
 	provideLogger,
 	provideRouter,
 	provideRouteRegistration,
-	provideTimeout, repository.NewUserRepo, repository.NewRoleRepository, repository.NewOrgUnitRepository, repository.NewAuditLogRepository, repository.NewMenuRepository, repository.NewUserManagementRepository, repository.NewResourceRepository, repository.NewDictionaryRepo, service.NewUserService, service.NewRefreshTokenService, service.NewPermissionService, service.NewRoleService, service.NewOrgUnitService, service.NewAuditLogService, service.NewMenuService, service.NewUserManagementService, service.NewUserProfileService, service.NewResourceService, service.NewDictionaryService, service.NewDashboardService, middleware.NewRBACMiddleware, handler.NewUserHandler, handler.NewRefreshTokenHandler, handler.NewRoleHandler, handler.NewOrgUnitHandler, handler.NewUserPermissionHandler, handler.NewUserProfileHandler, handler.NewAuditLogHandler, handler.NewMenuHandler, handler.NewUserManagementHandler, handler.NewResourceHandler, handler.NewDashboardHandler, handler.NewDictionaryHandler,
+	provideTimeout, route.NewRegistry, wire.Bind(new(services.RouteResolver), new(*route.Registry)), repository.NewUserRepo, repository.NewRoleRepository, repository.NewOrgUnitRepository, repository.NewAuditLogRepository, repository.NewMenuRepository, repository.NewUserManagementRepository, repository.NewResourceRepository, repository.NewDictionaryRepo, repository.NewRefreshTokenFamilyRepository, repository.NewSessionRepository, repository.NewTwoFactorRepository, repository.NewPasswordResetRepository, repository.NewEmailVerificationRepository, repository.NewExternalIdentityRepository, repository.NewPersonalAccessTokenRepository, repository.NewIPAccessPolicyRepository, repository.NewAccessRequestRepository, service.NewUserService, service.NewRefreshTokenService, service.NewPermissionService, service.NewRoleService, service.NewOrgUnitService, service.NewAuditLogService, service.NewMenuService, service.NewUserManagementService, service.NewUserProfileService, service.NewResourceService, service.NewDictionaryService, service.NewDashboardService, service.NewSessionService, service.NewTwoFactorService, service.NewLoginGuardService, service.NewPasswordResetService, service.NewEmailVerificationService, service.NewOIDCService, service.NewPersonalAccessTokenService, service.NewCaptchaService, service.NewLoginRiskService, service.NewIPAccessPolicyService, service.NewAccessRequestService, service.NewRoleExpirySweeper, mailer.NewMailer, middleware.NewRBACMiddleware, handler.NewUserHandler, handler.NewRefreshTokenHandler, handler.NewRoleHandler, handler.NewOrgUnitHandler, handler.NewUserPermissionHandler, handler.NewUserProfileHandler, handler.NewAuditLogHandler, handler.NewMenuHandler, handler.NewUserManagementHandler, handler.NewResourceHandler, handler.NewDashboardHandler, handler.NewDictionaryHandler, handler.NewSessionHandler, handler.NewJWKSHandler, handler.NewTwoFactorHandler, handler.NewPasswordResetHandler, handler.NewExternalIdentityHandler, handler.NewPersonalAccessTokenHandler, handler.NewIPAccessPolicyHandler, handler.NewAccessRequestHandler,
 )
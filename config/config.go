@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
@@ -14,7 +15,8 @@ type ServerConfig struct {
 	HttpPort       string   `yaml:"HttpPort"`
 	Mode           string   `yaml:"Mode"`
 	AllowedOrigins []string `yaml:"AllowedOrigins"`
-	RateLimit      int      `yaml:"RateLimit"`
+	RateLimit      int      `yaml:"RateLimit"` // 全局按 IP 每分钟最大请求数，0 表示不启用；分组与路由级规则见 rateLimit
+	TrustedProxies []string `yaml:"TrustedProxies"` // 受信任的反向代理 IP 或网段，只有来自这些地址的 X-Forwarded-For 才会被采信；为空时直接使用连接地址
 }
 
 type DatabaseConfig struct {
@@ -43,17 +45,37 @@ type TimeoutConfig struct {
 }
 
 type TokenConfig struct {
-	AccessTokenExpiryHour  int    `yaml:"AccessTokenExpiryHour"`
-	RefreshTokenExpiryHour int    `yaml:"RefreshTokenExpiryHour"`
-	AccessTokenSecret      string `yaml:"AccessTokenSecret"`
-	RefreshTokenSecret     string `yaml:"RefreshTokenSecret"`
-	TokenPrefix            string `yaml:"TokenPrefix"`
+	AccessTokenExpiryHour  int                `yaml:"AccessTokenExpiryHour"`
+	RefreshTokenExpiryHour int                `yaml:"RefreshTokenExpiryHour"`
+	AccessTokenSecret      string             `yaml:"AccessTokenSecret"`
+	RefreshTokenSecret     string             `yaml:"RefreshTokenSecret"`
+	TokenPrefix            string             `yaml:"TokenPrefix"`
+	SigningMethod          string             `yaml:"SigningMethod"` // 访问令牌签名算法：HS256（默认）、RS256、EdDSA
+	ActiveKid              string             `yaml:"ActiveKid"`     // 当前用于签名的密钥ID
+	SigningKeys            []SigningKeyConfig `yaml:"SigningKeys"`   // 非对称签名密钥，包含已轮换但仍需验证的旧密钥
+	ImpersonationExpiryMinute int             `yaml:"ImpersonationExpiryMinute"` // 模拟登录令牌有效期（分钟），不提供刷新令牌
+}
+
+// SigningKeyConfig 非对称签名密钥，旧密钥只需配置公钥即可继续验证其签发的令牌
+type SigningKeyConfig struct {
+	Kid            string `yaml:"Kid"`
+	PrivateKeyFile string `yaml:"PrivateKeyFile"`
+	PublicKeyFile  string `yaml:"PublicKeyFile"`
 }
 
 type PasswordConfig struct {
-	SaltPrefix string `yaml:"SaltPrefix"`
-	SaltSuffix string `yaml:"SaltSuffix"`
-	Cost       int    `yaml:"Cost"`
+	SaltPrefix     string `yaml:"SaltPrefix"`
+	SaltSuffix     string `yaml:"SaltSuffix"`
+	Cost           int    `yaml:"Cost"`
+	MinLength      int    `yaml:"MinLength"`      // 最小长度
+	RequireUpper   bool   `yaml:"RequireUpper"`   // 必须包含大写字母
+	RequireLower   bool   `yaml:"RequireLower"`   // 必须包含小写字母
+	RequireDigit   bool   `yaml:"RequireDigit"`   // 必须包含数字
+	RequireSymbol  bool   `yaml:"RequireSymbol"`  // 必须包含特殊字符
+	BannedListFile string `yaml:"BannedListFile"` // 禁用密码列表文件，每行一个
+	DisallowEmail  bool   `yaml:"DisallowEmail"`  // 密码不能包含邮箱（或邮箱用户名）
+	HistoryCount   int    `yaml:"HistoryCount"`   // 不能与最近 N 次使用过的密码相同，0 表示不限制
+	MaxAgeDays     int    `yaml:"MaxAgeDays"`     // 密码最长有效天数，过期后下次登录必须修改，0 表示不过期
 }
 
 type LogConfig struct {
@@ -71,8 +93,112 @@ type SnowflakeConfig struct {
 	StartTime string `yaml:"StartTime"`
 }
 
-type SessionConfig struct {
-	SessionSecret string `yaml:"SessionSecret"`
+type TwoFactorConfig struct {
+	Issuer                string `yaml:"Issuer"`                // otpauth URI 中显示的签发方名称
+	ChallengeExpiryMinute int    `yaml:"ChallengeExpiryMinute"` // 登录挑战令牌有效期（分钟）
+}
+
+type LoginSecurityConfig struct {
+	FreeAttempts         int `yaml:"FreeAttempts"`         // 开始退避前允许的连续失败次数
+	BackoffBaseSeconds   int `yaml:"BackoffBaseSeconds"`   // 退避基础时长（秒），之后每次失败翻倍
+	BackoffMaxSeconds    int `yaml:"BackoffMaxSeconds"`    // 退避时长上限（秒）
+	MaxFailures          int `yaml:"MaxFailures"`          // 同一邮箱连续失败达到该次数后锁定账号，0 表示不锁定
+	IPMaxFailures        int `yaml:"IPMaxFailures"`        // 同一 IP 连续失败达到该次数后临时封禁该 IP，0 表示不封禁
+	LockMinutes          int `yaml:"LockMinutes"`          // 锁定时长（分钟）
+	FailureWindowMinutes int `yaml:"FailureWindowMinutes"` // 失败次数统计窗口（分钟）
+}
+
+type LoginRiskConfig struct {
+	Enabled               bool `yaml:"Enabled"`               // 关闭时每次登录都需要验证码
+	CaptchaThreshold      int  `yaml:"CaptchaThreshold"`      // 风险分达到该值时需要验证码
+	FailureScore          int  `yaml:"FailureScore"`          // 该邮箱近期每次登录失败累加的分数
+	IPFailureScore        int  `yaml:"IPFailureScore"`        // 该 IP 近期每次登录失败累加的分数
+	NewDeviceScore        int  `yaml:"NewDeviceScore"`        // 使用该账号从未登录过的设备时累加的分数
+	VelocityWindowMinutes int  `yaml:"VelocityWindowMinutes"` // 登录频率统计窗口（分钟），按审计日志中的登录记录统计
+	VelocityLimit         int  `yaml:"VelocityLimit"`         // 窗口内成功登录次数达到该值视为异常
+	VelocityScore         int  `yaml:"VelocityScore"`         // 登录频率异常时累加的分数
+}
+
+type SessionLimitConfig struct {
+	MaxConcurrent int    `yaml:"MaxConcurrent"` // 每个用户最多同时有效的登录会话数，0 表示不限制；角色可单独设置并覆盖该值
+	Policy        string `yaml:"Policy"`        // 超出上限时的处理：reject（拒绝新登录）或 evict_oldest（下线最早登录的会话）
+}
+
+type RoleAssignmentConfig struct {
+	ExpirySweepIntervalSecond int `yaml:"ExpirySweepIntervalSecond"` // 清理到期限时角色分配的间隔（秒），到期的分配会被删除并记录审计
+	AccessRequestMaxMinutes   int `yaml:"AccessRequestMaxMinutes"`   // 访问申请可申请的最长时长（分钟），审批通过后按申请时长创建限时分配
+}
+
+type RBACConfig struct {
+	Mode string `yaml:"Mode"` // 资源权限检查方式：name（默认，按路由注册时声明的资源名）、route（按路由模板和方法匹配 API 资源的 Pattern 与 Method）
+}
+
+type MailConfig struct {
+	Driver    string `yaml:"Driver"`    // 发送方式：smtp、log（写入本地文件并打印日志，用于开发和测试）
+	Host      string `yaml:"Host"`      // SMTP 服务器地址
+	Port      int    `yaml:"Port"`      // SMTP 端口
+	Username  string `yaml:"Username"`  // SMTP 用户名
+	Password  string `yaml:"Password"`  // SMTP 密码
+	From      string `yaml:"From"`      // 发件人地址
+	OutputDir string `yaml:"OutputDir"` // log 方式下邮件文件的保存目录，为空时只打印日志
+}
+
+type PasswordResetConfig struct {
+	ExpiryMinute int    `yaml:"ExpiryMinute"` // 重置链接有效期（分钟）
+	ResetURL     string `yaml:"ResetURL"`     // 前端重置密码页面地址，令牌以 token 参数附加
+}
+
+type EmailVerificationConfig struct {
+	Enabled    bool   `yaml:"Enabled"`    // 注册后是否需要验证邮箱，关闭时注册即激活
+	ExpiryHour int    `yaml:"ExpiryHour"` // 验证链接有效期（小时）
+	VerifyURL  string `yaml:"VerifyURL"`  // 前端邮箱验证页面地址，令牌以 token 参数附加
+}
+
+type OIDCConfig struct {
+	StateExpiryMinute   int                  `yaml:"StateExpiryMinute"`   // 授权请求 state 的有效期（分钟）
+	FrontendCallbackURL string               `yaml:"FrontendCallbackURL"` // 登录完成后跳转的前端页面，结果放在 URL fragment 中；为空时回调直接返回 JSON
+	Providers           []OIDCProviderConfig `yaml:"Providers"`
+}
+
+// OIDCProviderConfig 单个 OIDC 身份提供方配置
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"Name"`         // 提供方标识，用于路由 /oidc/:provider，保存后不要修改
+	DisplayName  string   `yaml:"DisplayName"`  // 登录页显示名称
+	Issuer       string   `yaml:"Issuer"`       // 签发方地址，需与 discovery 文档中的 issuer 完全一致
+	ClientID     string   `yaml:"ClientID"`
+	ClientSecret string   `yaml:"ClientSecret"` // 可通过环境变量 OIDC_<NAME>_CLIENT_SECRET 覆盖
+	RedirectURL  string   `yaml:"RedirectURL"`  // 回调地址，指向本服务的 /oidc/:provider/callback
+	Scopes       []string `yaml:"Scopes"`       // 为空时使用 openid email profile
+	AllowSignup  bool     `yaml:"AllowSignup"`  // 首次登录且邮箱未注册时自动创建本地用户
+	LinkByEmail  bool     `yaml:"LinkByEmail"`  // 邮箱已验证且已注册时自动关联到该本地用户
+}
+
+type PersonalAccessTokenConfig struct {
+	MaxPerUser     int `yaml:"MaxPerUser"`     // 每个用户最多持有的有效令牌数，0 表示不限制
+	MaxExpiryDays  int `yaml:"MaxExpiryDays"`  // 令牌最长有效天数，0 表示允许永不过期
+	DefaultExpiryDays int `yaml:"DefaultExpiryDays"` // 创建时未指定有效期时使用的天数
+}
+
+type RateLimitConfig struct {
+	Store   string          `yaml:"Store"`   // 计数存储：memory 或 redis，为空时启用 Redis 则使用 redis
+	MaxKeys int             `yaml:"MaxKeys"` // 内存存储最多保留的计数键数，超出后淘汰最久未访问的键
+	Rules   []RateLimitRule `yaml:"Rules"`
+}
+
+// RateLimitRule 限流规则，WindowSecond 滑动窗口内同一计数键最多允许 Limit 次请求
+type RateLimitRule struct {
+	Name         string   `yaml:"Name"`         // 规则名称，作为计数键的一部分，不同规则分别计数
+	Group        string   `yaml:"Group"`        // 作用的路由分组：global（全部请求）、public（公共路由）、protected（需登录的路由）
+	Paths        []string `yaml:"Paths"`        // 限定的路由，如 /login、/users/:id，以 * 结尾表示前缀匹配；为空时作用于整个分组
+	KeyBy        string   `yaml:"KeyBy"`        // 计数维度：ip、user（未登录时按 ip）、route（所有客户端共享额度）
+	Limit        int      `yaml:"Limit"`
+	WindowSecond int      `yaml:"WindowSecond"`
+}
+
+type CaptchaConfig struct {
+	Type         string `yaml:"Type"`         // 验证码类型：math（算术题图片）、alphanumeric（扭曲字母数字图片）、audio（WAV 音频）
+	Length       int    `yaml:"Length"`       // 字母数字与音频验证码的字符数
+	ExpirySecond int    `yaml:"ExpirySecond"` // 验证码有效期（秒），答案保存在服务端，校验一次后即删除
 }
 
 type Config struct {
@@ -82,7 +208,19 @@ type Config struct {
 	Timeout  TimeoutConfig  `yaml:"timeout"`
 	Token    TokenConfig    `yaml:"token"`
 	Password PasswordConfig `yaml:"password"`
-	Session  SessionConfig  `yaml:"session"`
+	Captcha  CaptchaConfig  `yaml:"captcha"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	TwoFactor TwoFactorConfig `yaml:"twoFactor"`
+	LoginSecurity LoginSecurityConfig `yaml:"loginSecurity"`
+	LoginRisk LoginRiskConfig `yaml:"loginRisk"`
+	SessionLimit SessionLimitConfig `yaml:"sessionLimit"`
+	RoleAssignment RoleAssignmentConfig `yaml:"roleAssignment"`
+	RBAC     RBACConfig     `yaml:"rbac"`
+	Mail     MailConfig     `yaml:"mail"`
+	PasswordReset PasswordResetConfig `yaml:"passwordReset"`
+	EmailVerification EmailVerificationConfig `yaml:"emailVerification"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+	PersonalAccessToken PersonalAccessTokenConfig `yaml:"personalAccessToken"`
 	Log      LogConfig      `yaml:"log"`
 	Snowflake SnowflakeConfig `yaml:"snowflake"`
 }
@@ -94,7 +232,19 @@ var (
 	CfgTimeout   TimeoutConfig
 	CfgToken     TokenConfig
 	CfgPassword  PasswordConfig
-	CfgSession   SessionConfig
+	CfgCaptcha   CaptchaConfig
+	CfgRateLimit RateLimitConfig
+	CfgTwoFactor TwoFactorConfig
+	CfgLoginSecurity LoginSecurityConfig
+	CfgLoginRisk LoginRiskConfig
+	CfgSessionLimit SessionLimitConfig
+	CfgRoleAssignment RoleAssignmentConfig
+	CfgRBAC      RBACConfig
+	CfgMail      MailConfig
+	CfgPasswordReset PasswordResetConfig
+	CfgEmailVerification EmailVerificationConfig
+	CfgOIDC      OIDCConfig
+	CfgPersonalAccessToken PersonalAccessTokenConfig
 	CfgLog       LogConfig
 	CfgSnowflake SnowflakeConfig
 )
@@ -153,6 +303,14 @@ func InitConfig() {
 		}
 	}
 
+	if trustedProxies := os.Getenv("TRUSTED_PROXIES"); trustedProxies != "" {
+		cfg.Server.TrustedProxies = strings.Split(trustedProxies, ",")
+	}
+	if rateLimitStore := os.Getenv("RATE_LIMIT_STORE"); rateLimitStore != "" {
+		cfg.RateLimit.Store = rateLimitStore
+	}
+	validateRateLimitRules(cfg.RateLimit.Rules)
+
 	if dbType := os.Getenv("DB_TYPE"); dbType != "" {
 		cfg.Database.Type = dbType
 	}
@@ -237,9 +395,101 @@ func InitConfig() {
 	if tokenPrefix := os.Getenv("TOKEN_PREFIX"); tokenPrefix != "" {
 		cfg.Token.TokenPrefix = tokenPrefix
 	}
+	if signingMethod := os.Getenv("TOKEN_SIGNING_METHOD"); signingMethod != "" {
+		cfg.Token.SigningMethod = signingMethod
+	}
+	if activeKid := os.Getenv("TOKEN_ACTIVE_KID"); activeKid != "" {
+		cfg.Token.ActiveKid = activeKid
+	}
+
+	if captchaType := os.Getenv("CAPTCHA_TYPE"); captchaType != "" {
+		cfg.Captcha.Type = captchaType
+	}
+
+	if issuer := os.Getenv("TWO_FACTOR_ISSUER"); issuer != "" {
+		cfg.TwoFactor.Issuer = issuer
+	}
+
+	if maxFailures := os.Getenv("LOGIN_MAX_FAILURES"); maxFailures != "" {
+		if val, err := strconv.Atoi(maxFailures); err == nil {
+			cfg.LoginSecurity.MaxFailures = val
+		}
+	}
+	if ipMaxFailures := os.Getenv("LOGIN_IP_MAX_FAILURES"); ipMaxFailures != "" {
+		if val, err := strconv.Atoi(ipMaxFailures); err == nil {
+			cfg.LoginSecurity.IPMaxFailures = val
+		}
+	}
+	if lockMinutes := os.Getenv("LOGIN_LOCK_MINUTES"); lockMinutes != "" {
+		if val, err := strconv.Atoi(lockMinutes); err == nil {
+			cfg.LoginSecurity.LockMinutes = val
+		}
+	}
+
+	if maxConcurrent := os.Getenv("SESSION_MAX_CONCURRENT"); maxConcurrent != "" {
+		if val, err := strconv.Atoi(maxConcurrent); err == nil {
+			cfg.SessionLimit.MaxConcurrent = val
+		}
+	}
+	if policy := os.Getenv("SESSION_LIMIT_POLICY"); policy != "" {
+		cfg.SessionLimit.Policy = policy
+	}
+
+	if sweepInterval := os.Getenv("ROLE_EXPIRY_SWEEP_INTERVAL_SECOND"); sweepInterval != "" {
+		if val, err := strconv.Atoi(sweepInterval); err == nil {
+			cfg.RoleAssignment.ExpirySweepIntervalSecond = val
+		}
+	}
+
+	if maxMinutes := os.Getenv("ACCESS_REQUEST_MAX_MINUTES"); maxMinutes != "" {
+		if val, err := strconv.Atoi(maxMinutes); err == nil {
+			cfg.RoleAssignment.AccessRequestMaxMinutes = val
+		}
+	}
 
-	if sessionSecret := os.Getenv("SESSION_SECRET"); sessionSecret != "" {
-		cfg.Session.SessionSecret = sessionSecret
+	if rbacMode := os.Getenv("RBAC_MODE"); rbacMode != "" {
+		cfg.RBAC.Mode = rbacMode
+	}
+
+	if mailDriver := os.Getenv("MAIL_DRIVER"); mailDriver != "" {
+		cfg.Mail.Driver = mailDriver
+	}
+	if mailHost := os.Getenv("MAIL_HOST"); mailHost != "" {
+		cfg.Mail.Host = mailHost
+	}
+	if mailPort := os.Getenv("MAIL_PORT"); mailPort != "" {
+		if val, err := strconv.Atoi(mailPort); err == nil {
+			cfg.Mail.Port = val
+		}
+	}
+	if mailUsername := os.Getenv("MAIL_USERNAME"); mailUsername != "" {
+		cfg.Mail.Username = mailUsername
+	}
+	if mailPassword := os.Getenv("MAIL_PASSWORD"); mailPassword != "" {
+		cfg.Mail.Password = mailPassword
+	}
+	if mailFrom := os.Getenv("MAIL_FROM"); mailFrom != "" {
+		cfg.Mail.From = mailFrom
+	}
+
+	if resetURL := os.Getenv("PASSWORD_RESET_URL"); resetURL != "" {
+		cfg.PasswordReset.ResetURL = resetURL
+	}
+
+	if verificationEnabled := os.Getenv("EMAIL_VERIFICATION_ENABLED"); verificationEnabled != "" {
+		if val, err := strconv.ParseBool(verificationEnabled); err == nil {
+			cfg.EmailVerification.Enabled = val
+		}
+	}
+	if verifyURL := os.Getenv("EMAIL_VERIFY_URL"); verifyURL != "" {
+		cfg.EmailVerification.VerifyURL = verifyURL
+	}
+
+	for i := range cfg.OIDC.Providers {
+		envName := "OIDC_" + strings.ToUpper(strings.ReplaceAll(cfg.OIDC.Providers[i].Name, "-", "_")) + "_CLIENT_SECRET"
+		if clientSecret := os.Getenv(envName); clientSecret != "" {
+			cfg.OIDC.Providers[i].ClientSecret = clientSecret
+		}
 	}
 
 	if saltPrefix := os.Getenv("SALT_PREFIX"); saltPrefix != "" {
@@ -253,6 +503,21 @@ func InitConfig() {
 			cfg.Password.Cost = val
 		}
 	}
+	if minLength := os.Getenv("PASSWORD_MIN_LENGTH"); minLength != "" {
+		if val, err := strconv.Atoi(minLength); err == nil {
+			cfg.Password.MinLength = val
+		}
+	}
+	if historyCount := os.Getenv("PASSWORD_HISTORY_COUNT"); historyCount != "" {
+		if val, err := strconv.Atoi(historyCount); err == nil {
+			cfg.Password.HistoryCount = val
+		}
+	}
+	if maxAgeDays := os.Getenv("PASSWORD_MAX_AGE_DAYS"); maxAgeDays != "" {
+		if val, err := strconv.Atoi(maxAgeDays); err == nil {
+			cfg.Password.MaxAgeDays = val
+		}
+	}
 
 	CfgServer = cfg.Server
 	CfgDatabase = cfg.Database
@@ -260,7 +525,43 @@ func InitConfig() {
 	CfgTimeout = cfg.Timeout
 	CfgToken = cfg.Token
 	CfgPassword = cfg.Password
-	CfgSession = cfg.Session
+	CfgCaptcha = cfg.Captcha
+	CfgRateLimit = cfg.RateLimit
+	CfgTwoFactor = cfg.TwoFactor
+	CfgLoginSecurity = cfg.LoginSecurity
+	CfgLoginRisk = cfg.LoginRisk
+	CfgSessionLimit = cfg.SessionLimit
+	CfgRoleAssignment = cfg.RoleAssignment
+	CfgRBAC = cfg.RBAC
+	CfgMail = cfg.Mail
+	CfgPasswordReset = cfg.PasswordReset
+	CfgEmailVerification = cfg.EmailVerification
+	CfgOIDC = cfg.OIDC
+	CfgPersonalAccessToken = cfg.PersonalAccessToken
 	CfgLog = cfg.Log
 	CfgSnowflake = cfg.Snowflake
 }
+
+// validateRateLimitRules 校验限流规则，配置错误时启动失败，避免规则静默失效
+func validateRateLimitRules(rules []RateLimitRule) {
+	names := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Name == "" || names[r.Name] {
+			log.Fatalf("限流规则名称为空或重复: %q", r.Name)
+		}
+		names[r.Name] = true
+		switch r.Group {
+		case "global", "public", "protected":
+		default:
+			log.Fatalf("限流规则 %s 的 Group 无效: %q", r.Name, r.Group)
+		}
+		switch r.KeyBy {
+		case "ip", "user", "route":
+		default:
+			log.Fatalf("限流规则 %s 的 KeyBy 无效: %q", r.Name, r.KeyBy)
+		}
+		if r.Limit <= 0 || r.WindowSecond <= 0 {
+			log.Fatalf("限流规则 %s 的 Limit 与 WindowSecond 必须大于 0", r.Name)
+		}
+	}
+}
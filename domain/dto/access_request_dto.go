@@ -0,0 +1,33 @@
+package dto
+
+// CreateAccessRequestRequest 提交访问申请请求
+type CreateAccessRequestRequest struct {
+	RoleID          uint64 `json:"role_id,string" binding:"required"`
+	OrgUnitID       uint64 `json:"org_unit_id,string" binding:"required"` // 角色生效的组织节点
+	Reason          string `json:"reason" binding:"required,max=500"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"` // 申请时长（分钟），上限见配置 roleAssignment.AccessRequestMaxMinutes
+}
+
+// ReviewAccessRequestRequest 审批访问申请请求
+type ReviewAccessRequestRequest struct {
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// AccessRequestResponse 访问申请响应
+type AccessRequestResponse struct {
+	ID              uint64 `json:"id,string"`
+	RequesterID     uint64 `json:"requester_id,string"`
+	RequesterName   string `json:"requester_name"`
+	RoleID          uint64 `json:"role_id,string"`
+	RoleName        string `json:"role_name"`
+	OrgUnitID       uint64 `json:"org_unit_id,string"`
+	OrgUnitName     string `json:"org_unit_name"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Status          string `json:"status"`
+	ReviewerID      uint64 `json:"reviewer_id,string"`
+	ReviewComment   string `json:"review_comment"`
+	ReviewedAt      string `json:"reviewed_at"`
+	ValidUntil      string `json:"valid_until"`
+	CreatedAt       string `json:"created_at"`
+}
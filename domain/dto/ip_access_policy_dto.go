@@ -0,0 +1,32 @@
+package dto
+
+// CreateIPAccessPolicyRequest 创建 IP 访问策略请求
+type CreateIPAccessPolicyRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=role user"`
+	SubjectID   uint64 `json:"subject_id,string" binding:"required"`
+	Effect      string `json:"effect" binding:"required,oneof=allow deny"`
+	CIDR        string `json:"cidr" binding:"required"` // 网段或单个 IP，如 10.0.0.0/8、192.168.1.10
+	Description string `json:"description" binding:"max=255"`
+	Enabled     *bool  `json:"enabled"` // 不传时默认启用
+}
+
+// UpdateIPAccessPolicyRequest 更新 IP 访问策略请求，未传的字段保持原值
+type UpdateIPAccessPolicyRequest struct {
+	Effect      string  `json:"effect" binding:"omitempty,oneof=allow deny"`
+	CIDR        string  `json:"cidr"`
+	Description *string `json:"description" binding:"omitempty,max=255"`
+	Enabled     *bool   `json:"enabled"`
+}
+
+// IPAccessPolicyResponse IP 访问策略响应
+type IPAccessPolicyResponse struct {
+	ID          uint64 `json:"id,string"`
+	SubjectType string `json:"subject_type"`
+	SubjectID   uint64 `json:"subject_id,string"`
+	Effect      string `json:"effect"`
+	CIDR        string `json:"cidr"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
@@ -9,16 +9,34 @@ type LoginRequest struct {
 	// @Description 用户密码
 	// @Required
 	Password string `json:"password" binding:"required,min=6"`
-	// @Description 验证码
-	// @Required
-	Captcha string `json:"captcha" binding:"required"`
+	// @Description 验证码，登录风险评估要求时必填
+	Captcha string `json:"captcha"`
+	// @Description 验证码ID，获取验证码时由 X-Captcha-Id 响应头返回
+	CaptchaID string `json:"captcha_id"`
+	// @Description 设备名称（可选，为空时根据 User-Agent 推断）
+	Device string `json:"device" binding:"omitempty,max=100"`
 }
 
 // LoginResponse 登录响应
-// @Description 登录成功后的响应数据
+// @Description 登录成功后的响应数据，启用双因素认证时仅返回挑战令牌
 type LoginResponse struct {
 	// @Description 访问令牌
-	AccessToken string `json:"accessToken"`
+	AccessToken string `json:"accessToken,omitempty"`
 	// @Description 刷新令牌
-	RefreshToken string `json:"refreshToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// @Description 是否需要双因素认证
+	TwoFactorRequired bool `json:"twoFactorRequired,omitempty"`
+	// @Description 双因素认证挑战令牌，需与验证码一起提交到 /login/2fa
+	ChallengeToken string `json:"challengeToken,omitempty"`
+	// @Description 密码已过期，需先修改密码，修改后刷新令牌才能访问其他接口
+	PasswordChangeRequired bool `json:"passwordChangeRequired,omitempty"`
+	// @Description 下次从当前设备登录时是否需要验证码
+	CaptchaRequired bool `json:"captchaRequired"`
+}
+
+// LoginFailureResponse 登录失败时附带的数据
+// @Description 告知客户端下次登录是否需要先获取验证码
+type LoginFailureResponse struct {
+	// @Description 下次登录是否需要验证码
+	CaptchaRequired bool `json:"captchaRequired"`
 }
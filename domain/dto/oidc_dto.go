@@ -0,0 +1,17 @@
+package dto
+
+// OIDCProviderResponse 可用的单点登录身份提供方
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
+
+// ExternalIdentityResponse 当前用户关联的外部身份
+type ExternalIdentityResponse struct {
+	ID          uint64 `json:"id,string"`
+	Provider    string `json:"provider"`
+	Email       string `json:"email"`
+	LinkedAt    string `json:"linked_at"`
+	LastLoginAt string `json:"last_login_at"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// TokenScope 个人访问令牌的授权范围，资源名需在用户当前权限之内
+type TokenScope struct {
+	ResourceName string `json:"resource_name" binding:"required"`
+	IsRead       bool   `json:"is_read"`
+	IsWrite      bool   `json:"is_write"`
+}
+
+// CreatePersonalAccessTokenRequest 创建个人访问令牌请求
+type CreatePersonalAccessTokenRequest struct {
+	Name          string       `json:"name" binding:"required,max=100"`
+	Scopes        []TokenScope `json:"scopes" binding:"required,min=1,dive"`
+	ExpiresInDays *int         `json:"expires_in_days" binding:"omitempty,min=0"` // 有效天数，0 表示永不过期，不传时使用默认值
+}
+
+// PersonalAccessTokenResponse 个人访问令牌信息（不含令牌明文）
+type PersonalAccessTokenResponse struct {
+	ID         uint64       `json:"id,string"`
+	UserID     uint64       `json:"user_id,string"`
+	Name       string       `json:"name"`
+	TokenHint  string       `json:"token_hint"`
+	Scopes     []TokenScope `json:"scopes"`
+	Status     string       `json:"status"` // active / expired / revoked
+	CreatedAt  string       `json:"created_at"`
+	ExpiresAt  string       `json:"expires_at"`
+	LastUsedAt string       `json:"last_used_at"`
+	LastUsedIP string       `json:"last_used_ip"`
+	RevokedAt  string       `json:"revoked_at"`
+}
+
+// CreatePersonalAccessTokenResponse 创建个人访问令牌响应，令牌明文只在此返回一次
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
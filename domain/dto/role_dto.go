@@ -1,13 +1,19 @@
 package dto
 
+import "time"
+
 type CreateRoleRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	MaxSessions int    `json:"max_sessions" binding:"min=0"` // 最大并发登录会话数，0 表示使用全局配置
+	IsApprover  bool   `json:"is_approver"`                  // 是否可审批访问申请
 }
 
 type UpdateRoleRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	MaxSessions *int   `json:"max_sessions" binding:"omitempty,min=0"` // 不传时保持原值
+	IsApprover  *bool  `json:"is_approver"`                            // 不传时保持原值
 }
 
 type RoleResponse struct {
@@ -15,6 +21,8 @@ type RoleResponse struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	IsSystem    bool   `json:"is_system"`
+	MaxSessions int    `json:"max_sessions"`
+	IsApprover  bool   `json:"is_approver"`
 }
 
 type RoleDetailResponse struct {
@@ -22,6 +30,8 @@ type RoleDetailResponse struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	IsSystem    bool                   `json:"is_system"`
+	MaxSessions int                    `json:"max_sessions"`
+	IsApprover  bool                   `json:"is_approver"`
 	Resources   []RoleResourceResponse `json:"resources,omitempty"`
 	Menus       []RoleMenuResponse     `json:"menus,omitempty"`
 }
@@ -43,6 +53,12 @@ type MenuBriefResponse struct {
 type BindRoleResourceRequest struct {
 	ResourceID uint64 `json:"resource_id,string" binding:"required"`
 	IsWrite    bool `json:"is_write"`
+	Effect     string `json:"effect" binding:"omitempty,oneof=allow deny"` // 默认 allow；deny 时 is_write=true 仅拒绝写操作，否则读写均拒绝
+	Condition  string `json:"condition" binding:"max=500"` // 生效条件表达式，如 weekday in [mon, tue, wed, thu, fri] && time >= 09:00 && time < 18:00
+}
+
+type AddRoleParentRequest struct {
+	ParentID uint64 `json:"parent_id,string" binding:"required"`
 }
 
 type BindRoleMenuRequest struct {
@@ -55,6 +71,9 @@ type RoleResourceResponse struct {
 	ResourceID uint64                  `json:"resource_id,string"`
 	IsRead     bool                  `json:"is_read"`
 	IsWrite    bool                  `json:"is_write"`
+	Effect        string                 `json:"effect"`
+	Condition     string                 `json:"condition,omitempty"`
+	ConditionText string                 `json:"condition_text,omitempty"` // 条件的中文说明
 	Resource   *ResourceBriefResponse `json:"resource,omitempty"`
 }
 
@@ -68,3 +87,23 @@ type ResourceBriefResponse struct {
 	Action      string `json:"action"`
 	Description string `json:"description"`
 }
+
+// AssignUserRoleRequest 为用户分配角色，设置有效期即为限时分配，到期后自动失效
+type AssignUserRoleRequest struct {
+	RoleID     uint64     `json:"role_id,string" binding:"required"`
+	OrgUnitID  uint64     `json:"org_unit_id,string" binding:"required"`
+	ValidFrom  *time.Time `json:"valid_from"`  // 生效时间（RFC3339），为空表示立即生效
+	ValidUntil *time.Time `json:"valid_until"` // 失效时间（RFC3339），为空表示永久有效
+}
+
+type UserRoleResponse struct {
+	ID          uint64 `json:"id,string"`
+	UserID      uint64 `json:"user_id,string"`
+	RoleID      uint64 `json:"role_id,string"`
+	RoleName    string `json:"role_name"`
+	OrgUnitID   uint64 `json:"org_unit_id,string"`
+	OrgUnitName string `json:"org_unit_name"`
+	ValidFrom   string `json:"valid_from,omitempty"`
+	ValidUntil  string `json:"valid_until,omitempty"`
+	Active      bool   `json:"active"` // 当前是否生效
+}
@@ -0,0 +1,18 @@
+package dto
+
+// SessionResponse 登录会话响应
+type SessionResponse struct {
+	SessionID  string `json:"session_id"`
+	Device     string `json:"device"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Current    bool   `json:"current"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// RevokeSessionsResponse 批量撤销会话响应
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
@@ -11,14 +11,23 @@ type SignupRequest struct {
 	Email string `json:"email" binding:"required,email"`
 	// @Description 密码
 	// @Required
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 // SignupResponse 注册成功响应
 // @Description 注册成功后的响应数据
 type SignupResponse struct {
 	// @Description 访问token
-	AccessToken string `json:"accessToken"`
+	AccessToken string `json:"accessToken,omitempty"`
 	// @Description 刷新token
-	RefreshToken string `json:"refreshToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// @Description 是否需要先验证邮箱，为 true 时不返回令牌
+	VerificationRequired bool `json:"verificationRequired,omitempty"`
+}
+
+// VerifyEmailRequest 邮箱验证请求
+type VerifyEmailRequest struct {
+	// @Description 验证邮件中的一次性令牌
+	// @Required
+	Token string `json:"token" binding:"required"`
 }
@@ -0,0 +1,42 @@
+package dto
+
+// TwoFactorStatusResponse 双因素认证状态响应
+type TwoFactorStatusResponse struct {
+	Enabled                bool   `json:"enabled"`
+	EnabledAt              string `json:"enabled_at"`
+	RecoveryCodesRemaining int    `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse 双因素认证初始化响应
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest 提交 TOTP 验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest 关闭双因素认证请求
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// RecoveryCodesResponse 恢复码响应，恢复码明文仅在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorLoginRequest 双因素认证登录请求
+// @Description 使用登录挑战令牌与 TOTP 验证码（或恢复码）换取访问令牌和刷新令牌
+type TwoFactorLoginRequest struct {
+	// @Description 登录接口返回的挑战令牌
+	// @Required
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	// @Description TOTP 验证码或恢复码
+	// @Required
+	Code string `json:"code" binding:"required"`
+	// @Description 设备名称（可选，为空时根据 User-Agent 推断）
+	Device string `json:"device" binding:"omitempty,max=100"`
+}
@@ -4,7 +4,7 @@ package dto
 type CreateUserRequest struct {
 	Name     string   `json:"name" binding:"required"`
 	Email    string   `json:"email" binding:"required,email"`
-	Password string   `json:"password" binding:"required,min=6"`
+	Password string   `json:"password" binding:"required"`
 	RoleIDs  []string `json:"role_ids"`
 	OrgUnitID uint64  `json:"org_unit_id,string"`
 }
@@ -12,7 +12,7 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
 	Name     string   `json:"name"`
 	Email    string   `json:"email" binding:"omitempty,email"`
-	Password string   `json:"password" binding:"omitempty,min=6"`
+	Password string   `json:"password"`
 	RoleIDs  []string `json:"role_ids"`
 	OrgUnitID uint64  `json:"org_unit_id,string"`
 }
@@ -21,6 +21,7 @@ type UserResponse struct {
 	ID      uint64   `json:"id,string"`
 	Name    string   `json:"name"`
 	Email   string   `json:"email"`
+	Status  string   `json:"status"`
 	RoleIDs []string `json:"role_ids"`
 	Roles   []string `json:"roles"`
 }
@@ -42,5 +43,24 @@ type UpdateProfileRequest struct {
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ImpersonateResponse 模拟登录响应，AccessToken 以被模拟用户身份访问，过期后需重新发起
+type ImpersonateResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresAt   string `json:"expires_at"`
+	UserID      uint64 `json:"user_id,string"`
+	ActorID     uint64 `json:"actor_id,string"`
+}
+
+// ExplainPermissionQuery 权限判定说明的查询参数，resource、path、entity_type 三选一
+type ExplainPermissionQuery struct {
+	Resource   string `form:"resource"`    // 资源名，如 role:manage
+	Path       string `form:"path"`        // 请求路径，如 /roles/1，按 API 资源的路径模式找出对应资源
+	Method     string `form:"method"`      // 请求方法，默认 GET
+	EntityType string `form:"entity_type"` // 实体类型
+	EntityID   uint64 `form:"entity_id"`   // 实体ID
+	Action     string `form:"action"`      // 实体操作
+	IP         string `form:"ip"`          // 按该客户端 IP 求值 ip 条件，不传时 ip 条件不成立
 }
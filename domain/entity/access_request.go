@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// 访问申请状态
+const (
+	AccessRequestPending   = "pending"   // 待审批
+	AccessRequestApproved  = "approved"  // 已通过，已创建限时角色分配
+	AccessRequestRejected  = "rejected"  // 已驳回
+	AccessRequestCancelled = "cancelled" // 申请人已撤回
+	AccessRequestExpired   = "expired"   // 通过后授予的角色已到期
+)
+
+// AccessRequest 临时访问申请，用户为某个组织节点申请角色，审批通过后自动创建限时角色分配
+type AccessRequest struct {
+	global.G_MODEL
+	RequesterID     uint64     `gorm:"not null;index" json:"requester_id"`                          // 申请人ID
+	RoleID          uint64     `gorm:"not null;index" json:"role_id"`                               // 申请的角色ID
+	OrgUnitID       uint64     `gorm:"not null;index" json:"org_unit_id"`                           // 角色生效的组织节点
+	Reason          string     `gorm:"type:varchar(500);not null" json:"reason"`                    // 申请理由
+	DurationMinutes int        `gorm:"not null" json:"duration_minutes"`                            // 申请时长（分钟），从审批通过时开始计算
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`               // 状态：pending、approved、rejected、cancelled、expired
+	ReviewerID      uint64     `gorm:"not null;default:0" json:"reviewer_id"`                       // 审批人ID
+	ReviewComment   string     `gorm:"type:varchar(500);not null;default:''" json:"review_comment"` // 审批意见
+	ReviewedAt      *time.Time `json:"reviewed_at"`                                                 // 审批或撤回时间
+	UserRoleID      uint64     `gorm:"not null;default:0;index" json:"user_role_id"`                // 审批通过后创建的角色分配ID
+	ValidUntil      *time.Time `json:"valid_until"`                                                 // 授予角色的失效时间
+	Requester       User       `gorm:"foreignKey:RequesterID" json:"-"`                             // 关联申请人（不返回）
+	Role            Role       `gorm:"foreignKey:RoleID" json:"-"`                                  // 关联角色（不返回）
+	OrgUnit         OrgUnit    `gorm:"foreignKey:OrgUnitID" json:"-"`                               // 关联组织（不返回）
+}
@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DataScope 用户可访问数据的组织范围，All 为 true 时不限制（系统角色）
+type DataScope struct {
+	All    bool
+	OrgIDs []uint64 // 已展开下级的组织ID
+}
+
+// Contains 组织是否在数据范围内
+func (s *DataScope) Contains(orgUnitID uint64) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.OrgIDs {
+		if id == orgUnitID {
+			return true
+		}
+	}
+	return false
+}
+
+// OrgScopeRule 生成把表中数据限定在指定组织内的条件，table 为已按数据库方言加引号的表名
+type OrgScopeRule func(table string, orgIDs []uint64) clause.Expr
+
+var orgScopeRules = make(map[string]OrgScopeRule)
+
+// RegisterOrgScoped 将表注册为按组织划分的数据，注册后即可用 InDataScope 过滤
+// 内置表在本文件的 init 中注册，新的业务模块在各自实体文件的 init 中注册即可
+func RegisterOrgScoped(table string, rule OrgScopeRule) {
+	orgScopeRules[table] = rule
+}
+
+// OrgColumn 数据通过表中的组织ID列归属组织
+func OrgColumn(column string) OrgScopeRule {
+	return func(table string, orgIDs []uint64) clause.Expr {
+		return gorm.Expr(table+"."+column+" IN ?", orgIDs)
+	}
+}
+
+// OrgBinding 数据通过 OrgEntityBinding 归属组织，entityType 为绑定中的实体类型，实体ID取表的 id 列
+func OrgBinding(entityType string) OrgScopeRule {
+	return func(table string, orgIDs []uint64) clause.Expr {
+		return gorm.Expr("EXISTS (SELECT 1 FROM org_entity_binding WHERE org_entity_binding.entity_type = ? AND org_entity_binding.entity_id = "+table+".id AND org_entity_binding.org_unit_id IN ? AND org_entity_binding.deleted_at IS NULL)", entityType, orgIDs)
+	}
+}
+
+// OrgMember 数据按用户归属组织：column 指向的用户在这些组织中有角色分配
+// 使用 EXISTS 子查询而不是 JOIN，避免用户有多个角色时产生重复行
+func OrgMember(column string) OrgScopeRule {
+	return func(table string, orgIDs []uint64) clause.Expr {
+		return gorm.Expr("EXISTS (SELECT 1 FROM user_role WHERE user_role.user_id = "+table+"."+column+" AND user_role.org_unit_id IN ? AND user_role.deleted_at IS NULL)", orgIDs)
+	}
+}
+
+// InDataScope 把查询限定在数据范围内，用于 Scopes；不限制时不加条件，范围为空时不返回任何数据，scope 为 nil 时返回错误
+// 查询的表必须已通过 RegisterOrgScoped 注册，否则返回错误，避免遗漏注册导致数据越权
+func InDataScope(scope *DataScope) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if scope == nil {
+			db.AddError(errors.New("未指定数据范围"))
+			return db
+		}
+		if scope.All {
+			return db
+		}
+		table := db.Statement.Table
+		if table == "" {
+			model := db.Statement.Model
+			if model == nil {
+				model = db.Statement.Dest
+			}
+			if err := db.Statement.Parse(model); err != nil {
+				db.AddError(err)
+				return db
+			}
+			table = db.Statement.Schema.Table
+		}
+		rule, ok := orgScopeRules[table]
+		if !ok {
+			db.AddError(fmt.Errorf("表 %s 未注册组织数据范围", table))
+			return db
+		}
+		return db.Where(rule(db.Statement.Quote(table), scope.OrgIDs))
+	}
+}
+
+func init() {
+	RegisterOrgScoped("org_unit", OrgColumn("id"))
+	RegisterOrgScoped("user_role", OrgColumn("org_unit_id"))
+	RegisterOrgScoped("role_org_scope", OrgColumn("org_unit_id"))
+	RegisterOrgScoped("access_request", OrgColumn("org_unit_id"))
+	RegisterOrgScoped("user", OrgMember("id"))
+	RegisterOrgScoped("audit_log", OrgMember("operator_id"))
+}
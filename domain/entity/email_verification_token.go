@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// EmailVerificationToken 注册邮箱验证令牌，只保存令牌的 SHA-256 哈希，使用一次后失效
+type EmailVerificationToken struct {
+	global.G_MODEL
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`                  // 用户ID
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"` // 令牌哈希（不返回）
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`                     // 过期时间
+	UsedAt    *time.Time `json:"used_at"`                                        // 使用时间，为空表示未使用
+}
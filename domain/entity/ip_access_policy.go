@@ -0,0 +1,26 @@
+package entity
+
+import "github.com/lyj404/gin-api-template/global"
+
+// IP 访问策略作用对象类型
+const (
+	IPPolicySubjectRole = "role" // 作用于拥有该角色的所有用户
+	IPPolicySubjectUser = "user" // 只作用于指定用户
+)
+
+// IP 访问策略效果
+const (
+	IPPolicyEffectAllow = "allow" // 存在 allow 策略时只允许来自这些网段的访问
+	IPPolicyEffectDeny  = "deny"  // 拒绝来自该网段的访问，优先于 allow
+)
+
+// IPAccessPolicy 基于 CIDR 的 IP 访问策略，登录与每次鉴权时按客户端 IP 校验
+type IPAccessPolicy struct {
+	global.G_MODEL
+	SubjectType string `gorm:"type:varchar(20);not null;index:idx_ip_policy_subject" json:"subject_type"` // 作用对象类型：role、user
+	SubjectID   uint64 `gorm:"not null;index:idx_ip_policy_subject" json:"subject_id,string"`             // 角色ID或用户ID
+	Effect      string `gorm:"type:varchar(10);not null" json:"effect"`                                   // 效果：allow、deny
+	CIDR        string `gorm:"column:cidr;type:varchar(64);not null" json:"cidr"`                         // 网段，如 10.0.0.0/8，单个 IP 保存为 /32 或 /128
+	Description string `gorm:"type:varchar(255)" json:"description"`                                      // 说明
+	Enabled     bool   `gorm:"not null;default:true" json:"enabled"`                                      // 是否启用
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "github.com/lyj404/gin-api-template/global"
+
+// PasswordHistory 用户历史密码，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	global.G_MODEL
+	UserID       uint64 `gorm:"not null;index" json:"user_id"`       // 用户ID
+	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"` // 密码哈希（不返回）
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// PersonalAccessTokenPrefix 个人访问令牌的固定前缀，用于与 JWT 访问令牌区分
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken 个人访问令牌，供脚本与第三方集成调用 API，只保存令牌的 SHA-256 哈希
+type PersonalAccessToken struct {
+	global.G_MODEL
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`                  // 所属用户ID
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`         // 令牌名称
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"` // 令牌哈希（不返回）
+	TokenHint  string     `gorm:"type:varchar(16)" json:"token_hint"`             // 令牌开头几位，便于用户辨认
+	Scopes     string     `gorm:"type:text" json:"scopes"`                        // 授权范围（JSON 数组，元素为资源名与读写标记）
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"`                        // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`                                   // 最近使用时间
+	LastUsedIP string     `gorm:"type:varchar(64)" json:"last_used_ip"`           // 最近使用的客户端 IP
+	RevokedAt  *time.Time `json:"revoked_at"`                                     // 撤销时间，为空表示有效
+}
+
+// Active 令牌是否未撤销且未过期
+func (t *PersonalAccessToken) Active(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// RefreshTokenFamily 刷新令牌家族，同一次登录派生出的所有刷新令牌属于同一家族
+type RefreshTokenFamily struct {
+	global.G_MODEL
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`                          // 用户ID
+	FamilyID   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"family_id"` // 家族标识
+	CurrentJTI string     `gorm:"type:varchar(64);not null" json:"-"`                     // 当前唯一有效的刷新令牌ID
+	Revoked    bool       `gorm:"not null;default:false" json:"revoked"`                  // 是否已撤销
+	RevokedAt  *time.Time `json:"revoked_at"`                                             // 撤销时间
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`                       // 家族过期时间（随轮换延长）
+}
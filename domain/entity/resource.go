@@ -1,6 +1,10 @@
 package entity
 
-import "github.com/lyj404/gin-api-template/global"
+import (
+	"strings"
+
+	"github.com/lyj404/gin-api-template/global"
+)
 
 // Resource 资源实体，定义API路径或业务实体的权限
 type Resource struct {
@@ -13,3 +17,42 @@ type Resource struct {
 	Action      string `gorm:"type:varchar(50)" json:"action"`                        // 操作类型（仅Entity类型）
 	Description string `gorm:"type:varchar(255)" json:"description"`                 // 资源描述
 }
+
+// MatchesRoute API 资源的 Pattern 与 Method 是否匹配请求路由和方法
+// path 可以是路由模板（如 /users/:id）或实际请求路径；Method 为空或 * 时匹配任意方法
+func (r *Resource) MatchesRoute(path, method string) bool {
+	if r.Type != "api" {
+		return false
+	}
+	if r.Method != "" && r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return matchRoutePattern(r.Pattern, path)
+}
+
+// matchRoutePattern 按路由风格匹配路径：:name 匹配任意一段，末段 * 匹配其后的全部内容，段内以 * 结尾时按前缀匹配
+func matchRoutePattern(pattern, path string) bool {
+	patternSegs := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range patternSegs {
+		if seg == "*" && i == len(patternSegs)-1 {
+			return true
+		}
+		if i >= len(pathSegs) {
+			return false
+		}
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			if pathSegs[i] == "" {
+				return false
+			}
+		case strings.HasSuffix(seg, "*"):
+			if !strings.HasPrefix(pathSegs[i], strings.TrimSuffix(seg, "*")) {
+				return false
+			}
+		case seg != pathSegs[i]:
+			return false
+		}
+	}
+	return len(patternSegs) == len(pathSegs)
+}
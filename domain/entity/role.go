@@ -7,6 +7,8 @@ type Role struct {
 	Name          string         `gorm:"type:varchar(100);unique;not null" json:"name"`
 	Description   string         `gorm:"type:varchar(255)" json:"description"`
 	IsSystem      bool           `gorm:"default:false" json:"is_system"`
+	MaxSessions   int            `gorm:"not null;default:0" json:"max_sessions"` // 最大并发登录会话数，0 表示使用全局配置
+	IsApprover    bool           `gorm:"not null;default:false" json:"is_approver"` // 持有该角色的用户可审批其所在组织及下级组织的访问申请
 	RoleResources []RoleResource `gorm:"foreignKey:RoleID" json:"role_resources,omitempty" binding:"-"`
 	RoleOrgScopes []RoleOrgScope `gorm:"foreignKey:RoleID" json:"role_org_scopes,omitempty" binding:"-"`
 	RoleMenus     []RoleMenu     `gorm:"foreignKey:RoleID" json:"role_menus,omitempty" binding:"-"`
@@ -0,0 +1,12 @@
+package entity
+
+import "github.com/lyj404/gin-api-template/global"
+
+// RoleParent 角色继承关系，角色继承父角色的资源、菜单与组织范围绑定，支持多个父角色
+type RoleParent struct {
+	global.G_MODEL
+	RoleID   uint64 `gorm:"not null;index" json:"role_id"`   // 子角色ID
+	ParentID uint64 `gorm:"not null;index" json:"parent_id"` // 父角色ID
+	Role     Role   `gorm:"foreignKey:RoleID" json:"-"`      // 关联子角色（不返回）
+	Parent   Role   `gorm:"foreignKey:ParentID" json:"-"`    // 关联父角色（不返回）
+}
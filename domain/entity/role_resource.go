@@ -2,6 +2,12 @@ package entity
 
 import "github.com/lyj404/gin-api-template/global"
 
+// 角色资源绑定效果
+const (
+	RoleResourceEffectAllow = "allow" // 授予访问权限
+	RoleResourceEffectDeny  = "deny"  // 拒绝访问，优先于任何 allow，用于从通配授权中排除个别资源
+)
+
 // RoleResource 角色资源关联实体
 type RoleResource struct {
 	global.G_MODEL
@@ -9,6 +15,8 @@ type RoleResource struct {
 	ResourceID uint64     `gorm:"not null;index" json:"resource_id"`       // 资源ID
 	IsRead     bool     `gorm:"not null;default:true" json:"is_read"`    // 是否有读权限（默认true）
 	IsWrite    bool     `gorm:"not null;default:false" json:"is_write"`  // 是否有写权限
+	Effect     string   `gorm:"type:varchar(10);not null;default:'allow'" json:"effect"` // 效果：allow、deny
+	Condition  string   `gorm:"type:varchar(500);not null;default:''" json:"condition"` // 生效条件表达式（为空表示无条件）
 	Role       Role     `gorm:"foreignKey:RoleID" json:"-"`              // 关联角色（不返回）
 	Resource   Resource `gorm:"foreignKey:ResourceID" json:"-"`           // 关联资源（不返回）
 }
@@ -1,6 +1,17 @@
 package entity
 
-import "github.com/lyj404/gin-api-template/global"
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// 用户状态
+const (
+	UserStatusPending  = "pending"  // 待验证邮箱，不能登录
+	UserStatusActive   = "active"   // 正常
+	UserStatusDisabled = "disabled" // 已禁用，不能登录
+)
 
 // User 用户实体
 type User struct {
@@ -8,6 +19,8 @@ type User struct {
 	Name     string     `gorm:"type:varchar(50)" json:"name"`               // 用户姓名
 	Email    string     `gorm:"type:varchar(255);unique;not null" json:"email"` // 用户邮箱
 	PassWord string     `gorm:"type:varchar(255);column:password" json:"-"`  // 用户密码（不返回）
+	PasswordChangedAt *time.Time `json:"password_changed_at"`                 // 密码最近修改时间（为空时以创建时间计算）
+	Status   string     `gorm:"type:varchar(20);not null;default:active" json:"status"` // 用户状态：pending、active、disabled
 	Roles    []UserRole `gorm:"foreignKey:UserID" json:"-"`                  // 用户角色（不返回）
 }
 
@@ -15,3 +28,11 @@ type User struct {
 func (User) TableName() string {
 	return "user"
 }
+
+// PasswordExpiryBase 返回计算密码有效期的起始时间
+func (u *User) PasswordExpiryBase() time.Time {
+	if u.PasswordChangedAt != nil {
+		return *u.PasswordChangedAt
+	}
+	return u.CreatedAt
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// UserExternalIdentity 用户关联的外部身份（OIDC 单点登录），同一用户可关联多个身份提供方
+type UserExternalIdentity struct {
+	global.G_MODEL
+	UserID      uint64     `gorm:"not null;index" json:"user_id,string"`                                                // 用户ID
+	Provider    string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_identity_provider_subject" json:"provider"` // 身份提供方名称（对应配置中的 Name）
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject" json:"-"`       // 身份提供方中的用户唯一标识（sub）
+	Email       string     `gorm:"type:varchar(255)" json:"email"`                                                      // 身份提供方返回的邮箱
+	LastLoginAt *time.Time `json:"last_login_at"`                                                                       // 最近一次通过该身份登录的时间
+}
@@ -1,14 +1,41 @@
 package entity
 
-import "github.com/lyj404/gin-api-template/global"
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
 
 // UserRole 用户角色关联实体
 type UserRole struct {
 	global.G_MODEL
-	UserID    uint64    `gorm:"not null;index" json:"user_id"`     // 用户ID
-	RoleID    uint64    `gorm:"not null;index" json:"role_id"`     // 角色ID
-	OrgUnitID uint64    `gorm:"not null;index" json:"org_unit_id"` // 角色生效的组织节点
-	User      User    `gorm:"foreignKey:UserID" json:"-"`        // 关联用户（不返回）
-	Role      Role    `gorm:"foreignKey:RoleID" json:"-"`       // 关联角色（不返回）
-	OrgUnit   OrgUnit `gorm:"foreignKey:OrgUnitID" json:"-"`     // 关联组织（不返回）
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`     // 用户ID
+	RoleID     uint64     `gorm:"not null;index" json:"role_id"`     // 角色ID
+	OrgUnitID  uint64     `gorm:"not null;index" json:"org_unit_id"` // 角色生效的组织节点
+	ValidFrom  *time.Time `gorm:"index" json:"valid_from"`           // 生效时间（为空表示立即生效）
+	ValidUntil *time.Time `gorm:"index" json:"valid_until"`          // 失效时间（为空表示永久有效），到期后由清理任务删除并记录审计
+	User       User       `gorm:"foreignKey:UserID" json:"-"`        // 关联用户（不返回）
+	Role       Role       `gorm:"foreignKey:RoleID" json:"-"`        // 关联角色（不返回）
+	OrgUnit    OrgUnit    `gorm:"foreignKey:OrgUnitID" json:"-"`     // 关联组织（不返回）
+}
+
+// ActiveAt 分配在 t 时刻是否生效
+func (ur *UserRole) ActiveAt(t time.Time) bool {
+	if ur.ValidFrom != nil && ur.ValidFrom.After(t) {
+		return false
+	}
+	return ur.ValidUntil == nil || ur.ValidUntil.After(t)
+}
+
+// IsTemporary 是否为限时分配
+func (ur *UserRole) IsTemporary() bool {
+	return ur.ValidFrom != nil || ur.ValidUntil != nil
+}
+
+// UserRoleActiveAt 只保留在 t 时刻生效的用户角色分配，与 ActiveAt 判断一致，用于 Scopes
+func UserRoleActiveAt(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(user_role.valid_from IS NULL OR user_role.valid_from <= ?) AND (user_role.valid_until IS NULL OR user_role.valid_until > ?)", t, t)
+	}
 }
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// UserSession 用户登录会话，每次登录创建一条，访问令牌通过 sid 声明关联到会话
+type UserSession struct {
+	global.G_MODEL
+	UserID      uint64     `gorm:"not null;index" json:"user_id"`                           // 用户ID
+	SessionID   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"session_id"` // 会话标识（同时作为刷新令牌家族标识）
+	Device      string     `gorm:"type:varchar(100)" json:"device"`                         // 设备名称
+	IP          string     `gorm:"type:varchar(64)" json:"ip"`                              // 登录IP
+	UserAgent   string     `gorm:"type:varchar(512)" json:"user_agent"`                     // 客户端 User-Agent
+	Fingerprint string     `gorm:"type:varchar(64);index" json:"-"`                         // 设备指纹，用于登录风险评估识别新设备
+	LastSeenAt  time.Time  `gorm:"not null" json:"last_seen_at"`                            // 最近活跃时间
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`                        // 过期时间（随刷新令牌轮换延长）
+	Revoked     bool       `gorm:"not null;default:false" json:"revoked"`                   // 是否已撤销
+	RevokedAt   *time.Time `json:"revoked_at"`                                              // 撤销时间
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// UserTwoFactor 用户 TOTP 双因素认证配置，确认首个验证码前 Enabled 为 false
+type UserTwoFactor struct {
+	global.G_MODEL
+	UserID         uint64     `gorm:"not null;uniqueIndex" json:"user_id"`   // 用户ID
+	Secret         string     `gorm:"type:varchar(64);not null" json:"-"`    // TOTP 密钥（Base32，不返回）
+	Enabled        bool       `gorm:"not null;default:false" json:"enabled"` // 是否已启用
+	EnabledAt      *time.Time `json:"enabled_at"`                            // 启用时间
+	LastUsedStep   int64      `gorm:"not null;default:0" json:"-"`           // 最近一次使用的时间步，防止验证码重放
+	FailedAttempts int        `gorm:"not null;default:0" json:"-"`           // 连续验证失败次数
+	LastFailedAt   *time.Time `json:"-"`                                     // 最近一次验证失败时间
+}
+
+// UserRecoveryCode 双因素认证恢复码，每个恢复码仅能使用一次
+type UserRecoveryCode struct {
+	global.G_MODEL
+	UserID   uint64     `gorm:"not null;index" json:"user_id"`       // 用户ID
+	CodeHash string     `gorm:"type:varchar(255);not null" json:"-"` // 恢复码哈希（不返回）
+	UsedAt   *time.Time `json:"used_at"`                             // 使用时间，为空表示未使用
+}
@@ -2,13 +2,26 @@ package domain
 
 import "github.com/golang-jwt/jwt/v5"
 
+// JwtCustomClaims 访问令牌声明，SessionID 关联到服务端登录会话，PasswordExpired 表示签发时密码已超过最长有效期
 type JwtCustomClaims struct {
-	Name string `json:"name"`
-	ID   string `json:"id"`
+	Name            string `json:"name"`
+	ID              string `json:"id"`
+	SessionID       string `json:"sid"`
+	PasswordExpired bool   `json:"pwd_expired,omitempty"`
+	ActorID         string `json:"act,omitempty"` // 模拟登录时为实际操作者的用户ID（十六进制），ID 为被模拟的用户
 	jwt.RegisteredClaims
 }
 
+// JwtCustomRefreshClaims 刷新令牌声明，FamilyID 标识令牌家族（与会话ID相同），RegisteredClaims.ID(jti) 标识家族内的单个令牌
 type JwtCustomRefreshClaims struct {
-	ID string `json:"id"`
+	ID       string `json:"id"`
+	FamilyID string `json:"fid"`
+	jwt.RegisteredClaims
+}
+
+// JwtChallengeClaims 双因素认证登录挑战令牌声明，Purpose 固定为 "2fa"，不能作为访问令牌使用
+type JwtChallengeClaims struct {
+	ID      string `json:"id"`
+	Purpose string `json:"purpose"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// AccessRequestRepository 访问申请仓储接口
+type AccessRequestRepository interface {
+	Create(tx *gorm.DB, req *entity.AccessRequest) error
+	GetByID(id uint64) (*entity.AccessRequest, error)
+	// Transition 仅当申请仍处于 from 状态时写入新的状态与审批信息，返回是否更新成功，避免重复审批
+	Transition(tx *gorm.DB, req *entity.AccessRequest, from string) (bool, error)
+	// HasPending 申请人是否已有同一角色、同一组织的待审批申请
+	HasPending(requesterID, roleID, orgUnitID uint64) (bool, error)
+	// List 分页查询申请，requesterID 为 0 时不按申请人筛选，orgPaths 为 nil 时不按组织筛选，
+	// 否则只返回组织节点位于这些路径（含下级）之下的申请；status 为空时不筛选
+	List(requesterID uint64, orgPaths []string, status string, page, pageSize int) ([]entity.AccessRequest, int64, error)
+}
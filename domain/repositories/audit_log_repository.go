@@ -7,7 +7,7 @@ import (
 type AuditLogRepository interface {
 	Create(auditLog *entity.AuditLog) error
 	GetByID(id uint64) (*entity.AuditLog, error)
-	GetByOperator(operatorID uint64, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error)
-	GetByTarget(targetType string, targetID uint64, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error)
-	GetByTimeRange(startTime, endTime string, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error)
+	GetByOperator(operatorID uint64, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error)
+	GetByTarget(targetType string, targetID uint64, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error)
+	GetByTimeRange(startTime, endTime string, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error)
 }
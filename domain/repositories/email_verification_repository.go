@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationRepository 邮箱验证令牌仓储接口
+type EmailVerificationRepository interface {
+	// Create 写入新令牌，并使该用户此前未使用的令牌失效
+	Create(token *entity.EmailVerificationToken) error
+	GetByTokenHash(tokenHash string) (*entity.EmailVerificationToken, error)
+	// MarkUsed 标记令牌已使用，已被使用时返回 false
+	MarkUsed(tx *gorm.DB, id uint64) (bool, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// ExternalIdentityRepository 外部身份仓储接口
+type ExternalIdentityRepository interface {
+	GetByProviderSubject(provider, subject string) (*entity.UserExternalIdentity, error)
+	ListByUserID(userID uint64) ([]entity.UserExternalIdentity, error)
+	Create(tx *gorm.DB, identity *entity.UserExternalIdentity) error
+	// RecordLogin 更新最近登录时间及身份提供方返回的邮箱
+	RecordLogin(id uint64, email string, at time.Time) error
+	// Delete 物理删除用户的外部身份，不存在时返回 false
+	Delete(userID, id uint64) (bool, error)
+}
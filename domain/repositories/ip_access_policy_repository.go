@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// IPAccessPolicyRepository IP 访问策略仓储接口
+type IPAccessPolicyRepository interface {
+	Create(tx *gorm.DB, policy *entity.IPAccessPolicy) error
+	Update(tx *gorm.DB, policy *entity.IPAccessPolicy) error
+	Delete(tx *gorm.DB, id uint64) error
+	GetByID(id uint64) (*entity.IPAccessPolicy, error)
+	// List 按作用对象筛选策略，subjectType 为空时不筛选，subjectID 为 0 时不筛选
+	List(subjectType string, subjectID uint64) ([]entity.IPAccessPolicy, error)
+	// ListEnabled 获取全部已启用的策略
+	ListEnabled() ([]entity.IPAccessPolicy, error)
+}
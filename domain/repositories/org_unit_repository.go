@@ -11,6 +11,6 @@ type OrgUnitRepository interface {
 	Delete(id uint64) error
 	GetByID(id uint64) (*entity.OrgUnit, error)
 	GetByPath(path string) (*entity.OrgUnit, error)
-	GetAll() ([]entity.OrgUnit, error)
+	GetAll(scope *entity.DataScope) ([]entity.OrgUnit, error)
 	GetChildren(parentID uint64) ([]entity.OrgUnit, error)
 }
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository 找回密码重置令牌仓储接口
+type PasswordResetRepository interface {
+	// Create 写入新令牌，并使该用户此前未使用的令牌失效
+	Create(token *entity.PasswordResetToken) error
+	GetByTokenHash(tokenHash string) (*entity.PasswordResetToken, error)
+	// MarkUsed 标记令牌已使用，已被使用时返回 false
+	MarkUsed(tx *gorm.DB, id uint64) (bool, error)
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// PersonalAccessTokenRepository 个人访问令牌仓储接口
+type PersonalAccessTokenRepository interface {
+	Create(token *entity.PersonalAccessToken) error
+	GetByID(id uint64) (*entity.PersonalAccessToken, error)
+	GetByTokenHash(tokenHash string) (*entity.PersonalAccessToken, error)
+	// ListByUserID 获取用户的全部令牌（含已撤销与已过期），按创建时间倒序
+	ListByUserID(userID uint64) ([]entity.PersonalAccessToken, error)
+	// CountActiveByUserID 统计用户未撤销且未过期的令牌数量
+	CountActiveByUserID(userID uint64) (int64, error)
+	Touch(id uint64, lastUsedAt time.Time, ip string) error
+	// Revoke 撤销令牌，已撤销时返回 false
+	Revoke(id uint64) (bool, error)
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenFamilyRepository 刷新令牌家族仓储接口
+type RefreshTokenFamilyRepository interface {
+	Create(family *entity.RefreshTokenFamily) error
+	GetByFamilyID(familyID string) (*entity.RefreshTokenFamily, error)
+	// Rotate 仅当家族未撤销且当前令牌为 oldJTI 时替换为 newJTI，返回是否替换成功
+	Rotate(familyID, oldJTI, newJTI string, expiresAt time.Time) (bool, error)
+	Revoke(tx *gorm.DB, familyID string) error
+}
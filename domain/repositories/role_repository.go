@@ -19,4 +19,5 @@ type RoleRepository interface {
 	BindMenu(roleID, menuID uint64) error
 	UnbindMenu(roleID, menuID uint64) error
 	GetRoleMenus(roleID uint64) ([]entity.RoleMenu, error)
+	GetParents(roleID uint64) ([]entity.Role, error)
 }
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"gorm.io/gorm"
+)
+
+// SessionRepository 用户会话仓储接口
+type SessionRepository interface {
+	Create(tx *gorm.DB, session *entity.UserSession) error
+	GetBySessionID(sessionID string) (*entity.UserSession, error)
+	// ListActiveByUserID 获取用户未撤销且未过期的会话，按最近活跃时间倒序
+	ListActiveByUserID(userID uint64) ([]entity.UserSession, error)
+	// ListActiveForUpdate 锁定用户记录后获取其有效会话，按登录时间正序，用于串行化同一用户的并发登录
+	ListActiveForUpdate(tx *gorm.DB, userID uint64) ([]entity.UserSession, error)
+	// GetRoleSessionLimit 获取用户各角色设置的最大并发会话数中的最小值，均未设置时返回 0
+	GetRoleSessionLimit(userID uint64) (int, error)
+	// ExistsByFingerprint 用户是否曾用该设备指纹登录过（含已撤销与已过期的会话）
+	ExistsByFingerprint(userID uint64, fingerprint string) (bool, error)
+	Touch(sessionID string, lastSeenAt time.Time) error
+	Extend(sessionID string, lastSeenAt, expiresAt time.Time) error
+	Revoke(tx *gorm.DB, sessionID string) error
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// TwoFactorRepository 双因素认证仓储接口
+type TwoFactorRepository interface {
+	GetByUserID(userID uint64) (*entity.UserTwoFactor, error)
+	// Save 创建或更新用户的双因素认证配置
+	Save(tf *entity.UserTwoFactor) error
+	// Delete 删除用户的双因素认证配置及全部恢复码
+	Delete(userID uint64) error
+	Enable(userID uint64, enabledAt time.Time) error
+	// UseStep 记录已使用的时间步，仅当 step 大于已记录的时间步时成功，防止验证码重放
+	UseStep(userID uint64, step int64) (bool, error)
+	RecordFailure(userID uint64) error
+	ResetFailures(userID uint64) error
+
+	// ReplaceRecoveryCodes 删除用户原有恢复码并写入新的恢复码哈希
+	ReplaceRecoveryCodes(userID uint64, codeHashes []string) error
+	ListUnusedRecoveryCodes(userID uint64) ([]entity.UserRecoveryCode, error)
+	// UseRecoveryCode 标记恢复码已使用，已被使用时返回 false
+	UseRecoveryCode(id uint64) (bool, error)
+}
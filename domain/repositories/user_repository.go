@@ -7,11 +7,12 @@ import (
 
 // UserRepository 用户管理仓储接口（区别于 domain.UserRepo 主要用于登录场景）
 type UserRepository interface {
-	List(page, pageSize int, keyword string, orgIDs []uint64) ([]entity.User, int64, error)
+	List(page, pageSize int, keyword string, scope *entity.DataScope) ([]entity.User, int64, error)
 	GetByID(id uint64) (*entity.User, error)
 	Create(tx *gorm.DB, user *entity.User) error
 	Update(tx *gorm.DB, user *entity.User) error
 	UpdatePassword(tx *gorm.DB, id uint64, hashed string) error
+	UpdateStatus(tx *gorm.DB, id uint64, status string) error
 	Delete(tx *gorm.DB, id uint64) error
 	GetRoleIDsByUserID(userID uint64) ([]uint64, error)
 	ReplaceUserRoles(tx *gorm.DB, userID, orgUnitID uint64, roleIDs []uint64) error
@@ -26,6 +26,15 @@ func ErrorResponse(ctx *gin.Context, code int, message string) {
 	})
 }
 
+// ErrorResponseWithData 返回携带数据的错误响应，如逐条列出的校验失败原因
+func ErrorResponseWithData[T any](ctx *gin.Context, code int, message string, data *T) {
+	ctx.JSON(code, ResponseResult[T]{
+		Code:    code,
+		Message: message,
+		Data:    data,
+	})
+}
+
 // SuccessResponse 返回成功响应
 func SuccessResponse[T any](ctx *gin.Context, message string, data *T) {
 	ctx.JSON(http.StatusOK, ResponseResult[T]{
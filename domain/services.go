@@ -2,14 +2,22 @@ package domain
 
 import (
 	"context"
+	"errors"
 
 	"github.com/lyj404/gin-api-template/domain/entity"
 )
 
+// ErrRefreshTokenReused 已轮换过的刷新令牌被再次使用，所在令牌家族已被撤销
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
 type RefreshTokenService interface {
 	GetUserByID(c context.Context, id string) (entity.User, error)
-	CreateAccessToken(user *entity.User, secret string, expiry int) (accessToken string, err error)
-	CreateRefreshToken(user *entity.User, secret string, expiry int) (refreshToken string, err error)
+	// CreateAccessToken 创建归属于 sessionID 会话的访问令牌
+	CreateAccessToken(user *entity.User, sessionID string, secret string, expiry int) (accessToken string, err error)
+	// CreateRefreshToken 创建刷新令牌并以 sessionID 开启一个新的令牌家族
+	CreateRefreshToken(user *entity.User, sessionID string, secret string, expiry int) (refreshToken string, err error)
+	// RotateRefreshToken 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效；重复使用将撤销整个家族
+	RotateRefreshToken(c context.Context, requestToken string) (accessToken string, refreshToken string, err error)
 	ExtractIDFromToken(requestToken string, secret string) (string, error)
 }
 
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// ErrNotAccessRequestApprover 操作者不是该申请所在组织的审批人
+var ErrNotAccessRequestApprover = errors.New("无权审批该访问申请")
+
+// ErrAccessRequestNotPending 申请已被处理，不能再审批或撤回
+var ErrAccessRequestNotPending = errors.New("访问申请已处理")
+
+// AccessRequestService 临时访问申请服务接口
+// 审批人为系统管理员，或直接持有审批角色（Role.IsApprover）且分配所在组织为申请组织或其上级的用户，申请人不能审批自己的申请
+type AccessRequestService interface {
+	Create(req *dto.CreateAccessRequestRequest, requesterID uint64) (*entity.AccessRequest, error)
+	// ListMine 分页查询本人提交的申请
+	ListMine(requesterID uint64, status string, page, pageSize int) ([]entity.AccessRequest, int64, error)
+	// ListReviewable 分页查询操作者可审批范围内的申请
+	ListReviewable(operatorID uint64, status string, page, pageSize int) ([]entity.AccessRequest, int64, error)
+	// Approve 通过申请并创建从当前时间起、按申请时长到期的限时角色分配
+	Approve(id uint64, comment string, operatorID uint64) (*entity.AccessRequest, error)
+	Reject(id uint64, comment string, operatorID uint64) (*entity.AccessRequest, error)
+	// Cancel 申请人撤回待审批的申请
+	Cancel(id uint64, operatorID uint64) (*entity.AccessRequest, error)
+}
@@ -0,0 +1,18 @@
+package services
+
+// CaptchaChallenge 下发给客户端的验证码，答案只保存在服务端
+type CaptchaChallenge struct {
+	ID          string
+	Type        string
+	ContentType string
+	Content     []byte
+}
+
+// CaptchaService 验证码服务接口
+type CaptchaService interface {
+	// Generate 按配置的类型生成验证码，答案以验证码 ID 为键保存在服务端
+	Generate() (*CaptchaChallenge, error)
+
+	// Verify 校验答案，无论正确与否验证码都会被删除，每个验证码只能尝试一次
+	Verify(id, answer string) error
+}
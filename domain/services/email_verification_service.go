@@ -0,0 +1,13 @@
+package services
+
+import "github.com/lyj404/gin-api-template/domain/entity"
+
+// EmailVerificationService 注册邮箱验证服务接口
+type EmailVerificationService interface {
+	// Send 为待验证用户签发验证令牌并发送验证邮件，此前未使用的验证链接失效
+	Send(user *entity.User) error
+	// Verify 校验验证令牌并激活对应的待验证用户
+	Verify(token string) error
+	// Resend 管理员为待验证用户重新发送验证邮件
+	Resend(userID uint64, operatorID uint64) error
+}
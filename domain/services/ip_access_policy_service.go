@@ -0,0 +1,23 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// ErrIPNotAllowed 客户端 IP 不满足用户或其角色的访问策略
+var ErrIPNotAllowed = errors.New("当前网络不允许访问该账号")
+
+// IPAccessPolicyService IP 访问策略服务接口
+type IPAccessPolicyService interface {
+	List(subjectType string, subjectID uint64) ([]entity.IPAccessPolicy, error)
+	// Create 创建策略，operatorIP 为操作者当前 IP，策略生效后操作者自身会被拒绝时返回错误
+	Create(req *dto.CreateIPAccessPolicyRequest, operatorID uint64, operatorIP string) (*entity.IPAccessPolicy, error)
+	Update(id uint64, req *dto.UpdateIPAccessPolicyRequest, operatorID uint64, operatorIP string) (*entity.IPAccessPolicy, error)
+	Delete(id uint64, operatorID uint64, operatorIP string) error
+	// Check 校验用户能否从该 IP 访问，不允许时返回 ErrIPNotAllowed
+	// 用户与其角色的策略合并判断：命中任一 deny 即拒绝；存在 allow 策略时必须命中其中之一
+	Check(userID uint64, ip string) error
+}
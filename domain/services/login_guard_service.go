@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginBlockedError 登录因退避或锁定被拒绝，RetryAfter 为需要等待的时长
+type LoginBlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	seconds := int(e.RetryAfter.Seconds()) + 1
+	if e.Locked {
+		return fmt.Sprintf("账号已被临时锁定，请在 %d 秒后重试", seconds)
+	}
+	return fmt.Sprintf("登录尝试过于频繁，请在 %d 秒后重试", seconds)
+}
+
+// LoginGuardService 登录防护服务接口，按邮箱和客户端 IP 统计失败次数并进行退避与锁定
+type LoginGuardService interface {
+	// Check 检查邮箱和 IP 当前是否允许登录，不允许时返回 *LoginBlockedError
+	Check(email, ip string) error
+	// RecordFailure 记录一次登录失败，达到阈值时锁定账号或封禁 IP
+	RecordFailure(email, ip string) error
+	// RecordSuccess 登录成功后清除该邮箱的失败记录
+	RecordSuccess(email, ip string) error
+	// Failures 返回邮箱与 IP 在统计窗口内的登录失败次数
+	Failures(email, ip string) (emailFailures int, ipFailures int, err error)
+	// IsLocked 返回账号是否处于锁定状态及剩余锁定时长
+	IsLocked(email string) (bool, time.Duration, error)
+	// Unlock 解除账号锁定并清除失败记录
+	Unlock(userID uint64, operatorID uint64) error
+}
@@ -0,0 +1,14 @@
+package services
+
+// LoginRiskAssessment 登录风险评估结果
+type LoginRiskAssessment struct {
+	Score           int      `json:"score"`
+	Reasons         []string `json:"reasons"`
+	CaptchaRequired bool     `json:"captcha_required"`
+}
+
+// LoginRiskService 登录风险评估服务接口，风险分达到阈值时登录才需要验证码
+type LoginRiskService interface {
+	// Assess 根据邮箱与 IP 的近期失败次数、设备指纹是否陌生以及审计日志中的登录频率评估本次登录风险
+	Assess(email, ip, userAgent string) (*LoginRiskAssessment, error)
+}
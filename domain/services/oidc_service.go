@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// OIDCService OIDC 单点登录服务接口
+type OIDCService interface {
+	// Providers 返回已配置的身份提供方
+	Providers() []dto.OIDCProviderResponse
+	// AuthURL 生成 state、nonce 与 PKCE code_verifier 并返回身份提供方的授权地址
+	AuthURL(ctx context.Context, provider string) (string, error)
+	// Callback 校验 state 并用授权码换取 ID Token，按外部身份或已验证邮箱找到本地用户，必要时自动创建
+	Callback(ctx context.Context, provider, code, state string) (*entity.User, error)
+
+	ListIdentities(userID uint64) ([]entity.UserExternalIdentity, error)
+	// Unlink 解除外部身份关联，用户没有密码且只剩最后一个外部身份时不允许解除
+	Unlink(userID, identityID uint64) error
+}
@@ -0,0 +1,9 @@
+package services
+
+// PasswordResetService 找回密码服务接口
+type PasswordResetService interface {
+	// Forgot 为邮箱对应的用户签发重置令牌并发送重置邮件，邮箱不存在时同样返回成功，避免泄露账号是否存在
+	Forgot(email, ip string) error
+	// Reset 校验重置令牌并设置新密码，成功后令牌失效并撤销该用户的全部登录会话
+	Reset(token, newPassword string) error
+}
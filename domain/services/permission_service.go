@@ -1,5 +1,11 @@
 package services
 
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
 // MenuTreeNode 菜单树节点，用于返回给前端的用户菜单
 type MenuTreeNode struct {
 	ID        uint64           `json:"id"`
@@ -12,11 +18,17 @@ type MenuTreeNode struct {
 
 // PermissionService 权限服务接口，定义权限检查相关的业务逻辑
 type PermissionService interface {
-	// CheckPermission 检查用户是否有访问指定资源的权限
-	CheckPermission(userID uint64, resource string, method string) (bool, error)
+	// CheckPermission 检查用户是否有访问指定资源的权限，带条件的授权按 attrs 求值
+	CheckPermission(userID uint64, resource string, method string, attrs *AccessAttributes) (bool, error)
+
+	// CheckEntityPermission 检查用户是否有操作指定实体的权限，带条件的授权按 attrs 求值
+	CheckEntityPermission(userID uint64, entityType string, entityID uint64, action string, attrs *AccessAttributes) (bool, error)
+
+	// AllowedRouteResources 按 API 资源的 Pattern 与 Method 匹配路由和方法，返回其中用户有权访问的资源名，为空表示无权访问
+	AllowedRouteResources(userID uint64, path string, method string, attrs *AccessAttributes) ([]string, error)
 
-	// CheckEntityPermission 检查用户是否有操作指定实体的权限
-	CheckEntityPermission(userID uint64, entityType string, entityID uint64, action string) (bool, error)
+	// CheckScopes 检查令牌授权范围是否覆盖指定资源与请求方法，与用户权限取交集后生效
+	CheckScopes(scopes []PermissionInfo, resource string, method string) bool
 
 	// GetUserPermissions 获取用户的权限列表
 	GetUserPermissions(userID uint64) ([]PermissionInfo, error)
@@ -24,6 +36,12 @@ type PermissionService interface {
 	// GetUserOrgScope 获取用户的组织范围
 	GetUserOrgScope(userID uint64) ([]OrgScopeInfo, error)
 
+	// GetDataScope 获取用户的数据范围，配合 entity.InDataScope 过滤按组织划分的数据
+	GetDataScope(userID uint64) (*entity.DataScope, error)
+
+	// GetDataScopeWithFallback 获取用户的数据范围，角色未配置组织范围时退回到用户所在组织
+	GetDataScopeWithFallback(userID uint64) (*entity.DataScope, error)
+
 	// ClearUserCache 清除用户权限缓存
 	ClearUserCache(userID uint64) error
 
@@ -32,13 +50,115 @@ type PermissionService interface {
 
 	// HasSystemRole 检查用户是否拥有系统角色（如 super_admin）
 	HasSystemRole(userID uint64) (bool, error)
+
+	// ExplainPermission 按 CheckPermission/CheckEntityPermission 相同的逻辑判定，返回判定过程而不只是结果
+	ExplainPermission(req *ExplainRequest) (*PermissionTrace, error)
 }
 
 // PermissionInfo 权限信息结构
+// 带条件的授权与无条件授权分开列出，Condition 为原始表达式，ConditionText 为对应的中文说明
+// Effect 为 deny 时 IsRead/IsWrite 表示被拒绝的操作，拒绝优先于任何允许；为空时按 allow 处理
 type PermissionInfo struct {
-	ResourceName string `json:"resource_name"`
-	IsRead       bool   `json:"is_read"`
-	IsWrite      bool   `json:"is_write"`
+	ResourceName  string             `json:"resource_name"`
+	IsRead        bool               `json:"is_read"`
+	IsWrite       bool               `json:"is_write"`
+	Effect        string             `json:"effect,omitempty"`
+	Condition     string             `json:"condition,omitempty"`
+	ConditionText string             `json:"condition_text,omitempty"`
+	Sources       []PermissionSource `json:"sources,omitempty"`
+}
+
+// PermissionSource 权限来源，Role 为持有该绑定的角色，InheritedVia 为用户经由继承获得该角色时直接分配的角色
+type PermissionSource struct {
+	Role         string `json:"role"`
+	InheritedVia string `json:"inherited_via,omitempty"`
+}
+
+// AccessAttributes 权限条件求值使用的请求属性
+// 为 nil 时按当前时间求值，且不携带客户端 IP，依赖 ip 的条件不成立
+type AccessAttributes struct {
+	ClientIP string
+	Time     time.Time
+}
+
+// ExplainRequest 权限判定说明的输入，Resource、Path、EntityType 三选一
+// Path 为请求路径时需由调用方通过 RouteResolver 解析出 Route，按该路由实际使用的鉴权方式判定
+type ExplainRequest struct {
+	UserID     uint64
+	Resource   string
+	Path       string
+	Route      *ResolvedRoute // 路径判定使用
+	Method     string         // 资源或路径判定使用，默认 GET
+	EntityType string
+	EntityID   uint64
+	Action     string // 实体判定使用
+	Attrs      *AccessAttributes
+}
+
+// RouteResolver 按请求方法与路径找出注册的路由及其鉴权方式，由路由注册表实现
+type RouteResolver interface {
+	ResolveRoute(method, path string) (*ResolvedRoute, bool)
+}
+
+// ResolvedRoute 请求路径对应的路由模板与 RBAC 中间件对它的检查方式
+type ResolvedRoute struct {
+	Route     string // 路由模板，如 /users/:id
+	Resource  string // 按资源名检查时声明的资源名，为空表示按路由匹配资源
+	Unchecked bool   // 公开或登录即可访问的路由，不检查资源权限
+}
+
+// PermissionTrace 权限判定过程
+type PermissionTrace struct {
+	UserID     uint64          `json:"user_id,string"`
+	Method     string          `json:"method,omitempty"`
+	Path       string          `json:"path,omitempty"`
+	Route      string          `json:"route,omitempty"`
+	EntityType string          `json:"entity_type,omitempty"`
+	EntityID   uint64          `json:"entity_id,omitempty,string"`
+	Action     string          `json:"action,omitempty"`
+	ClientIP   string          `json:"client_ip,omitempty"`
+	Time       string          `json:"time"`
+	Roles      []TraceRole     `json:"roles"`
+	Resources  []ResourceTrace `json:"resources"`
+	OrgChecks  []OrgCheckTrace `json:"org_checks,omitempty"`
+	Allowed    bool            `json:"allowed"`
+	Reason     string          `json:"reason"`
+}
+
+// TraceRole 用户当前生效的角色，InheritedVia 为经由继承获得时直接分配的角色
+type TraceRole struct {
+	ID           uint64 `json:"id,string"`
+	Name         string `json:"name"`
+	InheritedVia string `json:"inherited_via,omitempty"`
+}
+
+// ResourceTrace 单个资源名的判定过程，Bindings 为资源模式匹配该资源名的全部授权
+type ResourceTrace struct {
+	Resource string         `json:"resource"`
+	Pattern  string         `json:"pattern,omitempty"` // 按路径判定时命中的资源路径模式
+	Bindings []BindingTrace `json:"bindings"`
+	Allowed  bool           `json:"allowed"`
+	Reason   string         `json:"reason"`
+}
+
+// BindingTrace 一条授权的匹配与求值结果
+// Applies 表示读写标记与请求方法相符（实体权限不区分读写）；ConditionHolds 为空表示判定已提前结束、未对其求值
+type BindingTrace struct {
+	PermissionInfo
+	Applies        bool  `json:"applies"`
+	ConditionHolds *bool `json:"condition_holds,omitempty"`
+	Decisive       bool  `json:"decisive,omitempty"`
+}
+
+// OrgCheckTrace 实体权限的组织范围比较，EntityPath 为实体所属组织的路径
+type OrgCheckTrace struct {
+	ScopeOrgUnitID     uint64 `json:"scope_org_unit_id,string"`
+	ScopePath          string `json:"scope_path"`
+	IncludeDescendants bool   `json:"include_descendants"`
+	EntityOrgUnitID    uint64 `json:"entity_org_unit_id,string"`
+	EntityPath         string `json:"entity_path"`
+	Matched            bool   `json:"matched"`
+	Reason             string `json:"reason"`
 }
 
 // OrgScopeInfo 组织范围信息结构
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// PersonalAccessTokenService 个人访问令牌服务接口
+type PersonalAccessTokenService interface {
+	// Create 创建令牌并返回令牌明文（只返回这一次），授权范围必须在用户当前权限之内
+	Create(userID uint64, req *dto.CreatePersonalAccessTokenRequest) (*entity.PersonalAccessToken, string, error)
+
+	// List 获取用户的全部令牌
+	List(userID uint64) ([]entity.PersonalAccessToken, error)
+
+	// Revoke 撤销用户的指定令牌，operatorID 为执行撤销的用户
+	Revoke(userID, tokenID, operatorID uint64) error
+
+	// Authenticate 校验令牌明文与所属用户状态，返回令牌及其授权范围，同时记录最近使用时间与 IP
+	Authenticate(token, ip string) (*entity.PersonalAccessToken, []PermissionInfo, error)
+}
@@ -1,10 +1,19 @@
 package services
 
 import (
+	"errors"
+	"time"
+
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/entity"
 )
 
+// ErrInvalidCondition 绑定资源时的条件表达式无法解析
+var ErrInvalidCondition = errors.New("条件表达式无效")
+
+// ErrRoleInheritanceCycle 添加父角色后会形成继承环
+var ErrRoleInheritanceCycle = errors.New("角色继承关系不能形成环")
+
 type RoleService interface {
 	CreateRole(role *entity.Role, operatorID uint64) error
 	UpdateRole(role *entity.Role, operatorID uint64) error
@@ -12,14 +21,18 @@ type RoleService interface {
 	GetRoleByID(id uint64, userID uint64) (*entity.Role, error)
 	GetAllRoles(userID uint64) ([]entity.Role, error)
 	ListRoles(req *dto.PaginationRequest, userID uint64) ([]entity.Role, int64, error)
-	BindResource(roleID, resourceID uint64, isWrite bool, operatorID uint64) error
+	BindResource(roleID, resourceID uint64, isWrite bool, effect, cond string, operatorID uint64) error
 	UnbindResource(roleID, resourceID uint64, operatorID uint64) error
 	BindOrgScope(roleID, orgUnitID uint64, includeDescendants bool, operatorID uint64) error
 	UnbindOrgScope(roleID, orgUnitID uint64, operatorID uint64) error
-	AssignRoleToUser(userID, roleID, orgUnitID uint64, operatorID uint64) error
+	AssignRoleToUser(userID, roleID, orgUnitID uint64, validFrom, validUntil *time.Time, operatorID uint64) error
 	RevokeRoleFromUser(userID, roleID, orgUnitID uint64, operatorID uint64) error
+	ListUserRoles(userID uint64, operatorID uint64) ([]entity.UserRole, error)
 	GetRoleResources(roleID uint64, userID uint64) ([]entity.RoleResource, error)
 	BindMenu(roleID, menuID uint64, operatorID uint64) error
 	UnbindMenu(roleID, menuID uint64, operatorID uint64) error
 	GetRoleMenus(roleID uint64, userID uint64) ([]entity.RoleMenu, error)
+	AddParent(roleID, parentID uint64, operatorID uint64) error
+	RemoveParent(roleID, parentID uint64, operatorID uint64) error
+	GetParents(roleID uint64, userID uint64) ([]entity.Role, error)
 }
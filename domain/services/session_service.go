@@ -0,0 +1,29 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// ErrSessionLimitExceeded 并发会话数已达上限且策略为拒绝新登录
+var ErrSessionLimitExceeded = errors.New("已达到最大同时登录数，请先在其他设备退出登录")
+
+// SessionService 用户会话服务接口，管理登录设备与远程登出
+type SessionService interface {
+	// Create 为用户创建登录会话，device 为空时根据 User-Agent 推断
+	// 超出并发会话上限时按策略返回 ErrSessionLimitExceeded 或下线最早登录的会话
+	Create(user *entity.User, ip, userAgent, device string) (*entity.UserSession, error)
+
+	// Validate 校验会话是否属于该用户且仍然有效，同时刷新最近活跃时间
+	Validate(sessionID string, userID uint64) error
+
+	// ListSessions 获取用户当前有效的会话列表
+	ListSessions(userID uint64) ([]entity.UserSession, error)
+
+	// Revoke 撤销用户的指定会话，operatorID 为执行撤销的用户
+	Revoke(userID uint64, sessionID string, operatorID uint64) error
+
+	// RevokeAll 撤销用户的全部会话，exceptSessionID 非空时保留该会话，返回撤销数量
+	RevokeAll(userID uint64, exceptSessionID string, operatorID uint64) (int, error)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+)
+
+// TwoFactorService TOTP 双因素认证服务接口
+type TwoFactorService interface {
+	Status(userID uint64) (*dto.TwoFactorStatusResponse, error)
+	// Setup 生成新的 TOTP 密钥，需通过 Confirm 提交首个验证码后才会启用
+	Setup(userID uint64) (*dto.TwoFactorSetupResponse, error)
+	// QRCode 返回待确认密钥的 otpauth 二维码 PNG
+	QRCode(userID uint64) ([]byte, error)
+	// Confirm 校验首个验证码并启用双因素认证，返回恢复码明文
+	Confirm(userID uint64, code string) ([]string, error)
+	// Disable 校验密码后关闭双因素认证
+	Disable(userID uint64, password string) error
+	// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，原恢复码全部失效
+	RegenerateRecoveryCodes(userID uint64, code string) ([]string, error)
+
+	IsEnabled(userID uint64) (bool, error)
+	// CreateChallenge 为已通过密码校验的用户签发登录挑战令牌
+	CreateChallenge(user *entity.User) (string, error)
+	// VerifyChallenge 校验挑战令牌与验证码（或恢复码），成功时返回对应用户
+	VerifyChallenge(challengeToken, code string) (*entity.User, error)
+
+	// Reset 管理员强制重置用户的双因素认证
+	Reset(userID uint64, operatorID uint64) error
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/entity"
 )
@@ -12,4 +14,22 @@ type UserManagementService interface {
 	Create(req *dto.CreateUserRequest, operatorID uint64) (*entity.User, error)
 	Update(id uint64, req *dto.UpdateUserRequest, operatorID uint64) (*entity.User, error)
 	Delete(id uint64, operatorID uint64) error
+	ListSessions(id uint64, operatorID uint64) ([]entity.UserSession, error)
+	RevokeSessions(id uint64, sessionID string, operatorID uint64) (int, error)
+	ResetTwoFactor(id uint64, operatorID uint64) error
+	Unlock(id uint64, operatorID uint64) error
+	// ResendVerification 为待验证用户重新发送邮箱验证邮件
+	ResendVerification(id uint64, operatorID uint64) error
+	// Activate 手动激活待验证或已禁用的用户
+	Activate(id uint64, operatorID uint64) error
+	// Disable 禁用用户并撤销其全部登录会话
+	Disable(id uint64, operatorID uint64) error
+	// ListTokens 获取用户的个人访问令牌
+	ListTokens(id uint64, operatorID uint64) ([]entity.PersonalAccessToken, error)
+	// RevokeToken 撤销用户的指定个人访问令牌
+	RevokeToken(id, tokenID uint64, operatorID uint64) error
+	// Impersonate 以指定用户身份签发短期模拟登录令牌，sessionID 为操作者当前的登录会话
+	Impersonate(id uint64, operatorID uint64, sessionID string) (string, time.Time, error)
+	// ExplainPermission 说明组织范围内的用户对资源或实体的权限判定过程
+	ExplainPermission(req *ExplainRequest, operatorID uint64) (*PermissionTrace, error)
 }
@@ -0,0 +1,208 @@
+package tokenutil
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/lyj404/gin-api-template/config"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// verificationKey 可用于验证访问令牌的公钥
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+var (
+	// signingMethod 访问令牌的非对称签名算法，为 nil 时使用 HMAC
+	signingMethod jwt.SigningMethod
+	signingKid    string
+	signingKey    crypto.PrivateKey
+	// verificationKeys 按 kid 索引的验证公钥，keyOrder 保持配置顺序用于输出 JWKS
+	verificationKeys map[string]verificationKey
+	keyOrder         []string
+)
+
+// JWK JSON Web Key，仅包含公钥参数
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// InitSigningKeys 根据配置加载访问令牌的签名密钥与验证公钥，HS256 时无需加载
+func InitSigningKeys() error {
+	cfg := config.CfgToken
+	switch cfg.SigningMethod {
+	case "", "HS256":
+		signingMethod = nil
+		return nil
+	case "RS256":
+		signingMethod = jwt.SigningMethodRS256
+	case "EdDSA":
+		signingMethod = jwt.SigningMethodEdDSA
+	default:
+		return fmt.Errorf("unsupported token signing method: %s", cfg.SigningMethod)
+	}
+
+	verificationKeys = make(map[string]verificationKey, len(cfg.SigningKeys))
+	keyOrder = keyOrder[:0]
+	signingKey = nil
+	signingKid = cfg.ActiveKid
+
+	for _, kc := range cfg.SigningKeys {
+		if kc.Kid == "" {
+			return fmt.Errorf("signing key kid is required")
+		}
+		if _, ok := verificationKeys[kc.Kid]; ok {
+			return fmt.Errorf("duplicate signing key kid: %s", kc.Kid)
+		}
+
+		var privateKey crypto.PrivateKey
+		var publicKey crypto.PublicKey
+		if kc.PrivateKeyFile != "" {
+			pemData, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return fmt.Errorf("read private key %s: %w", kc.Kid, err)
+			}
+			privateKey, publicKey, err = parsePrivateKey(pemData)
+			if err != nil {
+				return fmt.Errorf("parse private key %s: %w", kc.Kid, err)
+			}
+		}
+		if kc.PublicKeyFile != "" {
+			pemData, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return fmt.Errorf("read public key %s: %w", kc.Kid, err)
+			}
+			publicKey, err = parsePublicKey(pemData)
+			if err != nil {
+				return fmt.Errorf("parse public key %s: %w", kc.Kid, err)
+			}
+		}
+		if publicKey == nil {
+			return fmt.Errorf("signing key %s has neither private nor public key file", kc.Kid)
+		}
+
+		verificationKeys[kc.Kid] = verificationKey{kid: kc.Kid, method: methodForKey(publicKey), key: publicKey}
+		keyOrder = append(keyOrder, kc.Kid)
+		if kc.Kid == cfg.ActiveKid {
+			signingKey = privateKey
+		}
+	}
+
+	active, ok := verificationKeys[signingKid]
+	if !ok || signingKey == nil {
+		return fmt.Errorf("active signing key %q not found or has no private key", signingKid)
+	}
+	if active.method.Alg() != signingMethod.Alg() {
+		return fmt.Errorf("active signing key %s does not match signing method %s", signingKid, signingMethod.Alg())
+	}
+	return nil
+}
+
+// PublicJWKs 返回全部验证公钥的 JWK 表示，HS256 时为空
+func PublicJWKs() []JWK {
+	jwks := make([]JWK, 0, len(keyOrder))
+	if signingMethod == nil {
+		return jwks
+	}
+	for _, kid := range keyOrder {
+		vk := verificationKeys[kid]
+		jwk := JWK{Kid: vk.kid, Use: "sig", Alg: vk.method.Alg()}
+		switch k := vk.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// signAccessToken 使用当前签名密钥签发访问令牌，未配置非对称签名时使用 HMAC 密钥
+func signAccessToken(claims jwt.Claims, secret string) (string, error) {
+	if signingMethod == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	}
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = signingKid
+	return token.SignedString(signingKey)
+}
+
+// accessTokenKeyFunc 按令牌头中的 kid 选择验证公钥，已轮换的旧密钥在移除配置前仍然有效
+func accessTokenKeyFunc(secret string) jwt.Keyfunc {
+	if signingMethod == nil {
+		return hmacKeyFunc(secret)
+	}
+	return func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		vk, ok := verificationKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+		if t.Method.Alg() != vk.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return vk.key, nil
+	}
+}
+
+// hmacKeyFunc 仅接受 HMAC 签名的令牌
+func hmacKeyFunc(secret string) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	}
+}
+
+// parsePrivateKey 解析 PEM 格式的 RSA 或 Ed25519 私钥，同时返回对应公钥
+func parsePrivateKey(pemData []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(pemData); err == nil {
+		return key, &key.PublicKey, nil
+	}
+	key, err := jwt.ParseEdPrivateKeyFromPEM(pemData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported private key type")
+	}
+	return key, key.(ed25519.PrivateKey).Public(), nil
+}
+
+// parsePublicKey 解析 PEM 格式的 RSA 或 Ed25519 公钥
+func parsePublicKey(pemData []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pemData); err == nil {
+		return key, nil
+	}
+	key, err := jwt.ParseEdPublicKeyFromPEM(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported public key type")
+	}
+	return key, nil
+}
+
+func methodForKey(key crypto.PublicKey) jwt.SigningMethod {
+	if _, ok := key.(*rsa.PublicKey); ok {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
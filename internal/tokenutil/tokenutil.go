@@ -1,43 +1,69 @@
 package tokenutil
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/lyj404/gin-api-template/domain"
 	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/util"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 )
 
-// CreateAccessToken创建一个访问令牌
-func CreateAccessToken(user *entity.User, secret string, expire int) (accessToken string, err error) {
+// CreateAccessToken创建一个访问令牌，sessionID 为令牌所属的登录会话
+func CreateAccessToken(user *entity.User, sessionID string, secret string, expire int) (accessToken string, err error) {
 	// 创建自定义声明，其中包含用户信息和过期时间
 	claims := &domain.JwtCustomClaims{
-		ID:   strconv.FormatUint(uint64(user.ID), 16), //用户ID，转换为十六进制字符
-		Name: user.Name,                               // 用户名
+		ID:        strconv.FormatUint(uint64(user.ID), 16), //用户ID，转换为十六进制字符
+		Name:      user.Name,                               // 用户名
+		SessionID: sessionID,                               // 会话ID
+		// 密码过期时只允许修改密码，修改后刷新令牌即可解除
+		PasswordExpired: util.IsPasswordExpired(user.PasswordExpiryBase()),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expire))),
 		},
 	}
 
-	// 使用声明创建新的jwt令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// 使用密钥对令牌进行签名
-	t, err := token.SignedString([]byte(secret))
+	// 使用当前签名密钥对令牌进行签名（未配置非对称签名时使用 secret）
+	t, err := signAccessToken(claims, secret)
 	if err != nil {
 		return "", err
 	}
 	return t, nil
 }
 
-// CreateRefreshToken创建一个刷新令牌
-func CreateRefreshToken(user *entity.User, secret string, expire int) (refreshToken string, err error) {
+// CreateImpersonationToken 创建模拟登录访问令牌，expire 为有效期（分钟）
+// 令牌以被模拟用户的身份访问，同时在 act 声明中携带实际操作者，sessionID 为操作者本人的登录会话，操作者登出后令牌随之失效
+func CreateImpersonationToken(user *entity.User, actorID uint64, sessionID string, secret string, expire int) (string, time.Time, error) {
+	expiresAt := time.Now().Add(time.Minute * time.Duration(expire))
+	claims := &domain.JwtCustomClaims{
+		ID:        strconv.FormatUint(user.ID, 16),
+		Name:      user.Name,
+		SessionID: sessionID,
+		ActorID:   strconv.FormatUint(actorID, 16),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	t, err := signAccessToken(claims, secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return t, expiresAt, nil
+}
+
+// CreateRefreshToken创建一个刷新令牌，familyID 为令牌家族标识，tokenID 为本次令牌的唯一标识(jti)
+func CreateRefreshToken(user *entity.User, familyID, tokenID string, secret string, expire int) (refreshToken string, err error) {
 	// 创建自定义声明，其中包含用户信息和过期时间
 	claimsRefresh := &domain.JwtCustomRefreshClaims{
-		ID: strconv.FormatUint(uint64(user.ID), 16), //用户ID，转换为十六进制字符
+		ID:       strconv.FormatUint(uint64(user.ID), 16), //用户ID，转换为十六进制字符
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expire))),
 		},
 	}
@@ -52,6 +78,78 @@ func CreateRefreshToken(user *entity.User, secret string, expire int) (refreshTo
 	return rt, nil
 }
 
+// ParseAccessToken 解析访问令牌并返回其声明
+func ParseAccessToken(requestToken string, secret string) (*domain.JwtCustomClaims, error) {
+	claims := &domain.JwtCustomClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, accessTokenKeyFunc(secret))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("token is expired")
+		}
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("id not found in token")
+	}
+	return claims, nil
+}
+
+// ParseRefreshToken 解析刷新令牌并返回其声明
+func ParseRefreshToken(requestToken string, secret string) (*domain.JwtCustomRefreshClaims, error) {
+	claims := &domain.JwtCustomRefreshClaims{}
+	// 刷新令牌只在本服务内验证，始终使用 HMAC 签名
+	token, err := jwt.ParseWithClaims(requestToken, claims, hmacKeyFunc(secret))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("token is expired")
+		}
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// 旧版本签发的刷新令牌没有家族信息，视为无效
+	if claims.ID == "" || claims.FamilyID == "" || claims.RegisteredClaims.ID == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// challengePurpose 登录挑战令牌的用途标识
+const challengePurpose = "2fa"
+
+// CreateChallengeToken 创建双因素认证登录挑战令牌，expire 为有效期（分钟）
+func CreateChallengeToken(user *entity.User, secret string, expire int) (string, error) {
+	claims := &domain.JwtChallengeClaims{
+		ID:      strconv.FormatUint(user.ID, 16),
+		Purpose: challengePurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * time.Duration(expire))),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseChallengeToken 解析登录挑战令牌并返回用户ID（十六进制）
+func ParseChallengeToken(requestToken string, secret string) (string, error) {
+	claims := &domain.JwtChallengeClaims{}
+	token, err := jwt.ParseWithClaims(requestToken, claims, hmacKeyFunc(secret))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return "", fmt.Errorf("token is expired")
+		}
+		return "", err
+	}
+	if !token.Valid || claims.Purpose != challengePurpose || claims.ID == "" {
+		return "", fmt.Errorf("invalid token")
+	}
+	return claims.ID, nil
+}
+
 // ExtractIDFromToken从token提取用户ID
 func ExtractIDFromToken(requestToken string, secret string) (string, error) {
 	// 解析令牌并验证签名方法
@@ -87,13 +185,7 @@ func ExtractIDFromToken(requestToken string, secret string) (string, error) {
 	return id, nil
 }
 
-// parseToken 解析令牌并验证签名方法
+// parseToken 解析 HMAC 签名的令牌并验证签名方法
 func parseToken(requestToken string, secret string) (*jwt.Token, error) {
-	return jwt.Parse(requestToken, func(t *jwt.Token) (interface{}, error) {
-		// SigningMethodHS256 是 SigningMethodHMAC 的一个具体实现，因此可以使用SigningMethodHMAC来断言
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+	return jwt.Parse(requestToken, hmacKeyFunc(secret))
 }
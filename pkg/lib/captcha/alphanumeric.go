@@ -0,0 +1,188 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// alphanumericCharset 字母数字验证码字符集，去掉了 0/O、1/I/L 等易混淆字符
+const alphanumericCharset = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// defaultAlphanumericLength 未配置长度时的字符数
+const defaultAlphanumericLength = 5
+
+// AlphanumericProvider 扭曲字母数字图片验证码，答案不区分大小写
+type AlphanumericProvider struct {
+	Length int
+}
+
+func (p *AlphanumericProvider) Name() string {
+	return ProviderAlphanumeric
+}
+
+func (p *AlphanumericProvider) Generate() (*Challenge, error) {
+	length := p.Length
+	if length <= 0 {
+		length = defaultAlphanumericLength
+	}
+	answer := make([]byte, length)
+	for i := range answer {
+		answer[i] = alphanumericCharset[rng.Intn(len(alphanumericCharset))]
+	}
+
+	imageData, err := generateDistortedImage(string(answer))
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Answer:      string(answer),
+		Content:     imageData,
+		ContentType: "image/png",
+	}, nil
+}
+
+func (p *AlphanumericProvider) Match(answer, input string) bool {
+	return strings.EqualFold(strings.TrimSpace(input), answer)
+}
+
+// generateDistortedImage 逐字符随机旋转缩放后整体做正弦波形扭曲，再叠加干扰线与噪点
+func generateDistortedImage(text string) ([]byte, error) {
+	width, height := 40*(len(text)+1), 80
+
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), &image.Uniform{color.RGBA{255, 255, 255, 255}}, image.Point{}, draw.Src)
+
+	slot := width / (len(text) + 1)
+	for i, ch := range text {
+		textColor := color.RGBA{
+			R: uint8(rng.Intn(100) + 20),
+			G: uint8(rng.Intn(100) + 20),
+			B: uint8(rng.Intn(100) + 20),
+			A: 255,
+		}
+		cx := float64(slot*(i+1)) + float64(rng.Intn(9)-4)
+		cy := float64(height/2) + float64(rng.Intn(11)-5)
+		angle := (rng.Float64() - 0.5) * 0.8
+		scale := 5.0 + rng.Float64()*1.5
+		drawRotatedGlyph(src, ch, cx, cy, angle, scale, textColor)
+	}
+
+	// 水平与垂直方向的正弦扭曲，打乱字符笔画的直线特征
+	dst := image.NewRGBA(src.Bounds())
+	ampX, ampY := 3+rng.Float64()*3, 2+rng.Float64()*3
+	periodX, periodY := 30+rng.Float64()*30, 60+rng.Float64()*60
+	phaseX, phaseY := rng.Float64()*2*math.Pi, rng.Float64()*2*math.Pi
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := x + int(ampX*math.Sin(2*math.Pi*float64(y)/periodX+phaseX))
+			sy := y + int(ampY*math.Sin(2*math.Pi*float64(x)/periodY+phaseY))
+			if sx < 0 || sx >= width || sy < 0 || sy >= height {
+				dst.Set(x, y, color.RGBA{255, 255, 255, 255})
+				continue
+			}
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+
+	// 贯穿文字的干扰曲线
+	curveColor := color.RGBA{R: uint8(rng.Intn(100)), G: uint8(rng.Intn(100)), B: uint8(rng.Intn(100)), A: 255}
+	amp, period, phase := 8+rng.Float64()*10, 80+rng.Float64()*80, rng.Float64()*2*math.Pi
+	for x := 0; x < width; x++ {
+		y := height/2 + int(amp*math.Sin(2*math.Pi*float64(x)/period+phase))
+		dst.Set(x, y, curveColor)
+		dst.Set(x, y+1, curveColor)
+	}
+
+	for i := 0; i < 4; i++ {
+		lineColor := color.RGBA{
+			R: uint8(rng.Intn(160)),
+			G: uint8(rng.Intn(160)),
+			B: uint8(rng.Intn(160)),
+			A: 255,
+		}
+		drawLine(dst, rng.Intn(width), rng.Intn(height), rng.Intn(width), rng.Intn(height), lineColor)
+	}
+	for i := 0; i < width; i++ {
+		dst.Set(rng.Intn(width), rng.Intn(height), color.RGBA{
+			R: uint8(rng.Intn(256)),
+			G: uint8(rng.Intn(256)),
+			B: uint8(rng.Intn(256)),
+			A: 255,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawRotatedGlyph 以 (cx, cy) 为中心绘制旋转 angle 弧度、每个点阵单元放大 scale 像素的字符
+// 采用逆向映射：遍历目标像素并反算回点阵坐标，旋转后笔画不会出现空洞
+func drawRotatedGlyph(img *image.RGBA, ch rune, cx, cy, angle, scale float64, textColor color.RGBA) {
+	glyph, ok := glyphs5x7[ch]
+	if !ok {
+		return
+	}
+	cols, rows := float64(len(glyph[0])), float64(len(glyph))
+	radius := int(math.Hypot(cols, rows)*scale/2) + 1
+	sin, cos := math.Sin(-angle), math.Cos(-angle)
+
+	for py := int(cy) - radius; py <= int(cy)+radius; py++ {
+		for px := int(cx) - radius; px <= int(cx)+radius; px++ {
+			if px < 0 || px >= img.Rect.Dx() || py < 0 || py >= img.Rect.Dy() {
+				continue
+			}
+			dx, dy := float64(px)-cx, float64(py)-cy
+			u := (dx*cos-dy*sin)/scale + cols/2
+			v := (dx*sin+dy*cos)/scale + rows/2
+			if u < 0 || v < 0 || u >= cols || v >= rows {
+				continue
+			}
+			if glyph[int(v)][int(u)] == '#' {
+				img.Set(px, py, textColor)
+			}
+		}
+	}
+}
+
+// glyphs5x7 字母数字验证码使用的 5x7 点阵字模
+var glyphs5x7 = map[rune][]string{
+	'A': {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C': {" ####", "#    ", "#    ", "#    ", "#    ", "#    ", " ####"},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+	'F': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'G': {" ####", "#    ", "#    ", "#  ##", "#   #", "#   #", " ####"},
+	'H': {"#   #", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'J': {"  ###", "   # ", "   # ", "   # ", "   # ", "#  # ", " ##  "},
+	'K': {"#   #", "#  # ", "# #  ", "##   ", "# #  ", "#  # ", "#   #"},
+	'M': {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+	'N': {"#   #", "##  #", "# # #", "#  ##", "#   #", "#   #", "#   #"},
+	'P': {"#### ", "#   #", "#   #", "#### ", "#    ", "#    ", "#    "},
+	'Q': {" ### ", "#   #", "#   #", "#   #", "# # #", "#  # ", " ## #"},
+	'R': {"#### ", "#   #", "#   #", "#### ", "# #  ", "#  # ", "#   #"},
+	'S': {" ####", "#    ", "#    ", " ### ", "    #", "    #", "#### "},
+	'T': {"#####", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U': {"#   #", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'V': {"#   #", "#   #", "#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W': {"#   #", "#   #", "#   #", "# # #", "# # #", "## ##", "#   #"},
+	'X': {"#   #", "#   #", " # # ", "  #  ", " # # ", "#   #", "#   #"},
+	'Y': {"#   #", "#   #", " # # ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'Z': {"#####", "    #", "   # ", "  #  ", " #   ", "#    ", "#####"},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+}
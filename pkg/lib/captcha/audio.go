@@ -0,0 +1,120 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// 音频参数：8kHz 16 位单声道 PCM，体积小且所有浏览器都能直接播放
+const (
+	audioSampleRate = 8000
+	audioBeep       = 0.15 // 单次蜂鸣时长（秒）
+	audioBeepGap    = 0.15 // 同一数字内蜂鸣间隔（秒）
+	audioDigitGap   = 0.9  // 数字之间的停顿（秒）
+	audioLeadIn     = 0.5  // 开头静音（秒）
+	audioMaxDigit   = 5    // 每位数字最多蜂鸣次数，控制音频总时长
+)
+
+// defaultAudioLength 未配置长度时的数字位数
+const defaultAudioLength = 4
+
+// AudioProvider 音频验证码，供无法识别图片的用户使用
+// 每位数字对应一组蜂鸣，数字 n 连续响 n 声，组与组之间有较长停顿，用户按顺序输入每组的次数
+type AudioProvider struct {
+	Length int
+}
+
+func (p *AudioProvider) Name() string {
+	return ProviderAudio
+}
+
+func (p *AudioProvider) Generate() (*Challenge, error) {
+	length := p.Length
+	if length <= 0 {
+		length = defaultAudioLength
+	}
+	digits := make([]int, length)
+	answer := make([]byte, length)
+	for i := range digits {
+		digits[i] = rng.Intn(audioMaxDigit) + 1
+		answer[i] = byte('0' + digits[i])
+	}
+
+	return &Challenge{
+		Answer:      string(answer),
+		Content:     encodeWAV(synthesizeBeeps(digits)),
+		ContentType: "audio/wav",
+	}, nil
+}
+
+func (p *AudioProvider) Match(answer, input string) bool {
+	return strings.Join(strings.Fields(input), "") == answer
+}
+
+// synthesizeBeeps 按数字生成蜂鸣序列，每声频率随机浮动并叠加白噪声，增加机器识别难度
+func synthesizeBeeps(digits []int) []int16 {
+	total := audioLeadIn
+	for i, d := range digits {
+		total += float64(d)*(audioBeep+audioBeepGap) - audioBeepGap
+		if i < len(digits)-1 {
+			total += audioDigitGap
+		}
+	}
+	total += audioLeadIn
+
+	samples := make([]float64, int(total*audioSampleRate))
+	pos := int(audioLeadIn * audioSampleRate)
+	beepLen := int(audioBeep * audioSampleRate)
+	fade := audioSampleRate / 100 // 10ms 淡入淡出，避免爆音
+	for _, d := range digits {
+		for n := 0; n < d; n++ {
+			freq := 600 + rng.Float64()*400
+			for i := 0; i < beepLen && pos+i < len(samples); i++ {
+				gain := 1.0
+				if i < fade {
+					gain = float64(i) / float64(fade)
+				} else if i > beepLen-fade {
+					gain = float64(beepLen-i) / float64(fade)
+				}
+				samples[pos+i] += 0.6 * gain * math.Sin(2*math.Pi*freq*float64(i)/audioSampleRate)
+			}
+			pos += beepLen + int(audioBeepGap*audioSampleRate)
+		}
+		pos += int((audioDigitGap - audioBeepGap) * audioSampleRate)
+	}
+
+	pcm := make([]int16, len(samples))
+	for i, s := range samples {
+		s += (rng.Float64() - 0.5) * 0.1
+		s = math.Max(-1, math.Min(1, s))
+		pcm[i] = int16(s * math.MaxInt16)
+	}
+	return pcm
+}
+
+// encodeWAV 将 16 位单声道 PCM 数据封装为 WAV 文件
+func encodeWAV(pcm []int16) []byte {
+	dataSize := uint32(len(pcm) * 2)
+	var buf bytes.Buffer
+	buf.Grow(44 + int(dataSize))
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))                // fmt 块大小
+	binary.Write(&buf, binary.LittleEndian, uint16(1))                 // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1))                 // 单声道
+	binary.Write(&buf, binary.LittleEndian, uint32(audioSampleRate))   // 采样率
+	binary.Write(&buf, binary.LittleEndian, uint32(audioSampleRate*2)) // 字节率
+	binary.Write(&buf, binary.LittleEndian, uint16(2))                 // 块对齐
+	binary.Write(&buf, binary.LittleEndian, uint16(16))                // 位深
+
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, pcm)
+	return buf.Bytes()
+}
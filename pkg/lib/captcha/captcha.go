@@ -9,8 +9,35 @@ import (
 	"image/draw"
 	"image/png"
 	"math/rand"
+	"strconv"
+	"strings"
 )
 
+// MathProvider 算术题图片验证码，答案为整数
+type MathProvider struct{}
+
+func (p *MathProvider) Name() string {
+	return ProviderMath
+}
+
+func (p *MathProvider) Generate() (*Challenge, error) {
+	problem := GenerateMathProblem()
+	imageData, err := GenerateCaptchaImageBytes(problem.Question)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Answer:      strconv.Itoa(problem.Answer),
+		Content:     imageData,
+		ContentType: "image/png",
+	}, nil
+}
+
+func (p *MathProvider) Match(answer, input string) bool {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	return err == nil && strconv.Itoa(n) == answer
+}
+
 func GenerateMathProblem() CaptchaProblem {
 	captchaType := CaptchaType(rng.Intn(4))
 
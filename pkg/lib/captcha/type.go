@@ -1,11 +1,10 @@
 package captcha
 
 import (
+	"fmt"
 	"math/rand"
+	"sync"
 	"time"
-
-	"github.com/gorilla/sessions"
-	"github.com/lyj404/gin-api-template/config"
 )
 
 // 验证码类型
@@ -31,15 +30,60 @@ type CaptchaReponse struct {
 	ExpireTime int    `json:"expire_time"`
 }
 
-// Session 存储配置
-var Store *sessions.CookieStore
+// 验证码提供方名称，对应配置 captcha.Type
+const (
+	ProviderMath         = "math"         // 算术题图片
+	ProviderAlphanumeric = "alphanumeric" // 扭曲字母数字图片
+	ProviderAudio        = "audio"        // 数蜂鸣声的 WAV 音频
+)
+
+// Challenge 生成的验证码，Answer 只保存在服务端，Content 发送给客户端
+type Challenge struct {
+	Answer      string
+	Content     []byte
+	ContentType string
+}
 
-func InitStore() {
-	Store = sessions.NewCookieStore([]byte(config.CfgSession.SessionSecret))
+// CaptchaProvider 验证码提供方，负责出题与判定答案
+type CaptchaProvider interface {
+	// Name 提供方名称
+	Name() string
+	// Generate 生成一道验证码
+	Generate() (*Challenge, error)
+	// Match 判断用户输入是否与答案一致，由提供方决定是否忽略大小写、空白等
+	Match(answer, input string) bool
 }
 
-// 验证码 Session Key
-const CaptchaSessionKey = "captcha_data"
+// NewProvider 按名称创建验证码提供方，length 为字母数字与音频验证码的字符数
+func NewProvider(name string, length int) (CaptchaProvider, error) {
+	switch name {
+	case "", ProviderMath:
+		return &MathProvider{}, nil
+	case ProviderAlphanumeric:
+		return &AlphanumericProvider{Length: length}, nil
+	case ProviderAudio:
+		return &AudioProvider{Length: length}, nil
+	}
+	return nil, fmt.Errorf("不支持的验证码类型: %s", name)
+}
 
 // 全局随机数生成器
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+var rng = &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// lockedRand 并发安全的随机数生成器，验证码在多个请求中并发生成
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
@@ -0,0 +1,337 @@
+// Package condition 实现角色资源绑定上的访问条件表达式（ABAC）
+//
+// 表达式由比较式通过 &&（and）、||（or）、!（not）和括号组合而成，比较式左侧为请求属性：
+//
+//	time      请求时刻（服务器本地时区），取值如 09:00，支持 == != < <= > >=
+//	weekday   星期，取值 mon..sun 或 1..7（周一为 1），支持 == != < <= > >= in、not in
+//	ip        客户端 IP，取值为 IP 或 CIDR 字符串，支持 == != in、not in，按网段包含判断
+//	org_unit  用户角色所在的组织节点，取值为组织 ID，支持 == != in、not in、under（该组织或其下级）
+//
+// 例如财务人员仅在工作日 9 点到 18 点可写：
+//
+//	weekday in [mon, tue, wed, thu, fri] && time >= 09:00 && time < 18:00
+package condition
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxLength 条件表达式的最大长度，与数据库字段长度一致
+const MaxLength = 500
+
+// OrgUnit 用户所在的组织节点，Path 形如 /1/3/5
+type OrgUnit struct {
+	ID   uint64
+	Path string
+}
+
+// Attributes 求值时使用的请求属性
+type Attributes struct {
+	Time     time.Time
+	IP       net.IP    // 为空时所有 ip 比较都不成立
+	OrgUnits []OrgUnit // 用户通过角色分配所在的组织节点
+}
+
+// Expr 编译后的条件表达式
+type Expr struct {
+	source  string
+	root    node
+	usesOrg bool
+}
+
+// Compile 解析并校验条件表达式，错误信息可直接返回给调用方
+func Compile(source string) (*Expr, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("条件表达式不能为空")
+	}
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("条件表达式长度不能超过 %d", MaxLength)
+	}
+	p := &parser{lex: newLexer(source)}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	expr := &Expr{source: source, root: root}
+	walk(root, func(n node) {
+		if c, ok := n.(*compareNode); ok && c.attr == attrOrgUnit {
+			expr.usesOrg = true
+		}
+	})
+	return expr, nil
+}
+
+// compiled 已编译表达式缓存，权限检查时避免重复解析
+var compiled sync.Map
+
+// Cached 返回缓存的编译结果，同一表达式只解析一次
+func Cached(source string) (*Expr, error) {
+	if v, ok := compiled.Load(source); ok {
+		return v.(*Expr), nil
+	}
+	expr, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	compiled.Store(source, expr)
+	return expr, nil
+}
+
+// String 返回规范化前的原始表达式
+func (e *Expr) String() string {
+	return e.source
+}
+
+// UsesOrgUnit 表达式是否引用 org_unit，调用方据此决定是否加载用户组织
+func (e *Expr) UsesOrgUnit() bool {
+	return e.usesOrg
+}
+
+// Eval 按请求属性求值
+func (e *Expr) Eval(attrs Attributes) bool {
+	return e.root.eval(&attrs)
+}
+
+// Explain 返回表达式的中文说明，用于权限列表展示
+func (e *Expr) Explain() string {
+	return e.root.explain(false)
+}
+
+// 属性名
+const (
+	attrTime    = "time"
+	attrWeekday = "weekday"
+	attrIP      = "ip"
+	attrOrgUnit = "org_unit"
+)
+
+// 比较运算符
+const (
+	opEq    = "=="
+	opNe    = "!="
+	opLt    = "<"
+	opLe    = "<="
+	opGt    = ">"
+	opGe    = ">="
+	opIn    = "in"
+	opNotIn = "not in"
+	opUnder = "under"
+)
+
+var weekdayNames = map[string]int{
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+	"sun": 7, "sunday": 7,
+}
+
+var weekdayLabels = [...]string{"", "周一", "周二", "周三", "周四", "周五", "周六", "周日"}
+
+// value 比较式右侧的字面量，按属性类型转换后的结果
+type value struct {
+	num  int        // time 为当天分钟数，weekday 为 1..7
+	id   uint64     // org_unit
+	cidr *net.IPNet // ip
+	text string     // 展示用
+}
+
+// node 表达式语法树节点
+type node interface {
+	eval(attrs *Attributes) bool
+	explain(nested bool) string
+}
+
+type logicNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicNode) eval(attrs *Attributes) bool {
+	if n.and {
+		return n.left.eval(attrs) && n.right.eval(attrs)
+	}
+	return n.left.eval(attrs) || n.right.eval(attrs)
+}
+
+func (n *logicNode) explain(nested bool) string {
+	word := " 或 "
+	if n.and {
+		word = " 且 "
+	}
+	text := n.left.explain(n.childNeedsParen(n.left)) + word + n.right.explain(n.childNeedsParen(n.right))
+	if nested {
+		return "（" + text + "）"
+	}
+	return text
+}
+
+// childNeedsParen 子表达式与父节点运算符不同时加括号，避免说明产生歧义
+func (n *logicNode) childNeedsParen(child node) bool {
+	c, ok := child.(*logicNode)
+	return ok && c.and != n.and
+}
+
+type notNode struct {
+	inner node
+}
+
+func (n *notNode) eval(attrs *Attributes) bool {
+	return !n.inner.eval(attrs)
+}
+
+func (n *notNode) explain(bool) string {
+	return "不满足（" + n.inner.explain(false) + "）"
+}
+
+type compareNode struct {
+	attr   string
+	op     string
+	values []value
+}
+
+func (n *compareNode) eval(attrs *Attributes) bool {
+	switch n.attr {
+	case attrTime:
+		t := attrs.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		return compareInt(t.Hour()*60+t.Minute(), n.op, n.values[0].num)
+	case attrWeekday:
+		t := attrs.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		wd := int(t.Weekday())
+		if wd == 0 {
+			wd = 7
+		}
+		return n.matchAny(func(v value) bool { return wd == v.num }, func(v value) bool { return compareInt(wd, n.op, v.num) })
+	case attrIP:
+		if attrs.IP == nil {
+			return false
+		}
+		return n.matchAny(func(v value) bool { return v.cidr.Contains(attrs.IP) }, nil)
+	case attrOrgUnit:
+		if n.op == opUnder {
+			for _, org := range attrs.OrgUnits {
+				if strings.Contains(org.Path+"/", "/"+strconv.FormatUint(n.values[0].id, 10)+"/") {
+					return true
+				}
+			}
+			return false
+		}
+		return n.matchAny(func(v value) bool {
+			for _, org := range attrs.OrgUnits {
+				if org.ID == v.id {
+					return true
+				}
+			}
+			return false
+		}, nil)
+	}
+	return false
+}
+
+// matchAny 处理 == != in、not in 的集合语义，其余比较运算交给 compare
+func (n *compareNode) matchAny(equal func(value) bool, compare func(value) bool) bool {
+	switch n.op {
+	case opEq, opIn, opNe, opNotIn:
+		hit := false
+		for _, v := range n.values {
+			if equal(v) {
+				hit = true
+				break
+			}
+		}
+		if n.op == opNe || n.op == opNotIn {
+			return !hit
+		}
+		return hit
+	default:
+		return compare != nil && compare(n.values[0])
+	}
+}
+
+func compareInt(a int, op string, b int) bool {
+	switch op {
+	case opEq:
+		return a == b
+	case opNe:
+		return a != b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	}
+	return false
+}
+
+func (n *compareNode) explain(bool) string {
+	texts := make([]string, len(n.values))
+	for i, v := range n.values {
+		texts[i] = v.text
+	}
+	list := strings.Join(texts, "、")
+
+	switch n.attr {
+	case attrTime:
+		return "时间" + orderWords[n.op] + " " + list
+	case attrWeekday:
+		if word, ok := orderWords[n.op]; ok && n.op != opEq && n.op != opNe {
+			return "星期" + word + " " + list
+		}
+		if n.op == opNe || n.op == opNotIn {
+			return "星期不为 " + list
+		}
+		return "星期为 " + list
+	case attrIP:
+		if n.op == opNe || n.op == opNotIn {
+			return "客户端 IP 不属于 " + list
+		}
+		return "客户端 IP 属于 " + list
+	case attrOrgUnit:
+		switch n.op {
+		case opUnder:
+			return "所属组织为 " + list + " 或其下级"
+		case opNe, opNotIn:
+			return "所属组织不为 " + list
+		}
+		return "所属组织为 " + list
+	}
+	return ""
+}
+
+var orderWords = map[string]string{
+	opEq: "为",
+	opNe: "不为",
+	opLt: "早于",
+	opLe: "不晚于",
+	opGt: "晚于",
+	opGe: "不早于",
+}
+
+// walk 深度优先遍历语法树
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch v := n.(type) {
+	case *logicNode:
+		walk(v.left, fn)
+		walk(v.right, fn)
+	case *notNode:
+		walk(v.inner, fn)
+	}
+}
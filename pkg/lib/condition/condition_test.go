@@ -0,0 +1,151 @@
+package condition
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 2026-10-14 为周三
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 14, hour, minute, 0, 0, time.Local)
+}
+
+func TestEval(t *testing.T) {
+	wed := Attributes{Time: at(10, 30), IP: net.ParseIP("10.1.2.3")}
+	sun := Attributes{Time: time.Date(2026, 10, 18, 10, 30, 0, 0, time.Local), IP: net.ParseIP("10.1.2.3")}
+	lateNight := Attributes{Time: at(23, 59)}
+	midnight := Attributes{Time: at(0, 0)}
+	noIP := Attributes{Time: at(10, 30)}
+	inOrg := Attributes{Time: at(10, 30), OrgUnits: []OrgUnit{{ID: 5, Path: "/1/3/5"}}}
+
+	tests := []struct {
+		name   string
+		source string
+		attrs  Attributes
+		want   bool
+	}{
+		// 时刻边界
+		{"24:00 作为当天结束", "time < 24:00", lateNight, true},
+		{"23:59 含当分钟", "time <= 23:59", lateNight, true},
+		{"23:59 不早于", "time >= 23:59", lateNight, true},
+		{"午夜早于 00:01", "time < 00:01", midnight, true},
+		{"区间内", "time >= 09:00 && time < 18:00", wed, true},
+		{"区间外", "time >= 09:00 && time < 18:00", lateNight, false},
+		{"带引号的时刻", `time == "10:30"`, wed, true},
+
+		// 星期名称与数字
+		{"星期简称", "weekday == wed", wed, true},
+		{"星期全称", "weekday == Wednesday", wed, true},
+		{"星期数字", "weekday == 3", wed, true},
+		{"周日为 7", "weekday == 7", sun, true},
+		{"周日名称", "weekday == sun", sun, true},
+		{"工作日列表命中", "weekday in [mon, tue, wed, thu, fri]", wed, true},
+		{"工作日列表未命中", "weekday in [1, 2, 3, 4, 5]", sun, false},
+		{"not in 列表", "weekday not in [sat, sun]", sun, false},
+		{"星期比较", "weekday <= 5", wed, true},
+		{"星期不等", "weekday != wed", wed, false},
+
+		// CIDR 与单个 IP
+		{"CIDR 包含", `ip == "10.1.0.0/16"`, wed, true},
+		{"CIDR 不包含", `ip == "10.2.0.0/16"`, wed, false},
+		{"单个 IP 相等", `ip == "10.1.2.3"`, wed, true},
+		{"单个 IP 不等", `ip == "10.1.2.4"`, wed, false},
+		{"IP 列表混合", `ip in ["192.168.0.1", "10.0.0.0/8"]`, wed, true},
+		{"IP not in", `ip not in ["10.0.0.0/8"]`, wed, false},
+		{"IPv6 CIDR", `ip == "fd00::/8"`, Attributes{IP: net.ParseIP("fd00::1")}, true},
+		{"缺少 IP 时 == 不成立", `ip == "10.0.0.0/8"`, noIP, false},
+		{"缺少 IP 时 != 也不成立", `ip != "10.0.0.0/8"`, noIP, false},
+
+		// 组织
+		{"组织相等", "org_unit == 5", inOrg, true},
+		{"组织下级", "org_unit under 3", inOrg, true},
+		{"组织不在下级", "org_unit under 4", inOrg, false},
+
+		// 取反与优先级
+		{"! 取反", "!(weekday == wed)", wed, false},
+		{"not 取反", "not weekday == sun", wed, true},
+		{"双重取反", "!!(weekday == wed)", wed, true},
+		{"! 优先于 &&", "!weekday == sun && time > 12:00", sun, false},
+		{"&& 优先于 ||", "weekday == wed || weekday == sun && time > 12:00", wed, true},
+		{"括号改变优先级", "(weekday == wed || weekday == sun) && time > 12:00", wed, false},
+		{"and/or 关键字", "weekday == wed or weekday == sun and time > 12:00", wed, true},
+		{"not 作用于括号", "not (time >= 09:00 and time < 18:00)", lateNight, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile(%q) 失败: %v", tt.source, err)
+			}
+			if got := expr.Eval(tt.attrs); got != tt.want {
+				t.Errorf("Eval(%q) = %v，期望 %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"空表达式", "   ", "不能为空"},
+		{"超长", "time < 10:00" + strings.Repeat(" || time < 10:00", MaxLength/16), "长度不能超过"},
+		{"小时越界", "time < 25:00", "无效的时刻"},
+		{"24 点之后的分钟", "time < 24:01", "无效的时刻"},
+		{"分钟越界", "time < 9:60", "无效的时刻"},
+		{"时刻缺少分钟", "time < 9:", "无效的时刻"},
+		{"时刻不支持 in", "time in [09:00]", "不支持运算符"},
+		{"星期越界", "weekday == 8", "无效的星期"},
+		{"星期 0", "weekday == 0", "无效的星期"},
+		{"未知星期名", "weekday == funday", "无效的星期"},
+		{"IP 未加引号", "ip == 10", "带引号"},
+		{"无效 IP", `ip == "10.0.0.256"`, "无效的 IP"},
+		{"无效 CIDR", `ip == "10.0.0.0/33"`, "无效的 IP"},
+		{"IP 不支持比较", `ip < "10.0.0.1"`, "不支持运算符"},
+		{"组织 ID 为 0", "org_unit == 0", "无效的组织 ID"},
+		{"未知属性", "user == 1", "未知属性"},
+		{"字符串缺少结束引号", `ip == "10.0.0.1`, "缺少结束引号"},
+		{"单引号缺少结束引号", `ip in ['10.0.0.1]`, "缺少结束引号"},
+		{"括号未闭合", "(weekday == wed", "意外结束"},
+		{"列表未闭合", "weekday in [mon, tue", "意外结束"},
+		{"多余的右括号", "weekday == wed)", "多余的内容"},
+		{"in 缺少列表", "weekday in mon", "此处应为 ["},
+		{"not 之后不是 in", "weekday not == mon", "not 之后应为 in"},
+		{"缺少右侧表达式", "weekday == wed &&", "意外结束"},
+		{"! 之后为空", "!", "意外结束"},
+		{"无法识别的字符", "weekday == wed & time < 10:00", "无法识别的字符"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.source)
+			if err == nil {
+				t.Fatalf("Compile(%q) 应返回错误", tt.source)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile(%q) 错误为 %q，应包含 %q", tt.source, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCached(t *testing.T) {
+	source := "weekday in [sat, sun]"
+	first, err := Cached(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Cached(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("同一表达式应返回缓存的编译结果")
+	}
+	if _, err := Cached("weekday in [sat"); err == nil {
+		t.Error("无效表达式不应被缓存为成功")
+	}
+}
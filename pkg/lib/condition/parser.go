@@ -0,0 +1,365 @@
+package condition
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokTime
+	tokString
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lexer 将表达式切分为标识符、数字、时刻（HH:MM）、字符串和符号
+type lexer struct {
+	src string
+	pos int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t' || l.src[l.pos] == '\n' || l.src[l.pos] == '\r') {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	ch := l.src[l.pos]
+	switch {
+	case isLetter(ch):
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: strings.ToLower(l.src[start:l.pos]), pos: start}, nil
+	case isDigit(ch):
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		if l.pos < len(l.src) && l.src[l.pos] == ':' {
+			l.pos++
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+			return token{kind: tokTime, text: l.src[start:l.pos], pos: start}, nil
+		}
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+	case ch == '"' || ch == '\'':
+		l.pos++
+		end := strings.IndexByte(l.src[l.pos:], ch)
+		if end < 0 {
+			return token{}, fmt.Errorf("条件表达式第 %d 个字符处的字符串缺少结束引号", start+1)
+		}
+		text := l.src[l.pos : l.pos+end]
+		l.pos += end + 1
+		return token{kind: tokString, text: text, pos: start}, nil
+	}
+
+	for _, sym := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","} {
+		if strings.HasPrefix(l.src[l.pos:], sym) {
+			l.pos += len(sym)
+			return token{kind: tokSymbol, text: sym, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("条件表达式第 %d 个字符处存在无法识别的字符 %q", start+1, ch)
+}
+
+func isLetter(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// parser 递归下降解析，优先级从低到高为 ||、&&、!、比较式
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) is(text string) bool {
+	return (p.tok.kind == tokSymbol || p.tok.kind == tokIdent) && p.tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf("此处应为 %s", text)
+	}
+	return p.advance()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("条件表达式意外结束，"+format, args...)
+	}
+	return fmt.Errorf("条件表达式第 %d 个字符附近有误，"+format, append([]any{p.tok.pos + 1}, args...)...)
+}
+
+func (p *parser) parse() (node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("多余的内容 %q", p.tok.text)
+	}
+	return root, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("||") || p.is("or") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("&&") || p.is("and") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.is("!") || p.is("not") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	}
+	if p.is("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	if p.tok.kind != tokIdent {
+		return nil, p.errorf("应为属性名（time、weekday、ip、org_unit）")
+	}
+	attr := p.tok.text
+	switch attr {
+	case attrTime, attrWeekday, attrIP, attrOrgUnit:
+	default:
+		return nil, p.errorf("未知属性 %s，可用属性为 time、weekday、ip、org_unit", attr)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	if !operatorAllowed(attr, op) {
+		return nil, p.errorf("属性 %s 不支持运算符 %s", attr, op)
+	}
+
+	var raws []token
+	if op == opIn || op == opNotIn {
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		for {
+			raws = append(raws, p.tok)
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.is("]") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	} else {
+		raws = append(raws, p.tok)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]value, 0, len(raws))
+	for _, raw := range raws {
+		v, err := convertValue(attr, raw)
+		if err != nil {
+			return nil, fmt.Errorf("条件表达式第 %d 个字符附近有误，%v", raw.pos+1, err)
+		}
+		values = append(values, v)
+	}
+	return &compareNode{attr: attr, op: op, values: values}, nil
+}
+
+func (p *parser) parseOperator() (string, error) {
+	var op string
+	switch {
+	case p.tok.kind == tokSymbol && (p.is(opEq) || p.is(opNe) || p.is(opLt) || p.is(opLe) || p.is(opGt) || p.is(opGe)):
+		op = p.tok.text
+	case p.is(opIn), p.is(opUnder):
+		op = p.tok.text
+	case p.is("not"):
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		if !p.is(opIn) {
+			return "", p.errorf("not 之后应为 in")
+		}
+		op = opNotIn
+	default:
+		return "", p.errorf("应为比较运算符")
+	}
+	return op, p.advance()
+}
+
+// operatorAllowed 按属性类型限制可用的运算符
+func operatorAllowed(attr, op string) bool {
+	switch attr {
+	case attrTime:
+		return op != opIn && op != opNotIn && op != opUnder
+	case attrWeekday:
+		return op != opUnder
+	case attrIP:
+		return op == opEq || op == opNe || op == opIn || op == opNotIn
+	case attrOrgUnit:
+		return op == opEq || op == opNe || op == opIn || op == opNotIn || op == opUnder
+	}
+	return false
+}
+
+// convertValue 按属性类型解析字面量，同时生成展示文本
+func convertValue(attr string, tok token) (value, error) {
+	switch attr {
+	case attrTime:
+		if tok.kind != tokTime && tok.kind != tokString {
+			return value{}, fmt.Errorf("time 的取值应为 HH:MM")
+		}
+		parts := strings.Split(tok.text, ":")
+		if len(parts) != 2 {
+			return value{}, fmt.Errorf("无效的时刻 %s", tok.text)
+		}
+		h, errH := strconv.Atoi(parts[0])
+		m, errM := strconv.Atoi(parts[1])
+		if errH != nil || errM != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+			return value{}, fmt.Errorf("无效的时刻 %s", tok.text)
+		}
+		return value{num: h*60 + m, text: fmt.Sprintf("%02d:%02d", h, m)}, nil
+	case attrWeekday:
+		var day int
+		switch tok.kind {
+		case tokNumber:
+			day, _ = strconv.Atoi(tok.text)
+		case tokIdent, tokString:
+			day = weekdayNames[strings.ToLower(tok.text)]
+		}
+		if day < 1 || day > 7 {
+			return value{}, fmt.Errorf("无效的星期 %s，应为 mon..sun 或 1..7", tok.text)
+		}
+		return value{num: day, text: weekdayLabels[day]}, nil
+	case attrIP:
+		if tok.kind != tokString {
+			return value{}, fmt.Errorf("ip 的取值应为带引号的 IP 或 CIDR")
+		}
+		cidr, err := parseCIDR(tok.text)
+		if err != nil {
+			return value{}, err
+		}
+		return value{cidr: cidr, text: tok.text}, nil
+	case attrOrgUnit:
+		if tok.kind != tokNumber && tok.kind != tokString {
+			return value{}, fmt.Errorf("org_unit 的取值应为组织 ID")
+		}
+		id, err := strconv.ParseUint(tok.text, 10, 64)
+		if err != nil || id == 0 {
+			return value{}, fmt.Errorf("无效的组织 ID %s", tok.text)
+		}
+		return value{id: id, text: tok.text}, nil
+	}
+	return value{}, fmt.Errorf("未知属性 %s", attr)
+}
+
+// parseCIDR 单个 IP 按 /32 或 /128 处理
+func parseCIDR(text string) (*net.IPNet, error) {
+	if _, cidr, err := net.ParseCIDR(text); err == nil {
+		return cidr, nil
+	}
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return nil, fmt.Errorf("无效的 IP 或 CIDR %s", text)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
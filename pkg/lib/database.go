@@ -48,6 +48,18 @@ func NewDataBase() *gorm.DB {
 		&entity.MenuResource{},
 		&entity.SysDictionary{},
 		&entity.SysDictionaryDetail{},
+		&entity.RefreshTokenFamily{},
+		&entity.UserSession{},
+		&entity.UserTwoFactor{},
+		&entity.UserRecoveryCode{},
+		&entity.PasswordHistory{},
+		&entity.PasswordResetToken{},
+		&entity.EmailVerificationToken{},
+		&entity.UserExternalIdentity{},
+		&entity.PersonalAccessToken{},
+		&entity.IPAccessPolicy{},
+		&entity.RoleParent{},
+		&entity.AccessRequest{},
 	); err != nil {
 		log.Fatalf("数据库自动迁移失败: %v", err)
 	}
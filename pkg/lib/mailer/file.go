@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer 本地开发和测试用的邮件发送器，将邮件写入 Dir 目录下的 .eml 文件并打印日志，不实际发送
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(msg *Message) error {
+	if m.Dir == "" {
+		log.Printf("INFO: [mail] to=%s subject=%s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102150405.000000000"), recipient)
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, buildMessage(m.From, msg), 0o600); err != nil {
+		return fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+	log.Printf("INFO: [mail] to=%s subject=%s file=%s", msg.To, msg.Subject, path)
+	return nil
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"github.com/lyj404/gin-api-template/config"
+)
+
+// Message 待发送的邮件，Body 为纯文本
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer 按配置的 Driver 创建邮件发送器，未配置 smtp 时使用本地文件/日志发送器
+func NewMailer() Mailer {
+	cfg := config.CfgMail
+	if cfg.Driver == "smtp" {
+		return &SMTPMailer{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		}
+	}
+	return &FileMailer{Dir: cfg.OutputDir, From: cfg.From}
+}
@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"time"
+)
+
+// headerSanitizer 去除头部字段中的换行，防止邮件头注入
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// buildMessage 构造 RFC 5322 格式的纯文本邮件，主题按 RFC 2047 编码以支持中文
+func buildMessage(from string, msg *Message) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + headerSanitizer.Replace(from) + "\r\n")
+	buf.WriteString("To: " + headerSanitizer.Replace(msg.To) + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew 校验 ID Token 时间声明时允许的时钟偏差
+const clockSkew = time.Minute
+
+// flexibleBool 兼容部分身份提供方以字符串 "true"/"false" 返回布尔声明
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case bool:
+		*b = flexibleBool(t)
+	case string:
+		parsed, err := strconv.ParseBool(t)
+		if err != nil {
+			return err
+		}
+		*b = flexibleBool(parsed)
+	}
+	return nil
+}
+
+// IDTokenClaims ID Token 中用于登录和账号关联的声明
+type IDTokenClaims struct {
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+	Nonce             string       `json:"nonce"`
+	AuthorizedParty   string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// IsEmailVerified 身份提供方是否确认邮箱属于该用户
+func (c *IDTokenClaims) IsEmailVerified() bool {
+	return bool(c.EmailVerified)
+}
+
+// VerifyIDToken 校验 ID Token 的签名、签发方、受众、有效期与 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	if _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.get(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing sub")
+	}
+	// 存在多个受众或携带 azp 时，azp 必须为本客户端
+	if (len(claims.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("invalid id token: azp mismatch")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+	return claims, nil
+}
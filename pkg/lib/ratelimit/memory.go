@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMaxKeys 未配置时内存存储最多保留的计数键数
+const defaultMaxKeys = 100000
+
+// memorySweepInterval 清理过期计数键的最小间隔
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	key    string
+	window time.Duration
+	// hits 窗口内各次请求的时间，按先后顺序排列
+	hits []time.Time
+}
+
+// MemoryStore 单实例内存滑动窗口存储
+// 计数键按最近访问顺序排列，超过 MaxKeys 时淘汰最久未访问的键，并定期清理窗口内已无请求的键，避免内存无限增长
+type MemoryStore struct {
+	mu        sync.Mutex
+	maxKeys   int
+	entries   map[string]*list.Element
+	lru       *list.List
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建内存存储，maxKeys <= 0 时使用默认上限
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	return &MemoryStore{
+		maxKeys:   maxKeys,
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Allow(key string, limit int, window time.Duration) (*Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		s.sweep(now)
+	}
+
+	var entry *memoryEntry
+	if elem, ok := s.entries[key]; ok {
+		s.lru.MoveToFront(elem)
+		entry = elem.Value.(*memoryEntry)
+	} else {
+		entry = &memoryEntry{key: key}
+		s.entries[key] = s.lru.PushFront(entry)
+		for s.lru.Len() > s.maxKeys {
+			s.remove(s.lru.Back())
+		}
+	}
+	entry.window = window
+	entry.hits = trimHits(entry.hits, now.Add(-window))
+
+	res := &Result{Limit: limit}
+	if len(entry.hits) < limit {
+		entry.hits = append(entry.hits, now)
+		res.Allowed = true
+		res.Remaining = limit - len(entry.hits)
+	}
+	res.ResetAfter = window
+	if len(entry.hits) > 0 {
+		res.ResetAfter = entry.hits[0].Add(window).Sub(now)
+	}
+	return res, nil
+}
+
+// sweep 清理窗口内已无请求的计数键
+func (s *MemoryStore) sweep(now time.Time) {
+	for elem := s.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		entry := elem.Value.(*memoryEntry)
+		if len(entry.hits) == 0 || !entry.hits[len(entry.hits)-1].After(now.Add(-entry.window)) {
+			s.remove(elem)
+		}
+		elem = prev
+	}
+	s.lastSweep = now
+}
+
+func (s *MemoryStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*memoryEntry).key)
+}
+
+// trimHits 丢弃 since 之前（已滑出窗口）的请求记录
+func trimHits(hits []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(since) {
+		i++
+	}
+	return hits[i:]
+}
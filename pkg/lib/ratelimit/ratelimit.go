@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"strings"
+	"time"
+)
+
+// 计数维度，对应配置 rateLimit.Rules[].KeyBy
+const (
+	KeyByIP    = "ip"    // 按客户端 IP 计数
+	KeyByUser  = "user"  // 按登录用户计数，未登录时退回按 IP
+	KeyByRoute = "route" // 按路由计数，所有客户端共享同一额度
+)
+
+// 规则作用的路由分组，对应配置 rateLimit.Rules[].Group
+const (
+	GroupGlobal    = "global"    // 全部请求
+	GroupPublic    = "public"    // 无需登录的公共路由
+	GroupProtected = "protected" // 需要登录的路由，在鉴权之后执行，可按用户计数
+)
+
+// Rule 限流规则：Window 时间窗口内同一计数键最多允许 Limit 次请求
+type Rule struct {
+	Name   string
+	KeyBy  string
+	Limit  int
+	Window time.Duration
+	// Paths 限定的路由模板（与 gin FullPath 一致），以 * 结尾表示前缀匹配，为空时匹配全部路由
+	Paths []string
+}
+
+// Matches 判断规则是否作用于指定路由
+func (r *Rule) Matches(path string) bool {
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, p := range r.Paths {
+		if p == path {
+			return true
+		}
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Result 单次限流判定结果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter 窗口内最早一次请求滑出窗口、额度恢复一次所需的时间
+	ResetAfter time.Duration
+}
+
+// Store 滑动窗口计数存储，Allow 需保证判定与计数是原子的，并发请求不会超出限额
+type Store interface {
+	Allow(key string, limit int, window time.Duration) (*Result, error)
+}
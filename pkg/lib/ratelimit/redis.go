@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript 基于有序集合的滑动窗口，清理、计数与记录在同一脚本内完成，并发请求不会超出限额
+// 时间取自 Redis 服务器，避免多实例之间时钟不一致；返回 {是否允许, 剩余次数, 额度恢复毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local reset = 0
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// RedisStore 多实例共享的 Redis 滑动窗口存储
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Allow(key string, limit int, window time.Duration) (*Result, error) {
+	// 每次请求使用唯一成员，同一毫秒内的多次请求也会分别计数
+	vals, err := slidingWindowScript.Run(context.Background(), s.client, []string{key},
+		limit, window.Milliseconds(), uuid.NewString()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Allowed:    vals[0] == 1,
+		Limit:      limit,
+		Remaining:  int(vals[1]),
+		ResetAfter: time.Duration(vals[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type accessRequestRepository struct{}
+
+func NewAccessRequestRepository() repositories.AccessRequestRepository {
+	return &accessRequestRepository{}
+}
+
+func (r *accessRequestRepository) Create(tx *gorm.DB, req *entity.AccessRequest) error {
+	return tx.Create(req).Error
+}
+
+func (r *accessRequestRepository) GetByID(id uint64) (*entity.AccessRequest, error) {
+	var req entity.AccessRequest
+	if err := global.G_DB.Preload("Requester").Preload("Role").Preload("OrgUnit").First(&req, id).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *accessRequestRepository) Transition(tx *gorm.DB, req *entity.AccessRequest, from string) (bool, error) {
+	res := tx.Model(&entity.AccessRequest{}).Where("id = ? AND status = ?", req.ID, from).Updates(map[string]any{
+		"status":         req.Status,
+		"reviewer_id":    req.ReviewerID,
+		"review_comment": req.ReviewComment,
+		"reviewed_at":    req.ReviewedAt,
+		"user_role_id":   req.UserRoleID,
+		"valid_until":    req.ValidUntil,
+	})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *accessRequestRepository) HasPending(requesterID, roleID, orgUnitID uint64) (bool, error) {
+	var count int64
+	err := global.G_DB.Model(&entity.AccessRequest{}).
+		Where("requester_id = ? AND role_id = ? AND org_unit_id = ? AND status = ?", requesterID, roleID, orgUnitID, entity.AccessRequestPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *accessRequestRepository) List(requesterID uint64, orgPaths []string, status string, page, pageSize int) ([]entity.AccessRequest, int64, error) {
+	query := global.G_DB.Model(&entity.AccessRequest{})
+	if requesterID != 0 {
+		query = query.Where("access_request.requester_id = ?", requesterID)
+	}
+	if orgPaths != nil {
+		if len(orgPaths) == 0 {
+			return nil, 0, nil
+		}
+		query = query.Joins("JOIN org_unit ON org_unit.id = access_request.org_unit_id")
+		cond := global.G_DB
+		for i, path := range orgPaths {
+			if i == 0 {
+				cond = cond.Where("org_unit.path = ? OR org_unit.path LIKE ?", path, path+"/%")
+			} else {
+				cond = cond.Or("org_unit.path = ? OR org_unit.path LIKE ?", path, path+"/%")
+			}
+		}
+		query = query.Where(cond)
+	}
+	if status != "" {
+		query = query.Where("access_request.status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []entity.AccessRequest
+	err := query.Preload("Requester").Preload("Role").Preload("OrgUnit").
+		Order("access_request.id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&requests).Error
+	return requests, total, err
+}
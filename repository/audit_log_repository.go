@@ -26,12 +26,12 @@ func (r *auditLogRepository) GetByID(id uint64) (*entity.AuditLog, error) {
 	return &auditLog, nil
 }
 
-func (r *auditLogRepository) GetByOperator(operatorID uint64, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error) {
+func (r *auditLogRepository) GetByOperator(operatorID uint64, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error) {
 	var auditLogs []entity.AuditLog
 	var total int64
 
 	offset := (page - 1) * pageSize
-	query := r.scopedQuery(orgIDs).
+	query := r.scopedQuery(scope).
 		Order("audit_log.created_at DESC").
 		Limit(pageSize).
 		Offset(offset)
@@ -45,7 +45,7 @@ func (r *auditLogRepository) GetByOperator(operatorID uint64, page, pageSize int
 		return nil, 0, err
 	}
 
-	countQuery := r.scopedQuery(orgIDs)
+	countQuery := r.scopedQuery(scope)
 	if operatorID != 0 {
 		countQuery = countQuery.Where("audit_log.operator_id = ?", operatorID)
 	}
@@ -54,12 +54,12 @@ func (r *auditLogRepository) GetByOperator(operatorID uint64, page, pageSize int
 	return auditLogs, total, nil
 }
 
-func (r *auditLogRepository) GetByTarget(targetType string, targetID uint64, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error) {
+func (r *auditLogRepository) GetByTarget(targetType string, targetID uint64, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error) {
 	var auditLogs []entity.AuditLog
 	var total int64
 
 	offset := (page - 1) * pageSize
-	err := r.scopedQuery(orgIDs).
+	err := r.scopedQuery(scope).
 		Where("audit_log.target_type = ? AND audit_log.target_id = ?", targetType, targetID).
 		Order("audit_log.created_at DESC").
 		Limit(pageSize).
@@ -69,19 +69,19 @@ func (r *auditLogRepository) GetByTarget(targetType string, targetID uint64, pag
 		return nil, 0, err
 	}
 
-	r.scopedQuery(orgIDs).
+	r.scopedQuery(scope).
 		Where("audit_log.target_type = ? AND audit_log.target_id = ?", targetType, targetID).
 		Count(&total)
 
 	return auditLogs, total, nil
 }
 
-func (r *auditLogRepository) GetByTimeRange(startTime, endTime string, page, pageSize int, orgIDs []uint64) ([]entity.AuditLog, int64, error) {
+func (r *auditLogRepository) GetByTimeRange(startTime, endTime string, page, pageSize int, scope *entity.DataScope) ([]entity.AuditLog, int64, error) {
 	var auditLogs []entity.AuditLog
 	var total int64
 
 	offset := (page - 1) * pageSize
-	query := r.scopedQuery(orgIDs)
+	query := r.scopedQuery(scope)
 
 	if startTime != "" {
 		query = query.Where("audit_log.created_at >= ?", startTime)
@@ -99,7 +99,7 @@ func (r *auditLogRepository) GetByTimeRange(startTime, endTime string, page, pag
 		return nil, 0, err
 	}
 
-	countQuery := r.scopedQuery(orgIDs)
+	countQuery := r.scopedQuery(scope)
 	if startTime != "" {
 		countQuery = countQuery.Where("audit_log.created_at >= ?", startTime)
 	}
@@ -111,11 +111,6 @@ func (r *auditLogRepository) GetByTimeRange(startTime, endTime string, page, pag
 	return auditLogs, total, nil
 }
 
-func (r *auditLogRepository) scopedQuery(orgIDs []uint64) *gorm.DB {
-	// 使用 WHERE EXISTS 子查询替代 JOIN，避免操作者有多角色时产生重复行
-	query := global.G_DB.Model(&entity.AuditLog{})
-	if orgIDs != nil {
-		query = query.Where(`EXISTS (SELECT 1 FROM user_role WHERE user_role.user_id = audit_log.operator_id AND user_role.org_unit_id IN ? AND user_role.deleted_at IS NULL)`, orgIDs)
-	}
-	return query
+func (r *auditLogRepository) scopedQuery(scope *entity.DataScope) *gorm.DB {
+	return global.G_DB.Model(&entity.AuditLog{}).Scopes(entity.InDataScope(scope))
 }
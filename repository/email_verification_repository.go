@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type emailVerificationRepository struct{}
+
+func NewEmailVerificationRepository() repositories.EmailVerificationRepository {
+	return &emailVerificationRepository{}
+}
+
+func (r *emailVerificationRepository) Create(token *entity.EmailVerificationToken) error {
+	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *emailVerificationRepository) GetByTokenHash(tokenHash string) (*entity.EmailVerificationToken, error) {
+	var token entity.EmailVerificationToken
+	if err := global.G_DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *emailVerificationRepository) MarkUsed(tx *gorm.DB, id uint64) (bool, error) {
+	res := tx.Model(&entity.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
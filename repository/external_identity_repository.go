@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type externalIdentityRepository struct{}
+
+func NewExternalIdentityRepository() repositories.ExternalIdentityRepository {
+	return &externalIdentityRepository{}
+}
+
+func (r *externalIdentityRepository) GetByProviderSubject(provider, subject string) (*entity.UserExternalIdentity, error) {
+	var identity entity.UserExternalIdentity
+	if err := global.G_DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *externalIdentityRepository) ListByUserID(userID uint64) ([]entity.UserExternalIdentity, error) {
+	var identities []entity.UserExternalIdentity
+	err := global.G_DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *externalIdentityRepository) Create(tx *gorm.DB, identity *entity.UserExternalIdentity) error {
+	return tx.Create(identity).Error
+}
+
+func (r *externalIdentityRepository) RecordLogin(id uint64, email string, at time.Time) error {
+	return global.G_DB.Model(&entity.UserExternalIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"email":         email,
+			"last_login_at": at,
+		}).Error
+}
+
+// Delete 物理删除，避免软删除记录占用 provider+subject 唯一索引
+func (r *externalIdentityRepository) Delete(userID, id uint64) (bool, error) {
+	res := global.G_DB.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&entity.UserExternalIdentity{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type ipAccessPolicyRepository struct{}
+
+func NewIPAccessPolicyRepository() repositories.IPAccessPolicyRepository {
+	return &ipAccessPolicyRepository{}
+}
+
+func (r *ipAccessPolicyRepository) Create(tx *gorm.DB, policy *entity.IPAccessPolicy) error {
+	return tx.Create(policy).Error
+}
+
+func (r *ipAccessPolicyRepository) Update(tx *gorm.DB, policy *entity.IPAccessPolicy) error {
+	return tx.Model(&entity.IPAccessPolicy{}).Where("id = ?", policy.ID).Updates(map[string]any{
+		"effect":      policy.Effect,
+		"cidr":        policy.CIDR,
+		"description": policy.Description,
+		"enabled":     policy.Enabled,
+	}).Error
+}
+
+func (r *ipAccessPolicyRepository) Delete(tx *gorm.DB, id uint64) error {
+	return tx.Delete(&entity.IPAccessPolicy{}, id).Error
+}
+
+func (r *ipAccessPolicyRepository) GetByID(id uint64) (*entity.IPAccessPolicy, error) {
+	var policy entity.IPAccessPolicy
+	if err := global.G_DB.First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *ipAccessPolicyRepository) List(subjectType string, subjectID uint64) ([]entity.IPAccessPolicy, error) {
+	var policies []entity.IPAccessPolicy
+	query := global.G_DB.Model(&entity.IPAccessPolicy{})
+	if subjectType != "" {
+		query = query.Where("subject_type = ?", subjectType)
+	}
+	if subjectID != 0 {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	err := query.Order("id DESC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *ipAccessPolicyRepository) ListEnabled() ([]entity.IPAccessPolicy, error) {
+	var policies []entity.IPAccessPolicy
+	err := global.G_DB.Where("enabled = ?", true).Find(&policies).Error
+	return policies, err
+}
@@ -86,9 +86,9 @@ func (r *orgUnitRepository) GetByPath(path string) (*entity.OrgUnit, error) {
 	return &org, nil
 }
 
-func (r *orgUnitRepository) GetAll() ([]entity.OrgUnit, error) {
+func (r *orgUnitRepository) GetAll(scope *entity.DataScope) ([]entity.OrgUnit, error) {
 	var orgs []entity.OrgUnit
-	err := global.G_DB.Order("path").Find(&orgs).Error
+	err := global.G_DB.Scopes(entity.InDataScope(scope)).Order("path").Find(&orgs).Error
 	return orgs, err
 }
 
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+)
+
+type personalAccessTokenRepository struct{}
+
+func NewPersonalAccessTokenRepository() repositories.PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{}
+}
+
+func (r *personalAccessTokenRepository) Create(token *entity.PersonalAccessToken) error {
+	return global.G_DB.Create(token).Error
+}
+
+func (r *personalAccessTokenRepository) GetByID(id uint64) (*entity.PersonalAccessToken, error) {
+	var token entity.PersonalAccessToken
+	if err := global.G_DB.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) GetByTokenHash(tokenHash string) (*entity.PersonalAccessToken, error) {
+	var token entity.PersonalAccessToken
+	if err := global.G_DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) ListByUserID(userID uint64) ([]entity.PersonalAccessToken, error) {
+	var tokens []entity.PersonalAccessToken
+	err := global.G_DB.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *personalAccessTokenRepository) CountActiveByUserID(userID uint64) (int64, error) {
+	var count int64
+	err := global.G_DB.Model(&entity.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *personalAccessTokenRepository) Touch(id uint64, lastUsedAt time.Time, ip string) error {
+	return global.G_DB.Model(&entity.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"last_used_at": lastUsedAt,
+			"last_used_ip": ip,
+		}).Error
+}
+
+func (r *personalAccessTokenRepository) Revoke(id uint64) (bool, error) {
+	res := global.G_DB.Model(&entity.PersonalAccessToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
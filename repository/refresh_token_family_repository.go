@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type refreshTokenFamilyRepository struct{}
+
+func NewRefreshTokenFamilyRepository() repositories.RefreshTokenFamilyRepository {
+	return &refreshTokenFamilyRepository{}
+}
+
+func (r *refreshTokenFamilyRepository) Create(family *entity.RefreshTokenFamily) error {
+	return global.G_DB.Create(family).Error
+}
+
+func (r *refreshTokenFamilyRepository) GetByFamilyID(familyID string) (*entity.RefreshTokenFamily, error) {
+	var family entity.RefreshTokenFamily
+	if err := global.G_DB.Where("family_id = ?", familyID).First(&family).Error; err != nil {
+		return nil, err
+	}
+	return &family, nil
+}
+
+func (r *refreshTokenFamilyRepository) Rotate(familyID, oldJTI, newJTI string, expiresAt time.Time) (bool, error) {
+	// 以 current_jti 作为条件实现比较并交换，并发刷新时只有一个请求能成功
+	res := global.G_DB.Model(&entity.RefreshTokenFamily{}).
+		Where("family_id = ? AND current_jti = ? AND revoked = ?", familyID, oldJTI, false).
+		Updates(map[string]any{
+			"current_jti": newJTI,
+			"expires_at":  expiresAt,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *refreshTokenFamilyRepository) Revoke(tx *gorm.DB, familyID string) error {
+	now := time.Now()
+	return tx.Model(&entity.RefreshTokenFamily{}).
+		Where("family_id = ? AND revoked = ?", familyID, false).
+		Updates(map[string]any{
+			"revoked":    true,
+			"revoked_at": &now,
+		}).Error
+}
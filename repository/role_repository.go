@@ -92,3 +92,12 @@ func (r *roleRepository) GetRoleMenus(roleID uint64) ([]entity.RoleMenu, error)
 	err := global.G_DB.Preload("Menu").Where("role_id = ?", roleID).Find(&menus).Error
 	return menus, err
 }
+
+func (r *roleRepository) GetParents(roleID uint64) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := global.G_DB.Model(&entity.Role{}).
+		Joins(`JOIN role_parent ON role_parent.parent_id = "role".id AND role_parent.deleted_at IS NULL`).
+		Where("role_parent.role_id = ?", roleID).
+		Find(&roles).Error
+	return roles, err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sessionRepository struct{}
+
+func NewSessionRepository() repositories.SessionRepository {
+	return &sessionRepository{}
+}
+
+func (r *sessionRepository) Create(tx *gorm.DB, session *entity.UserSession) error {
+	return tx.Create(session).Error
+}
+
+func (r *sessionRepository) GetBySessionID(sessionID string) (*entity.UserSession, error) {
+	var session entity.UserSession
+	if err := global.G_DB.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveByUserID(userID uint64) ([]entity.UserSession, error) {
+	var sessions []entity.UserSession
+	err := global.G_DB.
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) ListActiveForUpdate(tx *gorm.DB, userID uint64) ([]entity.UserSession, error) {
+	// 锁定用户行而不是会话行：会话可能一条都没有，锁用户行才能让并发登录排队
+	var user entity.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	var sessions []entity.UserSession
+	err := tx.
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("created_at ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) GetRoleSessionLimit(userID uint64) (int, error) {
+	var limit *int
+	err := global.G_DB.Model(&entity.UserRole{}).
+		Joins("JOIN role ON role.id = user_role.role_id AND role.deleted_at IS NULL").
+		Scopes(entity.UserRoleActiveAt(time.Now())).
+		Where("user_role.user_id = ? AND role.max_sessions > 0", userID).
+		Select("MIN(role.max_sessions)").
+		Scan(&limit).Error
+	if err != nil || limit == nil {
+		return 0, err
+	}
+	return *limit, nil
+}
+
+func (r *sessionRepository) ExistsByFingerprint(userID uint64, fingerprint string) (bool, error) {
+	var count int64
+	err := global.G_DB.Model(&entity.UserSession{}).
+		Where("user_id = ? AND fingerprint = ?", userID, fingerprint).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRepository) Touch(sessionID string, lastSeenAt time.Time) error {
+	return global.G_DB.Model(&entity.UserSession{}).
+		Where("session_id = ?", sessionID).
+		Update("last_seen_at", lastSeenAt).Error
+}
+
+func (r *sessionRepository) Extend(sessionID string, lastSeenAt, expiresAt time.Time) error {
+	return global.G_DB.Model(&entity.UserSession{}).
+		Where("session_id = ? AND revoked = ?", sessionID, false).
+		Updates(map[string]any{
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+func (r *sessionRepository) Revoke(tx *gorm.DB, sessionID string) error {
+	now := time.Now()
+	return tx.Model(&entity.UserSession{}).
+		Where("session_id = ? AND revoked = ?", sessionID, false).
+		Updates(map[string]any{
+			"revoked":    true,
+			"revoked_at": &now,
+		}).Error
+}
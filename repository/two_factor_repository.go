@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type twoFactorRepository struct{}
+
+func NewTwoFactorRepository() repositories.TwoFactorRepository {
+	return &twoFactorRepository{}
+}
+
+func (r *twoFactorRepository) GetByUserID(userID uint64) (*entity.UserTwoFactor, error) {
+	var tf entity.UserTwoFactor
+	if err := global.G_DB.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *twoFactorRepository) Save(tf *entity.UserTwoFactor) error {
+	return global.G_DB.Save(tf).Error
+}
+
+// Delete 物理删除，避免软删除记录占用 user_id 唯一索引
+func (r *twoFactorRepository) Delete(userID uint64) error {
+	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&entity.UserTwoFactor{}).Error
+	})
+}
+
+func (r *twoFactorRepository) Enable(userID uint64, enabledAt time.Time) error {
+	return global.G_DB.Model(&entity.UserTwoFactor{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"enabled":    true,
+			"enabled_at": enabledAt,
+		}).Error
+}
+
+func (r *twoFactorRepository) UseStep(userID uint64, step int64) (bool, error) {
+	res := global.G_DB.Model(&entity.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]any{
+			"last_used_step":  step,
+			"failed_attempts": 0,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *twoFactorRepository) RecordFailure(userID uint64) error {
+	return global.G_DB.Model(&entity.UserTwoFactor{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"failed_attempts": gorm.Expr("failed_attempts + 1"),
+			"last_failed_at":  time.Now(),
+		}).Error
+}
+
+func (r *twoFactorRepository) ResetFailures(userID uint64) error {
+	return global.G_DB.Model(&entity.UserTwoFactor{}).
+		Where("user_id = ?", userID).
+		Update("failed_attempts", 0).Error
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(userID uint64, codeHashes []string) error {
+	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entity.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]entity.UserRecoveryCode, len(codeHashes))
+		for i, h := range codeHashes {
+			codes[i] = entity.UserRecoveryCode{UserID: userID, CodeHash: h}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *twoFactorRepository) ListUnusedRecoveryCodes(userID uint64) ([]entity.UserRecoveryCode, error) {
+	var codes []entity.UserRecoveryCode
+	err := global.G_DB.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	return codes, err
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(id uint64) (bool, error) {
+	res := global.G_DB.Model(&entity.UserRecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/repositories"
 	"github.com/lyj404/gin-api-template/global"
@@ -13,16 +15,14 @@ func NewUserManagementRepository() repositories.UserRepository {
 	return &userManagementRepository{}
 }
 
-func (r *userManagementRepository) List(page, pageSize int, keyword string, orgIDs []uint64) ([]entity.User, int64, error) {
+func (r *userManagementRepository) List(page, pageSize int, keyword string, scope *entity.DataScope) ([]entity.User, int64, error) {
 	var users []entity.User
 	var total int64
 
 	// "user" 是 PostgreSQL 保留字，在原始 SQL 中必须用双引号包裹
 	query := global.G_DB.Model(&entity.User{}).
-		Joins(`JOIN user_role ON user_role.user_id = "user".id AND user_role.deleted_at IS NULL`)
-	if orgIDs != nil {
-		query = query.Where("user_role.org_unit_id IN ?", orgIDs)
-	}
+		Joins(`JOIN user_role ON user_role.user_id = "user".id AND user_role.deleted_at IS NULL`).
+		Scopes(entity.InDataScope(scope))
 	if keyword != "" {
 		like := "%" + keyword + "%"
 		query = query.Where(`"user".name LIKE ? OR "user".email LIKE ?`, like, like)
@@ -30,10 +30,8 @@ func (r *userManagementRepository) List(page, pageSize int, keyword string, orgI
 
 	// 使用 WHERE id IN (子查询) 方式计数，避免 DISTINCT+Count/子查询表名引用 在 PostgreSQL 下的兼容问题
 	userIDs2 := global.G_DB.Model(&entity.User{}).
-		Joins(`JOIN user_role ON user_role.user_id = "user".id AND user_role.deleted_at IS NULL`)
-	if orgIDs != nil {
-		userIDs2 = userIDs2.Where("user_role.org_unit_id IN ?", orgIDs)
-	}
+		Joins(`JOIN user_role ON user_role.user_id = "user".id AND user_role.deleted_at IS NULL`).
+		Scopes(entity.InDataScope(scope))
 	if keyword != "" {
 		like := "%" + keyword + "%"
 		userIDs2 = userIDs2.Where(`"user".name LIKE ? OR "user".email LIKE ?`, like, like)
@@ -70,7 +68,14 @@ func (r *userManagementRepository) Update(tx *gorm.DB, user *entity.User) error
 }
 
 func (r *userManagementRepository) UpdatePassword(tx *gorm.DB, id uint64, hashed string) error {
-	return tx.Model(&entity.User{}).Where("id = ?", id).Update("password", hashed).Error
+	return tx.Model(&entity.User{}).Where("id = ?", id).Updates(map[string]any{
+		"password":            hashed,
+		"password_changed_at": time.Now(),
+	}).Error
+}
+
+func (r *userManagementRepository) UpdateStatus(tx *gorm.DB, id uint64, status string) error {
+	return tx.Model(&entity.User{}).Where("id = ?", id).Update("status", status).Error
 }
 
 func (r *userManagementRepository) Delete(tx *gorm.DB, id uint64) error {
@@ -83,21 +88,41 @@ func (r *userManagementRepository) Delete(tx *gorm.DB, id uint64) error {
 func (r *userManagementRepository) GetRoleIDsByUserID(userID uint64) ([]uint64, error) {
 	var roleIDs []uint64
 	err := global.G_DB.Model(&entity.UserRole{}).
+		Scopes(entity.UserRoleActiveAt(time.Now())).
 		Where("user_id = ?", userID).
 		Pluck("role_id", &roleIDs).Error
 	return roleIDs, err
 }
 
+// ReplaceUserRoles 只替换永久分配；限时分配由单独的接口管理，其角色即使出现在 roleIDs 中也保持限时，不会转为永久
 func (r *userManagementRepository) ReplaceUserRoles(tx *gorm.DB, userID, orgUnitID uint64, roleIDs []uint64) error {
-	if err := tx.Where("user_id = ?", userID).Delete(&entity.UserRole{}).Error; err != nil {
+	if err := tx.Where("user_id = ? AND valid_from IS NULL AND valid_until IS NULL", userID).Delete(&entity.UserRole{}).Error; err != nil {
 		return err
 	}
 	if len(roleIDs) == 0 {
 		return nil
 	}
+
+	var temporary []uint64
+	if err := tx.Model(&entity.UserRole{}).
+		Where("user_id = ? AND (valid_from IS NOT NULL OR valid_until IS NOT NULL)", userID).
+		Where("valid_until IS NULL OR valid_until > ?", time.Now()).
+		Pluck("role_id", &temporary).Error; err != nil {
+		return err
+	}
+	skip := make(map[uint64]bool, len(temporary))
+	for _, rid := range temporary {
+		skip[rid] = true
+	}
+
 	rows := make([]entity.UserRole, 0, len(roleIDs))
 	for _, rid := range roleIDs {
-		rows = append(rows, entity.UserRole{UserID: userID, RoleID: rid, OrgUnitID: orgUnitID})
+		if !skip[rid] {
+			rows = append(rows, entity.UserRole{UserID: userID, RoleID: rid, OrgUnitID: orgUnitID})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
 	}
 	return tx.Create(&rows).Error
 }
@@ -106,6 +131,7 @@ func (r *userManagementRepository) HasSystemRole(userID uint64) (bool, error) {
 	var count int64
 	err := global.G_DB.Model(&entity.UserRole{}).
 		Joins(`JOIN role ON role.id = user_role.role_id AND role.deleted_at IS NULL`).
+		Scopes(entity.UserRoleActiveAt(time.Now())).
 		Where("user_role.user_id = ? AND role.is_system = ?", userID, true).
 		Count(&count).Error
 	return count > 0, err
@@ -116,6 +142,7 @@ func (r *userManagementRepository) GetRoleNamesByUserID(userID uint64) ([]string
 	err := global.G_DB.Table("user_role").
 		Select("role.name").
 		Joins("LEFT JOIN role ON role.id = user_role.role_id").
+		Scopes(entity.UserRoleActiveAt(time.Now())).
 		Where("user_role.user_id = ? AND user_role.deleted_at IS NULL", userID).
 		Pluck("role.name", &names).Error
 	return names, err
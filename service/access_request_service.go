@@ -0,0 +1,302 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+// defaultAccessRequestMaxMinutes 未配置申请时长上限时使用的默认值（3 天）
+const defaultAccessRequestMaxMinutes = 3 * 24 * 60
+
+type accessRequestServiceImpl struct {
+	requestRepo repositories.AccessRequestRepository
+	roleRepo    repositories.RoleRepository
+	orgRepo     repositories.OrgUnitRepository
+	permSvc     services.PermissionService
+}
+
+func NewAccessRequestService(requestRepo repositories.AccessRequestRepository, roleRepo repositories.RoleRepository, orgRepo repositories.OrgUnitRepository, permSvc services.PermissionService) services.AccessRequestService {
+	return &accessRequestServiceImpl{
+		requestRepo: requestRepo,
+		roleRepo:    roleRepo,
+		orgRepo:     orgRepo,
+		permSvc:     permSvc,
+	}
+}
+
+func (s *accessRequestServiceImpl) Create(req *dto.CreateAccessRequestRequest, requesterID uint64) (*entity.AccessRequest, error) {
+	maxMinutes := config.CfgRoleAssignment.AccessRequestMaxMinutes
+	if maxMinutes <= 0 {
+		maxMinutes = defaultAccessRequestMaxMinutes
+	}
+	if req.DurationMinutes > maxMinutes {
+		return nil, fmt.Errorf("申请时长不能超过 %d 分钟", maxMinutes)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("申请理由不能为空")
+	}
+
+	role, err := s.roleRepo.GetByID(req.RoleID)
+	if err != nil {
+		return nil, errors.New("角色不存在")
+	}
+	if role.IsSystem {
+		return nil, errors.New("系统角色不能通过访问申请获得")
+	}
+	org, err := s.orgRepo.GetByID(req.OrgUnitID)
+	if err != nil {
+		return nil, errors.New("组织不存在")
+	}
+
+	pending, err := s.requestRepo.HasPending(requesterID, role.ID, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("已有相同角色和组织的待审批申请")
+	}
+	var held int64
+	err = global.G_DB.Model(&entity.UserRole{}).
+		Scopes(entity.UserRoleActiveAt(time.Now())).
+		Where("user_id = ? AND role_id = ? AND org_unit_id = ?", requesterID, role.ID, org.ID).
+		Count(&held).Error
+	if err != nil {
+		return nil, err
+	}
+	if held > 0 {
+		return nil, errors.New("已在该组织拥有此角色，无需申请")
+	}
+
+	request := &entity.AccessRequest{
+		RequesterID:     requesterID,
+		RoleID:          role.ID,
+		OrgUnitID:       org.ID,
+		Reason:          reason,
+		DurationMinutes: req.DurationMinutes,
+		Status:          entity.AccessRequestPending,
+	}
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.requestRepo.Create(tx, request); err != nil {
+			return err
+		}
+		afterJSON, _ := json.Marshal(request)
+		description := fmt.Sprintf("申请角色 %s(%d)，组织: %s(%d)，时长 %d 分钟，理由: %s", role.Name, role.ID, org.Name, org.ID, request.DurationMinutes, reason)
+		return s.audit(tx, requesterID, "request", "access_request", request.ID, "", string(afterJSON), description)
+	})
+	if err != nil {
+		return nil, err
+	}
+	request.Role = *role
+	request.OrgUnit = *org
+	return request, nil
+}
+
+func (s *accessRequestServiceImpl) ListMine(requesterID uint64, status string, page, pageSize int) ([]entity.AccessRequest, int64, error) {
+	return s.requestRepo.List(requesterID, nil, status, page, pageSize)
+}
+
+func (s *accessRequestServiceImpl) ListReviewable(operatorID uint64, status string, page, pageSize int) ([]entity.AccessRequest, int64, error) {
+	isSuper, err := s.permSvc.HasSystemRole(operatorID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if isSuper {
+		return s.requestRepo.List(0, nil, status, page, pageSize)
+	}
+	paths, err := s.approverOrgPaths(operatorID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if paths == nil {
+		paths = []string{}
+	}
+	return s.requestRepo.List(0, paths, status, page, pageSize)
+}
+
+func (s *accessRequestServiceImpl) Approve(id uint64, comment string, operatorID uint64) (*entity.AccessRequest, error) {
+	request, err := s.reviewable(id, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	validUntil := now.Add(time.Duration(request.DurationMinutes) * time.Minute)
+	before := *request
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		userRole := entity.UserRole{
+			UserID:     request.RequesterID,
+			RoleID:     request.RoleID,
+			OrgUnitID:  request.OrgUnitID,
+			ValidFrom:  &now,
+			ValidUntil: &validUntil,
+		}
+		if err := tx.Create(&userRole).Error; err != nil {
+			return err
+		}
+
+		request.Status = entity.AccessRequestApproved
+		request.ReviewerID = operatorID
+		request.ReviewComment = comment
+		request.ReviewedAt = &now
+		request.UserRoleID = userRole.ID
+		request.ValidUntil = &validUntil
+		ok, err := s.requestRepo.Transition(tx, request, entity.AccessRequestPending)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return services.ErrAccessRequestNotPending
+		}
+
+		if err := s.auditTransition(tx, operatorID, "approve", &before, request, "通过"); err != nil {
+			return err
+		}
+		return s.audit(tx, operatorID, "assign", "user_role", userRole.ID, "", "",
+			fmt.Sprintf("用户 %d 分配角色 %d (组织: %d)，有效期: %s ~ %s，来源: 访问申请 %d",
+				userRole.UserID, userRole.RoleID, userRole.OrgUnitID, formatValidity(&now, "立即"), formatValidity(&validUntil, "永久"), request.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, s.permSvc.ClearUserCache(request.RequesterID)
+}
+
+func (s *accessRequestServiceImpl) Reject(id uint64, comment string, operatorID uint64) (*entity.AccessRequest, error) {
+	request, err := s.reviewable(id, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	before := *request
+	request.Status = entity.AccessRequestRejected
+	request.ReviewerID = operatorID
+	request.ReviewComment = comment
+	request.ReviewedAt = &now
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		return s.transition(tx, operatorID, "reject", &before, request, "驳回")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *accessRequestServiceImpl) Cancel(id uint64, operatorID uint64) (*entity.AccessRequest, error) {
+	request, err := s.requestRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("访问申请不存在")
+	}
+	if request.RequesterID != operatorID {
+		return nil, errors.New("只能撤回本人的访问申请")
+	}
+	if request.Status != entity.AccessRequestPending {
+		return nil, services.ErrAccessRequestNotPending
+	}
+
+	now := time.Now()
+	before := *request
+	request.Status = entity.AccessRequestCancelled
+	request.ReviewedAt = &now
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		return s.transition(tx, operatorID, "cancel", &before, request, "撤回")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// reviewable 加载待审批的申请并校验操作者的审批资格
+func (s *accessRequestServiceImpl) reviewable(id uint64, operatorID uint64) (*entity.AccessRequest, error) {
+	request, err := s.requestRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("访问申请不存在")
+	}
+	if request.Status != entity.AccessRequestPending {
+		return nil, services.ErrAccessRequestNotPending
+	}
+	if request.RequesterID == operatorID {
+		return nil, errors.New("不能审批本人的访问申请")
+	}
+
+	isSuper, err := s.permSvc.HasSystemRole(operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if isSuper {
+		return request, nil
+	}
+	paths, err := s.approverOrgPaths(operatorID)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if request.OrgUnit.Path == path || strings.HasPrefix(request.OrgUnit.Path, path+"/") {
+			return request, nil
+		}
+	}
+	return nil, services.ErrNotAccessRequestApprover
+}
+
+// approverOrgPaths 操作者当前生效的审批角色分配所在的组织路径，审批范围包含这些组织及其下级
+func (s *accessRequestServiceImpl) approverOrgPaths(operatorID uint64) ([]string, error) {
+	var paths []string
+	err := global.G_DB.Model(&entity.UserRole{}).
+		Scopes(entity.UserRoleActiveAt(time.Now())).
+		Joins("JOIN role ON role.id = user_role.role_id AND role.deleted_at IS NULL").
+		Joins("JOIN org_unit ON org_unit.id = user_role.org_unit_id AND org_unit.deleted_at IS NULL").
+		Where("user_role.user_id = ? AND role.is_approver = ?", operatorID, true).
+		Distinct().
+		Pluck("org_unit.path", &paths).Error
+	return paths, err
+}
+
+// transition 从待审批状态切换到 request.Status 并记录审计，状态已被其他请求修改时返回 ErrAccessRequestNotPending
+func (s *accessRequestServiceImpl) transition(tx *gorm.DB, operatorID uint64, action string, before, request *entity.AccessRequest, verb string) error {
+	ok, err := s.requestRepo.Transition(tx, request, entity.AccessRequestPending)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return services.ErrAccessRequestNotPending
+	}
+	return s.auditTransition(tx, operatorID, action, before, request, verb)
+}
+
+func (s *accessRequestServiceImpl) auditTransition(tx *gorm.DB, operatorID uint64, action string, before, request *entity.AccessRequest, verb string) error {
+	beforeJSON, _ := json.Marshal(before)
+	afterJSON, _ := json.Marshal(request)
+	description := fmt.Sprintf("%s访问申请 %d：用户 %s(%d) 申请角色 %s(%d)，组织: %s(%d)",
+		verb, request.ID, request.Requester.Name, request.RequesterID, request.Role.Name, request.RoleID, request.OrgUnit.Name, request.OrgUnitID)
+	if request.ReviewComment != "" {
+		description += "，意见: " + request.ReviewComment
+	}
+	return s.audit(tx, operatorID, action, "access_request", request.ID, string(beforeJSON), string(afterJSON), description)
+}
+
+func (s *accessRequestServiceImpl) audit(tx *gorm.DB, operatorID uint64, action, targetType string, targetID uint64, before, after, description string) error {
+	log := entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(tx, operatorID),
+		Action:       action,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		BeforeData:   before,
+		AfterData:    after,
+		Description:  description,
+	}
+	return tx.Create(&log).Error
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/pkg/lib/captcha"
+)
+
+var (
+	errCaptchaExpired   = errors.New("Verification code has expired, please refresh")
+	errCaptchaIncorrect = errors.New("Incorrect verification code")
+)
+
+type captchaServiceImpl struct {
+	provider captcha.CaptchaProvider
+	store    captchaStore
+}
+
+// NewCaptchaService 按配置创建验证码服务，验证码类型无效时返回错误
+func NewCaptchaService() (services.CaptchaService, error) {
+	provider, err := captcha.NewProvider(config.CfgCaptcha.Type, config.CfgCaptcha.Length)
+	if err != nil {
+		return nil, err
+	}
+	return &captchaServiceImpl{
+		provider: provider,
+		store:    newCaptchaStore(),
+	}, nil
+}
+
+func (s *captchaServiceImpl) Generate() (*services.CaptchaChallenge, error) {
+	challenge, err := s.provider.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	if err := s.store.Save(id, challenge.Answer, captchaExpiry()); err != nil {
+		return nil, err
+	}
+	return &services.CaptchaChallenge{
+		ID:          id,
+		Type:        s.provider.Name(),
+		ContentType: challenge.ContentType,
+		Content:     challenge.Content,
+	}, nil
+}
+
+func (s *captchaServiceImpl) Verify(id, answer string) error {
+	if id == "" {
+		return errCaptchaExpired
+	}
+	stored, err := s.store.Take(id)
+	if err != nil {
+		return err
+	}
+	if stored == "" {
+		return errCaptchaExpired
+	}
+	if !s.provider.Match(stored, answer) {
+		return errCaptchaIncorrect
+	}
+	return nil
+}
+
+func captchaExpiry() time.Duration {
+	if config.CfgCaptcha.ExpirySecond <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(config.CfgCaptcha.ExpirySecond) * time.Second
+}
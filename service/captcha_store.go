@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/redis/go-redis/v9"
+)
+
+// captchaStore 验证码答案存储，启用 Redis 时多实例共享
+type captchaStore interface {
+	Save(id, answer string, ttl time.Duration) error
+	// Take 取出并删除答案，不存在或已过期时返回空字符串
+	Take(id string) (string, error)
+}
+
+func newCaptchaStore() captchaStore {
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		return &redisCaptchaStore{client: global.G_REDIS}
+	}
+	return &memoryCaptchaStore{entries: make(map[string]memoryCaptcha)}
+}
+
+func captchaKey(id string) string {
+	return "captcha:" + id
+}
+
+type redisCaptchaStore struct {
+	client *redis.Client
+}
+
+func (s *redisCaptchaStore) Save(id, answer string, ttl time.Duration) error {
+	return s.client.Set(context.Background(), captchaKey(id), answer, ttl).Err()
+}
+
+func (s *redisCaptchaStore) Take(id string) (string, error) {
+	answer, err := getDelScript.Run(context.Background(), s.client, []string{captchaKey(id)}).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return answer, err
+}
+
+type memoryCaptcha struct {
+	answer    string
+	expiresAt time.Time
+}
+
+// memoryCaptchaStore 进程内验证码存储，写入时清理过期项，并限制总量防止被刷爆内存
+type memoryCaptchaStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCaptcha
+}
+
+// memoryCaptchaLimit 内存中最多保留的未使用验证码数量，超出时淘汰最早过期的一项
+const memoryCaptchaLimit = 10000
+
+func (s *memoryCaptchaStore) Save(id, answer string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	if len(s.entries) >= memoryCaptchaLimit {
+		var oldestKey string
+		var oldest time.Time
+		for key, entry := range s.entries {
+			if oldestKey == "" || entry.expiresAt.Before(oldest) {
+				oldestKey, oldest = key, entry.expiresAt
+			}
+		}
+		delete(s.entries, oldestKey)
+	}
+	s.entries[id] = memoryCaptcha{answer: answer, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryCaptchaStore) Take(id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return "", nil
+	}
+	delete(s.entries, id)
+	if time.Now().After(entry.expiresAt) {
+		return "", nil
+	}
+	return entry.answer, nil
+}
@@ -20,43 +20,24 @@ func NewDashboardService(permSvc services.PermissionService) services.DashboardS
 }
 
 func (s *dashboardServiceImpl) GetStats(userID uint64) (*services.DashboardStats, error) {
-	isSuper, err := s.permSvc.HasSystemRole(userID)
+	scope, err := s.permSvc.GetDataScope(userID)
 	if err != nil {
-		return nil, fmt.Errorf("检查系统角色失败: %w", err)
+		return nil, fmt.Errorf("获取数据范围失败: %w", err)
 	}
 
 	var stats services.DashboardStats
 
-	if isSuper {
-		if err := global.G_DB.Model(&entity.UserRole{}).
-			Distinct("user_id").
-			Count(&stats.UserCount).Error; err != nil {
-			return nil, err
-		}
-		if err := global.G_DB.Model(&entity.RoleOrgScope{}).
-			Distinct("role_id").
-			Count(&stats.RoleCount).Error; err != nil {
-			return nil, err
-		}
-	} else {
-		orgScope, err := s.permSvc.GetUserOrgScope(userID)
-		if err != nil {
-			return nil, fmt.Errorf("获取组织范围失败: %w", err)
-		}
-		orgIDs := CollectOrgIDs(orgScope)
-
-		if err := global.G_DB.Model(&entity.UserRole{}).
-			Where("org_unit_id IN ?", orgIDs).
-			Distinct("user_id").
-			Count(&stats.UserCount).Error; err != nil {
-			return nil, err
-		}
-		if err := global.G_DB.Model(&entity.RoleOrgScope{}).
-			Where("org_unit_id IN ?", orgIDs).
-			Distinct("role_id").
-			Count(&stats.RoleCount).Error; err != nil {
-			return nil, err
-		}
+	if err := global.G_DB.Model(&entity.UserRole{}).
+		Scopes(entity.InDataScope(scope)).
+		Distinct("user_id").
+		Count(&stats.UserCount).Error; err != nil {
+		return nil, err
+	}
+	if err := global.G_DB.Model(&entity.RoleOrgScope{}).
+		Scopes(entity.InDataScope(scope)).
+		Distinct("role_id").
+		Count(&stats.RoleCount).Error; err != nil {
+		return nil, err
 	}
 
 	menus, err := s.permSvc.GetUserMenus(userID)
@@ -69,15 +50,19 @@ func (s *dashboardServiceImpl) GetStats(userID uint64) (*services.DashboardStats
 	if err != nil {
 		return nil, err
 	}
-	stats.ResourceCount = int64(len(perms))
+	for _, perm := range perms {
+		if perm.Effect != entity.RoleResourceEffectDeny {
+			stats.ResourceCount++
+		}
+	}
 
 	return &stats, nil
 }
 
 func (s *dashboardServiceImpl) GetAuditTrend(userID uint64) ([]services.AuditTrendItem, error) {
-	isSuper, err := s.permSvc.HasSystemRole(userID)
+	scope, err := s.permSvc.GetDataScopeWithFallback(userID)
 	if err != nil {
-		return nil, fmt.Errorf("检查系统角色失败: %w", err)
+		return nil, fmt.Errorf("获取数据范围失败: %w", err)
 	}
 
 	type row struct {
@@ -96,26 +81,10 @@ func (s *dashboardServiceImpl) GetAuditTrend(userID uint64) ([]services.AuditTre
 	cutoff := time.Now().AddDate(0, 0, -6).Truncate(24 * time.Hour)
 
 	query := global.G_DB.Model(&entity.AuditLog{}).
+		Scopes(entity.InDataScope(scope)).
 		Select(dateExpr+" as log_date, COUNT(*) as count").
 		Where("audit_log.created_at >= ?", cutoff)
 
-	if !isSuper {
-		orgScope, err := s.permSvc.GetUserOrgScope(userID)
-		if err != nil {
-			return nil, fmt.Errorf("获取组织范围失败: %w", err)
-		}
-		orgIDs := CollectOrgIDs(orgScope)
-		if len(orgIDs) == 0 {
-			var userRole entity.UserRole
-			if err := global.G_DB.Where("user_id = ?", userID).First(&userRole).Error; err == nil {
-				orgIDs = []uint64{userRole.OrgUnitID}
-			}
-		}
-		if len(orgIDs) > 0 {
-			query = query.Where(`EXISTS (SELECT 1 FROM user_role WHERE user_role.user_id = audit_log.operator_id AND user_role.org_unit_id IN ? AND user_role.deleted_at IS NULL)`, orgIDs)
-		}
-	}
-
 	if err := query.Group("log_date").Order("log_date ASC").Scan(&rows).Error; err != nil {
 		return nil, err
 	}
@@ -127,28 +96,6 @@ func (s *dashboardServiceImpl) GetAuditTrend(userID uint64) ([]services.AuditTre
 	return out, nil
 }
 
-func CollectOrgIDs(orgScope []services.OrgScopeInfo) []uint64 {
-	idSet := make(map[uint64]struct{})
-	for _, scope := range orgScope {
-		if scope.IncludeDescendants {
-			var ids []uint64
-			global.G_DB.Model(&entity.OrgUnit{}).
-				Where("path LIKE ? OR id = ?", scope.Path+"/%", scope.OrgUnitID).
-				Pluck("id", &ids)
-			for _, id := range ids {
-				idSet[id] = struct{}{}
-			}
-		} else {
-			idSet[scope.OrgUnitID] = struct{}{}
-		}
-	}
-	ids := make([]uint64, 0, len(idSet))
-	for id := range idSet {
-		ids = append(ids, id)
-	}
-	return ids
-}
-
 func countMenuNodes(menus []services.MenuTreeNode) int64 {
 	var count int64
 	for _, m := range menus {
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// GetDataScope 系统角色不限制；其他用户只取角色组织范围展开下级后的组织，未配置组织范围时不可访问任何数据
+func (s *permissionServiceImpl) GetDataScope(userID uint64) (*entity.DataScope, error) {
+	return s.dataScope(userID, false)
+}
+
+// GetDataScopeWithFallback 与 GetDataScope 相同，但角色未配置组织范围时退回到用户第一条角色分配所在的组织
+func (s *permissionServiceImpl) GetDataScopeWithFallback(userID uint64) (*entity.DataScope, error) {
+	return s.dataScope(userID, true)
+}
+
+func (s *permissionServiceImpl) dataScope(userID uint64, fallback bool) (*entity.DataScope, error) {
+	isSuper, err := s.HasSystemRole(userID)
+	if err != nil {
+		return nil, err
+	}
+	if isSuper {
+		return &entity.DataScope{All: true}, nil
+	}
+
+	orgScope, err := s.getUserOrgScope(userID)
+	if err != nil {
+		return nil, err
+	}
+	orgIDs, err := CollectOrgIDs(orgScope)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgIDs) == 0 && fallback {
+		var userRole entity.UserRole
+		if err := global.G_DB.Where("user_id = ?", userID).First(&userRole).Error; err == nil {
+			orgIDs = []uint64{userRole.OrgUnitID}
+		}
+	}
+	return &entity.DataScope{OrgIDs: orgIDs}, nil
+}
+
+// CollectOrgIDs 展开组织范围，包含下级的范围按路径取出全部下级组织
+func CollectOrgIDs(orgScope []services.OrgScopeInfo) ([]uint64, error) {
+	idSet := make(map[uint64]struct{})
+	for _, scope := range orgScope {
+		if scope.IncludeDescendants {
+			var ids []uint64
+			if err := global.G_DB.Model(&entity.OrgUnit{}).
+				Where("path LIKE ? OR id = ?", scope.Path+"/%", scope.OrgUnitID).
+				Pluck("id", &ids).Error; err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				idSet[id] = struct{}{}
+			}
+		} else {
+			idSet[scope.OrgUnitID] = struct{}{}
+		}
+	}
+	ids := make([]uint64, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/mailer"
+	"gorm.io/gorm"
+)
+
+var errInvalidVerificationToken = errors.New("验证链接无效或已过期")
+
+type emailVerificationServiceImpl struct {
+	verificationRepo repositories.EmailVerificationRepository
+	userRepo         repositories.UserRepository
+	auditLogService  services.AuditLogService
+	mailer           mailer.Mailer
+}
+
+func NewEmailVerificationService(
+	verificationRepo repositories.EmailVerificationRepository,
+	userRepo repositories.UserRepository,
+	auditLogService services.AuditLogService,
+	m mailer.Mailer,
+) services.EmailVerificationService {
+	return &emailVerificationServiceImpl{
+		verificationRepo: verificationRepo,
+		userRepo:         userRepo,
+		auditLogService:  auditLogService,
+		mailer:           m,
+	}
+}
+
+func (s *emailVerificationServiceImpl) Send(user *entity.User) error {
+	token, err := newOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	expiryHour := config.CfgEmailVerification.ExpiryHour
+	if err := s.verificationRepo.Create(&entity.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashOneTimeToken(token),
+		ExpiresAt: time.Now().Add(time.Duration(expiryHour) * time.Hour),
+	}); err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "验证您的邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n感谢注册，请在 %d 小时内打开以下链接完成邮箱验证，验证后即可登录：\n\n%s\n\n如果您没有注册过账号，请忽略本邮件。\n",
+			user.Name, expiryHour, tokenLink(config.CfgEmailVerification.VerifyURL, token)),
+	}
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("ERROR: 邮箱验证邮件发送失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *emailVerificationServiceImpl) Verify(token string) error {
+	verification, err := s.verificationRepo.GetByTokenHash(hashOneTimeToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidVerificationToken
+		}
+		return err
+	}
+	if verification.UsedAt != nil || time.Now().After(verification.ExpiresAt) {
+		return errInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(verification.UserID)
+	if err != nil {
+		return errInvalidVerificationToken
+	}
+
+	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+		used, err := s.verificationRepo.MarkUsed(tx, verification.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errInvalidVerificationToken
+		}
+		// 已被管理员禁用的账号不能通过验证链接重新启用
+		if user.Status != entity.UserStatusPending {
+			return nil
+		}
+		if err := s.userRepo.UpdateStatus(tx, user.ID, entity.UserStatusActive); err != nil {
+			return err
+		}
+		return tx.Create(&entity.AuditLog{
+			OperatorID:   user.ID,
+			OperatorName: user.Name,
+			Action:       "verify_email",
+			TargetType:   "user",
+			TargetID:     user.ID,
+			Description:  "验证邮箱: " + user.Email,
+		}).Error
+	})
+}
+
+func (s *emailVerificationServiceImpl) Resend(userID uint64, operatorID uint64) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Status != entity.UserStatusPending {
+		return errors.New("用户不是待验证状态")
+	}
+
+	if err := s.Send(user); err != nil {
+		return err
+	}
+
+	return s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(global.G_DB, operatorID),
+		Action:       "resend_verification",
+		TargetType:   "user",
+		TargetID:     user.ID,
+		Description:  "重新发送邮箱验证邮件: " + user.Email,
+	})
+}
@@ -1,6 +1,12 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"gorm.io/gorm"
 )
@@ -12,3 +18,33 @@ func getOperatorName(tx *gorm.DB, userID uint64) string {
 	}
 	return user.Name
 }
+
+// oneTimeTokenBytes 邮件链接一次性令牌的随机字节数
+const oneTimeTokenBytes = 32
+
+// newOneTimeToken 生成通过邮件链接下发的一次性令牌（重置密码、邮箱验证）
+func newOneTimeToken() (string, error) {
+	raw := make([]byte, oneTimeTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashOneTimeToken 计算一次性令牌的 SHA-256 哈希，数据库中只保存哈希
+func hashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenLink 将一次性令牌以 token 参数附加到前端页面地址
+func tokenLink(pageURL, token string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
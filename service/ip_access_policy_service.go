@@ -0,0 +1,348 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+// ipPolicyCacheTTL 已启用策略的本地缓存时间，本实例修改策略时立即刷新，其他实例最迟在该时间后生效
+const ipPolicyCacheTTL = 30 * time.Second
+
+// compiledIPPolicy 预先解析好网段的策略，避免每次鉴权都解析 CIDR
+type compiledIPPolicy struct {
+	entity.IPAccessPolicy
+	network *net.IPNet
+}
+
+type ipAccessPolicyServiceImpl struct {
+	policyRepo repositories.IPAccessPolicyRepository
+	userRepo   repositories.UserRepository
+	roleRepo   repositories.RoleRepository
+
+	mu       sync.RWMutex
+	cache    []compiledIPPolicy
+	loadedAt time.Time
+}
+
+func NewIPAccessPolicyService(policyRepo repositories.IPAccessPolicyRepository, userRepo repositories.UserRepository, roleRepo repositories.RoleRepository) services.IPAccessPolicyService {
+	return &ipAccessPolicyServiceImpl{
+		policyRepo: policyRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+	}
+}
+
+func (s *ipAccessPolicyServiceImpl) List(subjectType string, subjectID uint64) ([]entity.IPAccessPolicy, error) {
+	return s.policyRepo.List(subjectType, subjectID)
+}
+
+func (s *ipAccessPolicyServiceImpl) Create(req *dto.CreateIPAccessPolicyRequest, operatorID uint64, operatorIP string) (*entity.IPAccessPolicy, error) {
+	if err := s.checkOperator(operatorID); err != nil {
+		return nil, err
+	}
+	if err := s.checkSubject(req.SubjectType, req.SubjectID); err != nil {
+		return nil, err
+	}
+	cidr, err := normalizeCIDR(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &entity.IPAccessPolicy{
+		SubjectType: req.SubjectType,
+		SubjectID:   req.SubjectID,
+		Effect:      req.Effect,
+		CIDR:        cidr,
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.checkNotLockedOut(operatorID, operatorIP, func(policies []entity.IPAccessPolicy) []entity.IPAccessPolicy {
+		return append(policies, *policy)
+	}); err != nil {
+		return nil, err
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.policyRepo.Create(tx, policy); err != nil {
+			return err
+		}
+		afterJSON, _ := json.Marshal(policy)
+		return s.audit(tx, operatorID, "create", policy.ID, "", string(afterJSON), fmt.Sprintf("创建 IP 访问策略: %s %s %d %s", policy.Effect, policy.SubjectType, policy.SubjectID, policy.CIDR))
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return policy, nil
+}
+
+func (s *ipAccessPolicyServiceImpl) Update(id uint64, req *dto.UpdateIPAccessPolicyRequest, operatorID uint64, operatorIP string) (*entity.IPAccessPolicy, error) {
+	if err := s.checkOperator(operatorID); err != nil {
+		return nil, err
+	}
+	old, err := s.policyRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("策略不存在")
+	}
+
+	updated := *old
+	if req.Effect != "" {
+		updated.Effect = req.Effect
+	}
+	if req.CIDR != "" {
+		if updated.CIDR, err = normalizeCIDR(req.CIDR); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	if err := s.checkNotLockedOut(operatorID, operatorIP, func(policies []entity.IPAccessPolicy) []entity.IPAccessPolicy {
+		return append(withoutPolicy(policies, id), updated)
+	}); err != nil {
+		return nil, err
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.policyRepo.Update(tx, &updated); err != nil {
+			return err
+		}
+		beforeJSON, _ := json.Marshal(old)
+		afterJSON, _ := json.Marshal(updated)
+		return s.audit(tx, operatorID, "update", id, string(beforeJSON), string(afterJSON), fmt.Sprintf("更新 IP 访问策略: %s %s %d %s", updated.Effect, updated.SubjectType, updated.SubjectID, updated.CIDR))
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return &updated, nil
+}
+
+func (s *ipAccessPolicyServiceImpl) Delete(id uint64, operatorID uint64, operatorIP string) error {
+	if err := s.checkOperator(operatorID); err != nil {
+		return err
+	}
+	policy, err := s.policyRepo.GetByID(id)
+	if err != nil {
+		return errors.New("策略不存在")
+	}
+	// 删除 allow 策略可能让剩余的 allow 策略把操作者挡在外面
+	if err := s.checkNotLockedOut(operatorID, operatorIP, func(policies []entity.IPAccessPolicy) []entity.IPAccessPolicy {
+		return withoutPolicy(policies, id)
+	}); err != nil {
+		return err
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.policyRepo.Delete(tx, id); err != nil {
+			return err
+		}
+		beforeJSON, _ := json.Marshal(policy)
+		return s.audit(tx, operatorID, "delete", id, string(beforeJSON), "", fmt.Sprintf("删除 IP 访问策略: %s %s %d %s", policy.Effect, policy.SubjectType, policy.SubjectID, policy.CIDR))
+	})
+	if err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *ipAccessPolicyServiceImpl) Check(userID uint64, ip string) error {
+	policies, err := s.enabledPolicies()
+	if err != nil {
+		return err
+	}
+	return s.evaluate(policies, userID, ip)
+}
+
+// evaluate 按用户及其角色的策略判断 IP 能否访问
+func (s *ipAccessPolicyServiceImpl) evaluate(policies []compiledIPPolicy, userID uint64, ip string) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	// 只有存在角色策略时才查询用户角色，大多数请求无需额外查询
+	var roleIDs map[uint64]bool
+	for _, p := range policies {
+		if p.SubjectType == entity.IPPolicySubjectRole {
+			ids, err := s.userRepo.GetRoleIDsByUserID(userID)
+			if err != nil {
+				return err
+			}
+			roleIDs = make(map[uint64]bool, len(ids))
+			for _, id := range ids {
+				roleIDs[id] = true
+			}
+			break
+		}
+	}
+
+	clientIP := net.ParseIP(ip)
+	hasAllow, allowed := false, false
+	for _, p := range policies {
+		applies := (p.SubjectType == entity.IPPolicySubjectUser && p.SubjectID == userID) ||
+			(p.SubjectType == entity.IPPolicySubjectRole && roleIDs[p.SubjectID])
+		if !applies {
+			continue
+		}
+		matched := clientIP != nil && p.network.Contains(clientIP)
+		switch p.Effect {
+		case entity.IPPolicyEffectDeny:
+			if matched {
+				return services.ErrIPNotAllowed
+			}
+		case entity.IPPolicyEffectAllow:
+			hasAllow = true
+			allowed = allowed || matched
+		}
+	}
+	if hasAllow && !allowed {
+		return services.ErrIPNotAllowed
+	}
+	return nil
+}
+
+// enabledPolicies 返回已启用的策略，缓存过期时从数据库重新加载
+func (s *ipAccessPolicyServiceImpl) enabledPolicies() ([]compiledIPPolicy, error) {
+	s.mu.RLock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < ipPolicyCacheTTL {
+		policies := s.cache
+		s.mu.RUnlock()
+		return policies, nil
+	}
+	s.mu.RUnlock()
+
+	rows, err := s.policyRepo.ListEnabled()
+	if err != nil {
+		return nil, err
+	}
+	policies := compileIPPolicies(rows)
+
+	s.mu.Lock()
+	s.cache = policies
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return policies, nil
+}
+
+func (s *ipAccessPolicyServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// checkNotLockedOut 模拟变更后的策略集合，避免操作者把自己当前所在的网络挡在外面
+func (s *ipAccessPolicyServiceImpl) checkNotLockedOut(operatorID uint64, operatorIP string, change func([]entity.IPAccessPolicy) []entity.IPAccessPolicy) error {
+	rows, err := s.policyRepo.ListEnabled()
+	if err != nil {
+		return err
+	}
+	err = s.evaluate(compileIPPolicies(change(rows)), operatorID, operatorIP)
+	if errors.Is(err, services.ErrIPNotAllowed) {
+		return fmt.Errorf("该变更会导致你当前的 IP（%s）无法访问，请先调整策略", operatorIP)
+	}
+	return err
+}
+
+// checkOperator IP 访问策略可以限制系统管理员登录，只允许系统管理员维护
+func (s *ipAccessPolicyServiceImpl) checkOperator(operatorID uint64) error {
+	isSuper, err := s.userRepo.HasSystemRole(operatorID)
+	if err != nil {
+		return err
+	}
+	if !isSuper {
+		return errors.New("只有系统管理员可以管理 IP 访问策略")
+	}
+	return nil
+}
+
+func (s *ipAccessPolicyServiceImpl) checkSubject(subjectType string, subjectID uint64) error {
+	switch subjectType {
+	case entity.IPPolicySubjectRole:
+		if _, err := s.roleRepo.GetByID(subjectID); err != nil {
+			return errors.New("角色不存在")
+		}
+	case entity.IPPolicySubjectUser:
+		if _, err := s.userRepo.GetByID(subjectID); err != nil {
+			return errors.New("用户不存在")
+		}
+	default:
+		return errors.New("无效的作用对象类型")
+	}
+	return nil
+}
+
+func (s *ipAccessPolicyServiceImpl) audit(tx *gorm.DB, operatorID uint64, action string, targetID uint64, before, after, description string) error {
+	log := entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(tx, operatorID),
+		Action:       action,
+		TargetType:   "ip_access_policy",
+		TargetID:     targetID,
+		BeforeData:   before,
+		AfterData:    after,
+		Description:  description,
+	}
+	return tx.Create(&log).Error
+}
+
+// compileIPPolicies 解析已启用策略的网段，保存时已校验过格式，解析失败的记录直接跳过
+func compileIPPolicies(rows []entity.IPAccessPolicy) []compiledIPPolicy {
+	policies := make([]compiledIPPolicy, 0, len(rows))
+	for _, row := range rows {
+		if !row.Enabled {
+			continue
+		}
+		_, network, err := net.ParseCIDR(row.CIDR)
+		if err != nil {
+			continue
+		}
+		policies = append(policies, compiledIPPolicy{IPAccessPolicy: row, network: network})
+	}
+	return policies
+}
+
+func withoutPolicy(policies []entity.IPAccessPolicy, id uint64) []entity.IPAccessPolicy {
+	result := make([]entity.IPAccessPolicy, 0, len(policies))
+	for _, p := range policies {
+		if p.ID != id {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// normalizeCIDR 校验并规范化网段，单个 IP 转换为 /32 或 /128
+func normalizeCIDR(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if strings.Contains(input, "/") {
+		_, network, err := net.ParseCIDR(input)
+		if err != nil {
+			return "", fmt.Errorf("无效的网段: %s", input)
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(input)
+	if ip == nil {
+		return "", fmt.Errorf("无效的 IP 地址: %s", input)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/redis/go-redis/v9"
+)
+
+// loginAttemptStore 登录失败计数与临时封禁的存储，启用 Redis 时多实例共享
+type loginAttemptStore interface {
+	// Incr 计数加一并返回当前值，首次计数时设置过期时间
+	Incr(key string, window time.Duration) (int, error)
+	// Count 返回 key 当前的计数，不存在或已过期时为 0
+	Count(key string) (int, error)
+	// Block 在 d 时间内阻止 key 对应的登录
+	Block(key string, d time.Duration) error
+	// BlockedFor 返回 key 剩余的阻止时长，未阻止时为 0
+	BlockedFor(key string) (time.Duration, error)
+	Delete(keys ...string) error
+}
+
+func newLoginAttemptStore() loginAttemptStore {
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		return &redisLoginAttemptStore{client: global.G_REDIS}
+	}
+	return &memoryLoginAttemptStore{entries: make(map[string]*memoryAttemptEntry)}
+}
+
+// incrWithExpireScript 计数加一，首次计数时设置过期时间
+var incrWithExpireScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+type redisLoginAttemptStore struct {
+	client *redis.Client
+}
+
+func (s *redisLoginAttemptStore) Incr(key string, window time.Duration) (int, error) {
+	return incrWithExpireScript.Run(context.Background(), s.client, []string{key}, window.Milliseconds()).Int()
+}
+
+func (s *redisLoginAttemptStore) Count(key string) (int, error) {
+	n, err := s.client.Get(context.Background(), key).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (s *redisLoginAttemptStore) Block(key string, d time.Duration) error {
+	return s.client.Set(context.Background(), key, 1, d).Err()
+}
+
+func (s *redisLoginAttemptStore) BlockedFor(key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// 键不存在时 PTTL 返回负值
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *redisLoginAttemptStore) Delete(keys ...string) error {
+	return s.client.Del(context.Background(), keys...).Err()
+}
+
+// memoryAttemptSweepInterval 内存存储清理过期记录的写入次数间隔
+const memoryAttemptSweepInterval = 1000
+
+type memoryAttemptEntry struct {
+	count     int
+	expiresAt time.Time
+}
+
+type memoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryAttemptEntry
+	writes  int
+}
+
+func (s *memoryLoginAttemptStore) Incr(key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = &memoryAttemptEntry{expiresAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+	entry.count++
+	return entry.count, nil
+}
+
+func (s *memoryLoginAttemptStore) Count(key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return 0, nil
+	}
+	return entry.count, nil
+}
+
+func (s *memoryLoginAttemptStore) Block(key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+
+	s.entries[key] = &memoryAttemptEntry{count: 1, expiresAt: time.Now().Add(d)}
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) BlockedFor(key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := time.Until(entry.expiresAt)
+	if remaining <= 0 {
+		delete(s.entries, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+func (s *memoryLoginAttemptStore) Delete(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep 每累计一定写入次数清理一次过期记录，避免内存无限增长，调用方需持有锁
+func (s *memoryLoginAttemptStore) sweep() {
+	s.writes++
+	if s.writes < memoryAttemptSweepInterval {
+		return
+	}
+	s.writes = 0
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
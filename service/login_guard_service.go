@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+)
+
+type loginGuardServiceImpl struct {
+	store           loginAttemptStore
+	userRepo        repositories.UserRepository
+	auditLogService services.AuditLogService
+}
+
+func NewLoginGuardService(userRepo repositories.UserRepository, auditLogService services.AuditLogService) services.LoginGuardService {
+	return &loginGuardServiceImpl{
+		store:           newLoginAttemptStore(),
+		userRepo:        userRepo,
+		auditLogService: auditLogService,
+	}
+}
+
+func (s *loginGuardServiceImpl) Check(email, ip string) error {
+	email = normalizeEmail(email)
+
+	if remaining, err := s.store.BlockedFor(loginLockKey("email", email)); err != nil {
+		return err
+	} else if remaining > 0 {
+		return &services.LoginBlockedError{Locked: true, RetryAfter: remaining}
+	}
+
+	var wait time.Duration
+	for _, key := range []string{loginLockKey("ip", ip), loginBackoffKey("email", email), loginBackoffKey("ip", ip)} {
+		remaining, err := s.store.BlockedFor(key)
+		if err != nil {
+			return err
+		}
+		if remaining > wait {
+			wait = remaining
+		}
+	}
+	if wait > 0 {
+		return &services.LoginBlockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (s *loginGuardServiceImpl) RecordFailure(email, ip string) error {
+	cfg := config.CfgLoginSecurity
+	email = normalizeEmail(email)
+	window := time.Duration(cfg.FailureWindowMinutes) * time.Minute
+	lockDuration := time.Duration(cfg.LockMinutes) * time.Minute
+
+	emailFailures, err := s.store.Incr(loginFailKey("email", email), window)
+	if err != nil {
+		return err
+	}
+	if cfg.MaxFailures > 0 && emailFailures >= cfg.MaxFailures {
+		if err := s.store.Block(loginLockKey("email", email), lockDuration); err != nil {
+			return err
+		}
+		s.store.Delete(loginFailKey("email", email), loginBackoffKey("email", email))
+		s.auditLock(email, ip, emailFailures)
+	} else if backoff := loginBackoff(emailFailures); backoff > 0 {
+		if err := s.store.Block(loginBackoffKey("email", email), backoff); err != nil {
+			return err
+		}
+	}
+
+	ipFailures, err := s.store.Incr(loginFailKey("ip", ip), window)
+	if err != nil {
+		return err
+	}
+	if cfg.IPMaxFailures > 0 && ipFailures >= cfg.IPMaxFailures {
+		if err := s.store.Block(loginLockKey("ip", ip), lockDuration); err != nil {
+			return err
+		}
+		s.store.Delete(loginFailKey("ip", ip), loginBackoffKey("ip", ip))
+		s.auditIPLock(ip, ipFailures)
+	} else if backoff := loginBackoff(ipFailures); backoff > 0 {
+		if err := s.store.Block(loginBackoffKey("ip", ip), backoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *loginGuardServiceImpl) RecordSuccess(email, ip string) error {
+	email = normalizeEmail(email)
+	// IP 的失败计数保留到统计窗口结束，避免攻击者用自己的账号登录来清零
+	return s.store.Delete(loginFailKey("email", email), loginBackoffKey("email", email), loginBackoffKey("ip", ip))
+}
+
+func (s *loginGuardServiceImpl) Failures(email, ip string) (int, int, error) {
+	emailFailures, err := s.store.Count(loginFailKey("email", normalizeEmail(email)))
+	if err != nil {
+		return 0, 0, err
+	}
+	ipFailures, err := s.store.Count(loginFailKey("ip", ip))
+	if err != nil {
+		return 0, 0, err
+	}
+	return emailFailures, ipFailures, nil
+}
+
+func (s *loginGuardServiceImpl) IsLocked(email string) (bool, time.Duration, error) {
+	remaining, err := s.store.BlockedFor(loginLockKey("email", normalizeEmail(email)))
+	if err != nil {
+		return false, 0, err
+	}
+	return remaining > 0, remaining, nil
+}
+
+func (s *loginGuardServiceImpl) Unlock(userID uint64, operatorID uint64) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	email := normalizeEmail(user.Email)
+
+	locked, _, err := s.IsLocked(email)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.New("账号未被锁定")
+	}
+
+	if err := s.store.Delete(loginLockKey("email", email), loginFailKey("email", email), loginBackoffKey("email", email)); err != nil {
+		return err
+	}
+
+	return s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(global.G_DB, operatorID),
+		Action:       "unlock",
+		TargetType:   "user",
+		TargetID:     user.ID,
+		Description:  "解除账号锁定: " + user.Email,
+	})
+}
+
+// auditLock 记录账号锁定审计日志，邮箱不存在对应用户时不记录
+func (s *loginGuardServiceImpl) auditLock(email, ip string, failures int) {
+	var user entity.User
+	if err := global.G_DB.Where("email = ?", email).First(&user).Error; err != nil {
+		return
+	}
+	s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   user.ID,
+		OperatorName: user.Name,
+		Action:       "lock",
+		TargetType:   "user",
+		TargetID:     user.ID,
+		Description: fmt.Sprintf("连续登录失败 %d 次，账号锁定 %d 分钟: %s (IP: %s)",
+			failures, config.CfgLoginSecurity.LockMinutes, user.Email, ip),
+	})
+}
+
+// auditIPLock 记录 IP 被锁定的审计日志，IP 锁定不对应具体账号，操作人记为系统
+func (s *loginGuardServiceImpl) auditIPLock(ip string, failures int) {
+	s.auditLogService.Create(&entity.AuditLog{
+		OperatorName: "系统",
+		Action:       "lock",
+		TargetType:   "ip",
+		Description: fmt.Sprintf("IP 连续登录失败 %d 次，锁定 %d 分钟: %s",
+			failures, config.CfgLoginSecurity.LockMinutes, ip),
+	})
+}
+
+// loginBackoff 计算第 failures 次失败后的退避时长，超过免退避次数后按指数增长并受上限约束
+func loginBackoff(failures int) time.Duration {
+	cfg := config.CfgLoginSecurity
+	exponent := failures - cfg.FreeAttempts - 1
+	if exponent < 0 || cfg.BackoffBaseSeconds <= 0 {
+		return 0
+	}
+	maxBackoff := time.Duration(cfg.BackoffMaxSeconds) * time.Second
+	if exponent >= 30 {
+		return maxBackoff
+	}
+	backoff := time.Duration(cfg.BackoffBaseSeconds) * time.Second << exponent
+	if maxBackoff > 0 && backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginFailKey(kind, value string) string {
+	return "login_fail:" + kind + ":" + value
+}
+
+func loginBackoffKey(kind, value string) string {
+	return "login_backoff:" + kind + ":" + value
+}
+
+func loginLockKey(kind, value string) string {
+	return "login_lock:" + kind + ":" + value
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+type loginRiskServiceImpl struct {
+	loginGuardSvc services.LoginGuardService
+	sessionRepo   repositories.SessionRepository
+}
+
+func NewLoginRiskService(loginGuardSvc services.LoginGuardService, sessionRepo repositories.SessionRepository) services.LoginRiskService {
+	return &loginRiskServiceImpl{
+		loginGuardSvc: loginGuardSvc,
+		sessionRepo:   sessionRepo,
+	}
+}
+
+func (s *loginRiskServiceImpl) Assess(email, ip, userAgent string) (*services.LoginRiskAssessment, error) {
+	cfg := config.CfgLoginRisk
+	// 未启用风险评估时保持每次登录都需要验证码
+	if !cfg.Enabled {
+		return &services.LoginRiskAssessment{Reasons: []string{"risk_disabled"}, CaptchaRequired: true}, nil
+	}
+
+	assessment := &services.LoginRiskAssessment{Reasons: []string{}}
+	add := func(score int, reason string) {
+		if score <= 0 {
+			return
+		}
+		assessment.Score += score
+		assessment.Reasons = append(assessment.Reasons, reason)
+	}
+
+	emailFailures, ipFailures, err := s.loginGuardSvc.Failures(email, ip)
+	if err != nil {
+		return nil, err
+	}
+	add(emailFailures*cfg.FailureScore, "email_failures")
+	add(ipFailures*cfg.IPFailureScore, "ip_failures")
+
+	var user entity.User
+	err = global.G_DB.Where("email = ?", email).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		// 邮箱未注册时按陌生设备计分，避免通过是否需要验证码判断邮箱是否已注册
+		add(cfg.NewDeviceScore, "new_device")
+	} else {
+		known, err := s.sessionRepo.ExistsByFingerprint(user.ID, deviceFingerprint(userAgent))
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			add(cfg.NewDeviceScore, "new_device")
+		}
+
+		if cfg.VelocityLimit > 0 && cfg.VelocityWindowMinutes > 0 {
+			var logins int64
+			since := time.Now().Add(-time.Duration(cfg.VelocityWindowMinutes) * time.Minute)
+			if err := global.G_DB.Model(&entity.AuditLog{}).
+				Where("action = ? AND target_type = ? AND target_id = ? AND created_at > ?", "login", "user", user.ID, since).
+				Count(&logins).Error; err != nil {
+				return nil, err
+			}
+			if logins >= int64(cfg.VelocityLimit) {
+				add(cfg.VelocityScore, "login_velocity")
+			}
+		}
+	}
+
+	assessment.CaptchaRequired = assessment.Score >= cfg.CaptchaThreshold
+	return assessment, nil
+}
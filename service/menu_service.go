@@ -14,12 +14,14 @@ import (
 // menuServiceImpl 菜单服务实现
 type menuServiceImpl struct {
 	menuRepo domain.MenuRepository
+	permSvc  services.PermissionService
 }
 
 // NewMenuService 创建菜单服务实例
-func NewMenuService(menuRepo domain.MenuRepository) services.MenuService {
+func NewMenuService(menuRepo domain.MenuRepository, permSvc services.PermissionService) services.MenuService {
 	return &menuServiceImpl{
 		menuRepo: menuRepo,
+		permSvc:  permSvc,
 	}
 }
 
@@ -120,7 +122,7 @@ func buildMenuTree(menus []entity.Menu) []entity.Menu {
 
 // createAuditLog 创建审计日志
 func (s *menuServiceImpl) BindResource(menuID, resourceID uint64, operatorID uint64) error {
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		mr := entity.MenuResource{MenuID: menuID, ResourceID: resourceID}
 		if err := tx.Create(&mr).Error; err != nil {
 			return err
@@ -128,16 +130,40 @@ func (s *menuServiceImpl) BindResource(menuID, resourceID uint64, operatorID uin
 		description := fmt.Sprintf("菜单 %d 绑定资源 %d", menuID, resourceID)
 		return s.createAuditLog(tx, operatorID, "bind", "menu_resource", mr.ID, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.clearMenuUsersCache(menuID)
 }
 
 func (s *menuServiceImpl) UnbindResource(menuID, resourceID uint64, operatorID uint64) error {
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		description := fmt.Sprintf("菜单 %d 解绑资源 %d", menuID, resourceID)
 		if err := tx.Where("menu_id = ? AND resource_id = ?", menuID, resourceID).Delete(&entity.MenuResource{}).Error; err != nil {
 			return err
 		}
 		return s.createAuditLog(tx, operatorID, "unbind", "menu_resource", 0, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.clearMenuUsersCache(menuID)
+}
+
+// clearMenuUsersCache 菜单关联的资源并入用户权限，变更后清除绑定该菜单的角色（含继承它们的角色）的持有者的权限缓存
+func (s *menuServiceImpl) clearMenuUsersCache(menuID uint64) error {
+	var roleIDs []uint64
+	if err := global.G_DB.Model(&entity.RoleMenu{}).Where("menu_id = ?", menuID).Pluck("role_id", &roleIDs).Error; err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	userIDs, err := roleUserIDs(global.G_DB, roleIDs...)
+	if err != nil {
+		return err
+	}
+	return clearUsersCache(s.permSvc, userIDs)
 }
 
 func (s *menuServiceImpl) GetMenuResources(menuID uint64) ([]entity.MenuResource, error) {
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/oidc"
+	"gorm.io/gorm"
+)
+
+// maxUserNameLength 用户姓名字段长度上限，自动创建用户时截断
+const maxUserNameLength = 50
+
+var errInvalidOIDCState = errors.New("登录请求无效或已过期，请重新登录")
+
+type oidcProvider struct {
+	cfg    config.OIDCProviderConfig
+	client *oidc.Provider
+}
+
+type oidcServiceImpl struct {
+	providers       map[string]*oidcProvider
+	order           []string
+	stateStore      oidcStateStore
+	identityRepo    repositories.ExternalIdentityRepository
+	userRepo        repositories.UserRepository
+	auditLogService services.AuditLogService
+}
+
+func NewOIDCService(identityRepo repositories.ExternalIdentityRepository, userRepo repositories.UserRepository, auditLogService services.AuditLogService) services.OIDCService {
+	s := &oidcServiceImpl{
+		providers:       make(map[string]*oidcProvider),
+		stateStore:      newOIDCStateStore(),
+		identityRepo:    identityRepo,
+		userRepo:        userRepo,
+		auditLogService: auditLogService,
+	}
+	for _, pc := range config.CfgOIDC.Providers {
+		s.providers[pc.Name] = &oidcProvider{
+			cfg: pc,
+			client: oidc.NewProvider(oidc.Config{
+				Issuer:       pc.Issuer,
+				ClientID:     pc.ClientID,
+				ClientSecret: pc.ClientSecret,
+				RedirectURL:  pc.RedirectURL,
+				Scopes:       pc.Scopes,
+			}),
+		}
+		s.order = append(s.order, pc.Name)
+	}
+	return s
+}
+
+func (s *oidcServiceImpl) Providers() []dto.OIDCProviderResponse {
+	providers := make([]dto.OIDCProviderResponse, 0, len(s.order))
+	for _, name := range s.order {
+		p := s.providers[name]
+		displayName := p.cfg.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		providers = append(providers, dto.OIDCProviderResponse{
+			Name:        name,
+			DisplayName: displayName,
+			LoginURL:    "/oidc/" + name + "/login",
+		})
+	}
+	return providers
+}
+
+func (s *oidcServiceImpl) AuthURL(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", errors.New("不支持的身份提供方")
+	}
+
+	state, err := newOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := newOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+	codeVerifier, err := newOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.client.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := time.Duration(config.CfgOIDC.StateExpiryMinute) * time.Minute
+	if err := s.stateStore.Save(state, &oidcAuthState{Provider: provider, Nonce: nonce, CodeVerifier: codeVerifier}, ttl); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+func (s *oidcServiceImpl) Callback(ctx context.Context, provider, code, state string) (*entity.User, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, errors.New("不支持的身份提供方")
+	}
+
+	authState, err := s.stateStore.Take(state)
+	if err != nil {
+		return nil, err
+	}
+	if authState == nil || authState.Provider != provider {
+		return nil, errInvalidOIDCState
+	}
+
+	token, err := p.client.Exchange(ctx, code, authState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.client.VerifyIDToken(ctx, token.IDToken, authState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// 已关联的外部身份直接登录
+	identity, err := s.identityRepo.GetByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("关联的用户不存在: %w", err)
+		}
+		if err := s.identityRepo.RecordLogin(identity.ID, claims.Email, now); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 只有身份提供方确认过的邮箱才能用于关联或创建用户，防止冒用他人邮箱
+	if claims.Email == "" || !claims.IsEmailVerified() {
+		return nil, errors.New("身份提供方未返回已验证的邮箱，无法登录")
+	}
+
+	var user entity.User
+	err = global.G_DB.Where("email = ?", claims.Email).First(&user).Error
+	switch {
+	case err == nil:
+		if !p.cfg.LinkByEmail {
+			return nil, errors.New("该邮箱已注册，未开启按邮箱自动关联")
+		}
+		err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+			if err := s.identityRepo.Create(tx, newExternalIdentity(user.ID, provider, claims, now)); err != nil {
+				return err
+			}
+			// 身份提供方已验证邮箱，待验证的本地账号随之激活
+			if user.Status == entity.UserStatusPending {
+				if err := s.userRepo.UpdateStatus(tx, user.ID, entity.UserStatusActive); err != nil {
+					return err
+				}
+				user.Status = entity.UserStatusActive
+			}
+			return tx.Create(&entity.AuditLog{
+				OperatorID:   user.ID,
+				OperatorName: user.Name,
+				Action:       "link_identity",
+				TargetType:   "user",
+				TargetID:     user.ID,
+				Description:  fmt.Sprintf("按邮箱关联外部身份 %s: %s", provider, user.Email),
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &user, nil
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !p.cfg.AllowSignup {
+			return nil, errors.New("该邮箱未注册，未开启自动创建用户")
+		}
+		user = entity.User{
+			Name:   oidcUserName(claims),
+			Email:  claims.Email,
+			Status: entity.UserStatusActive,
+		}
+		err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+			if err := s.userRepo.Create(tx, &user); err != nil {
+				return err
+			}
+			if err := s.identityRepo.Create(tx, newExternalIdentity(user.ID, provider, claims, now)); err != nil {
+				return err
+			}
+			return tx.Create(&entity.AuditLog{
+				OperatorID:   user.ID,
+				OperatorName: user.Name,
+				Action:       "signup",
+				TargetType:   "user",
+				TargetID:     user.ID,
+				Description:  fmt.Sprintf("通过 %s 单点登录注册: %s", provider, user.Email),
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &user, nil
+
+	default:
+		return nil, err
+	}
+}
+
+func (s *oidcServiceImpl) ListIdentities(userID uint64) ([]entity.UserExternalIdentity, error) {
+	return s.identityRepo.ListByUserID(userID)
+}
+
+func (s *oidcServiceImpl) Unlink(userID, identityID uint64) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	identities, err := s.identityRepo.ListByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	var target *entity.UserExternalIdentity
+	for i := range identities {
+		if identities[i].ID == identityID {
+			target = &identities[i]
+		}
+	}
+	if target == nil {
+		return errors.New("外部身份不存在")
+	}
+	if user.PassWord == "" && len(identities) == 1 {
+		return errors.New("账号未设置密码，不能解除最后一个外部身份")
+	}
+
+	if _, err := s.identityRepo.Delete(userID, identityID); err != nil {
+		return err
+	}
+	return s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   userID,
+		OperatorName: user.Name,
+		Action:       "unlink_identity",
+		TargetType:   "user",
+		TargetID:     userID,
+		Description:  fmt.Sprintf("解除外部身份关联 %s: %s", target.Provider, target.Email),
+	})
+}
+
+func newExternalIdentity(userID uint64, provider string, claims *oidc.IDTokenClaims, now time.Time) *entity.UserExternalIdentity {
+	return &entity.UserExternalIdentity{
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+}
+
+// oidcUserName 自动创建用户时的姓名，依次取 name、preferred_username、邮箱用户名
+func oidcUserName(claims *oidc.IDTokenClaims) string {
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > maxUserNameLength {
+		name = string(runes[:maxUserNameLength])
+	}
+	return name
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/redis/go-redis/v9"
+)
+
+// oidcAuthState 发起授权时保存的上下文，回调时按 state 取出且只能取出一次
+type oidcAuthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// oidcStateStore 授权 state 存储，启用 Redis 时多实例共享
+type oidcStateStore interface {
+	Save(state string, data *oidcAuthState, ttl time.Duration) error
+	// Take 取出并删除 state，不存在或已过期时返回 nil
+	Take(state string) (*oidcAuthState, error)
+}
+
+func newOIDCStateStore() oidcStateStore {
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		return &redisOIDCStateStore{client: global.G_REDIS}
+	}
+	return &memoryOIDCStateStore{entries: make(map[string]memoryOIDCState)}
+}
+
+func oidcStateKey(state string) string {
+	return "oidc_state:" + state
+}
+
+// getDelScript 原子读取并删除，兼容不支持 GETDEL 的 Redis 版本
+var getDelScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+	redis.call('DEL', KEYS[1])
+end
+return v
+`)
+
+type redisOIDCStateStore struct {
+	client *redis.Client
+}
+
+func (s *redisOIDCStateStore) Save(state string, data *oidcAuthState, ttl time.Duration) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), oidcStateKey(state), raw, ttl).Err()
+}
+
+func (s *redisOIDCStateStore) Take(state string) (*oidcAuthState, error) {
+	raw, err := getDelScript.Run(context.Background(), s.client, []string{oidcStateKey(state)}).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data oidcAuthState
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+type memoryOIDCState struct {
+	data      *oidcAuthState
+	expiresAt time.Time
+}
+
+type memoryOIDCStateStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryOIDCState
+}
+
+func (s *memoryOIDCStateStore) Save(state string, data *oidcAuthState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 写入时顺带清理过期 state，未完成的授权请求不会无限累积
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.entries[state] = memoryOIDCState{data: data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryOIDCStateStore) Take(state string) (*oidcAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.entries, state)
+	if time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	return entry.data, nil
+}
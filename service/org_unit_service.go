@@ -3,7 +3,6 @@ package service
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/repositories"
@@ -77,63 +76,27 @@ func (s *orgUnitServiceImpl) GetOrgUnitByID(id uint64, userID uint64) (*entity.O
 	if err != nil {
 		return nil, err
 	}
-	if ok, _ := s.permSvc.HasSystemRole(userID); !ok {
-		scope, err := s.permSvc.GetUserOrgScope(userID)
-		if err != nil || !s.orgInScope(org, scope) {
-			return nil, fmt.Errorf("组织不存在")
-		}
+	scope, err := s.permSvc.GetDataScope(userID)
+	if err != nil || !scope.Contains(org.ID) {
+		return nil, fmt.Errorf("组织不存在")
 	}
 	return org, nil
 }
 
 func (s *orgUnitServiceImpl) GetAllOrgUnits(userID uint64) ([]entity.OrgUnit, error) {
-	all, err := s.orgRepo.GetAll()
+	scope, err := s.permSvc.GetDataScope(userID)
 	if err != nil {
 		return nil, err
 	}
-	if ok, _ := s.permSvc.HasSystemRole(userID); ok {
-		return all, nil
-	}
-	return s.filterByScope(all, userID), nil
+	return s.orgRepo.GetAll(scope)
 }
 
 func (s *orgUnitServiceImpl) GetOrgTree(userID uint64) ([]entity.OrgUnit, error) {
-	all, err := s.orgRepo.GetAll()
+	scope, err := s.permSvc.GetDataScope(userID)
 	if err != nil {
 		return nil, err
 	}
-	if ok, _ := s.permSvc.HasSystemRole(userID); ok {
-		return all, nil
-	}
-	return s.filterByScope(all, userID), nil
-}
-
-func (s *orgUnitServiceImpl) filterByScope(orgs []entity.OrgUnit, userID uint64) []entity.OrgUnit {
-	scope, err := s.permSvc.GetUserOrgScope(userID)
-	if err != nil || len(scope) == 0 {
-		return nil
-	}
-
-	result := make([]entity.OrgUnit, 0, len(orgs))
-	for _, org := range orgs {
-		if s.orgInScope(&org, scope) {
-			result = append(result, org)
-		}
-	}
-	return result
-}
-
-func (s *orgUnitServiceImpl) orgInScope(org *entity.OrgUnit, scope []services.OrgScopeInfo) bool {
-	for _, sc := range scope {
-		if sc.IncludeDescendants {
-			if org.ID == sc.OrgUnitID || strings.HasPrefix(org.Path, sc.Path+"/") {
-				return true
-			}
-		} else if org.ID == sc.OrgUnitID {
-			return true
-		}
-	}
-	return false
+	return s.orgRepo.GetAll(scope)
 }
 
 func (s *orgUnitServiceImpl) createAuditLog(tx *gorm.DB, operatorID uint64, action, targetType string, targetID uint64, beforeData, afterData, description string) error {
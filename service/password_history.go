@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/util"
+	"gorm.io/gorm"
+)
+
+// checkPasswordReuse 校验新密码未与当前密码及最近 HistoryCount 次使用过的密码相同
+func checkPasswordReuse(tx *gorm.DB, user *entity.User, password string) error {
+	count := config.CfgPassword.HistoryCount
+	if count <= 0 {
+		return nil
+	}
+
+	reuseErr := &util.PasswordPolicyError{Violations: []util.PasswordViolation{
+		{Rule: "history", Message: "不能使用最近使用过的密码"},
+	}}
+	if user.PassWord != "" && util.ComparePassword(user.PassWord, password) == nil {
+		return reuseErr
+	}
+
+	var histories []entity.PasswordHistory
+	if err := tx.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(count).Find(&histories).Error; err != nil {
+		return err
+	}
+	for _, h := range histories {
+		if util.ComparePassword(h.PasswordHash, password) == nil {
+			return reuseErr
+		}
+	}
+	return nil
+}
+
+// savePasswordHistory 记录新密码哈希，只保留最近 HistoryCount 条
+func savePasswordHistory(tx *gorm.DB, userID uint64, hashed string) error {
+	count := config.CfgPassword.HistoryCount
+	if count <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&entity.PasswordHistory{UserID: userID, PasswordHash: hashed}).Error; err != nil {
+		return err
+	}
+
+	// 先取出要保留的最近 count 条再删除其余记录；MySQL 不支持只有 OFFSET 没有 LIMIT 的查询
+	var keep []uint64
+	if err := tx.Model(&entity.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(count).
+		Pluck("id", &keep).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("user_id = ? AND id NOT IN ?", userID, keep).Delete(&entity.PasswordHistory{}).Error
+}
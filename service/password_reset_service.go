@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/mailer"
+	"github.com/lyj404/gin-api-template/util"
+	"gorm.io/gorm"
+)
+
+var errInvalidResetToken = errors.New("重置链接无效或已过期")
+
+type passwordResetServiceImpl struct {
+	resetRepo      repositories.PasswordResetRepository
+	userRepo       repositories.UserRepository
+	sessionService services.SessionService
+	mailer         mailer.Mailer
+}
+
+func NewPasswordResetService(
+	resetRepo repositories.PasswordResetRepository,
+	userRepo repositories.UserRepository,
+	sessionService services.SessionService,
+	m mailer.Mailer,
+) services.PasswordResetService {
+	return &passwordResetServiceImpl{
+		resetRepo:      resetRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		mailer:         m,
+	}
+}
+
+func (s *passwordResetServiceImpl) Forgot(email, ip string) error {
+	var user entity.User
+	if err := global.G_DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := newOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	expiry := time.Duration(config.CfgPasswordReset.ExpiryMinute) * time.Minute
+	if err := s.resetRepo.Create(&entity.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashOneTimeToken(token),
+		ExpiresAt: time.Now().Add(expiry),
+		RequestIP: ip,
+	}); err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置您账号密码的请求，请在 %d 分钟内打开以下链接设置新密码：\n\n%s\n\n链接只能使用一次。如果这不是您本人的操作，请忽略本邮件，您的密码不会改变。\n",
+			user.Name, config.CfgPasswordReset.ExpiryMinute, tokenLink(config.CfgPasswordReset.ResetURL, token)),
+	}
+	// 异步发送，避免响应耗时差异暴露邮箱是否已注册
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("ERROR: 重置密码邮件发送失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *passwordResetServiceImpl) Reset(token, newPassword string) error {
+	resetToken, err := s.resetRepo.GetByTokenHash(hashOneTimeToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidResetToken
+		}
+		return err
+	}
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return errInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(resetToken.UserID)
+	if err != nil {
+		return errInvalidResetToken
+	}
+
+	// 先校验密码策略，不符合时令牌保持可用
+	if err := util.ValidatePassword(newPassword, user.Email); err != nil {
+		return err
+	}
+	hashed, err := util.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkPasswordReuse(tx, user, newPassword); err != nil {
+			return err
+		}
+		used, err := s.resetRepo.MarkUsed(tx, resetToken.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errInvalidResetToken
+		}
+		if err := s.userRepo.UpdatePassword(tx, user.ID, hashed); err != nil {
+			return err
+		}
+		if err := savePasswordHistory(tx, user.ID, hashed); err != nil {
+			return err
+		}
+		return tx.Create(&entity.AuditLog{
+			OperatorID:   user.ID,
+			OperatorName: user.Name,
+			Action:       "reset_password",
+			TargetType:   "user",
+			TargetID:     user.ID,
+			Description:  fmt.Sprintf("通过邮件重置密码: %s", user.Email),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 密码已重置，撤销全部登录会话及其刷新令牌
+	_, err = s.sessionService.RevokeAll(user.ID, "", user.ID)
+	return err
+}
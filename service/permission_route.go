@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// routeResourcesCacheKey 按路由鉴权时每个请求都要用到全部 API 资源，启用 Redis 时缓存，资源增删改后清除
+const routeResourcesCacheKey = "permission:route-resources"
+
+// AllowedRouteResources 找出 Pattern 与 Method 匹配路由和方法的 API 资源，返回其中用户有权访问的资源名
+// 任一匹配资源上生效的 deny 即整体拒绝，返回空；没有匹配的资源时同样返回空
+func (s *permissionServiceImpl) AllowedRouteResources(userID uint64, path, method string, attrs *services.AccessAttributes) ([]string, error) {
+	return s.checkRoute(userID, path, method, attrs, nil)
+}
+
+// checkRoute 按路由匹配资源的判定，CheckRoute 中间件与权限说明共用
+// 汇总全部匹配资源的授权按拒绝优先裁决：先检查所有资源上的 deny，任一生效即拒绝，
+// 否则返回存在生效 allow 的资源名。trace 不为空时每个匹配资源记录一条 ResourceTrace
+func (s *permissionServiceImpl) checkRoute(userID uint64, path, method string, attrs *services.AccessAttributes, trace *services.PermissionTrace) ([]string, error) {
+	resources, err := s.resourcesForPath(path, method)
+	if err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		traceResult(trace, false, "没有与该路由和方法对应的 API 资源")
+		return nil, nil
+	}
+	permissions, err := s.getUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if trace != nil {
+		trace.Resources = make([]services.ResourceTrace, len(resources))
+	}
+	resTrace := func(i int) *services.ResourceTrace {
+		if trace == nil {
+			return nil
+		}
+		return &trace.Resources[i]
+	}
+
+	matched := make([][]services.PermissionInfo, len(resources))
+	refs := make([][]*services.BindingTrace, len(resources))
+	for i, res := range resources {
+		if rt := resTrace(i); rt != nil {
+			rt.Resource = res.Name
+			rt.Pattern = res.Pattern
+		}
+		matched[i] = s.matchBindings(permissions, res.Name, method, resTrace(i))
+		refs[i] = appliedBindings(resTrace(i))
+	}
+
+	eval := s.newConditionEvaluator(userID, attrs)
+	for i, res := range resources {
+		j, err := firstEffective(eval, matched[i], refs[i], true)
+		if err != nil {
+			return nil, err
+		}
+		if j >= 0 {
+			reason := "命中拒绝授权 " + describePermission(matched[i][j])
+			traceDecision(resTrace(i), refs[i], j, false, reason)
+			traceResult(trace, false, "资源 "+res.Name+" "+reason)
+			return nil, nil
+		}
+	}
+
+	var allowed []string
+	traceResult(trace, false, "对应的资源均未授权")
+	for i, res := range resources {
+		ok, err := decideAllow(eval, matched[i], resTrace(i), refs[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if len(allowed) == 0 && trace != nil {
+				traceResult(trace, true, "资源 "+res.Name+" "+resTrace(i).Reason)
+			}
+			allowed = append(allowed, res.Name)
+		}
+	}
+	return allowed, nil
+}
+
+// resourcesForPath 找出 Pattern 与 Method 匹配请求路径和方法的 API 资源
+func (s *permissionServiceImpl) resourcesForPath(path, method string) ([]entity.Resource, error) {
+	all, err := s.apiResources()
+	if err != nil {
+		return nil, err
+	}
+	var matched []entity.Resource
+	for _, res := range all {
+		if res.MatchesRoute(path, method) {
+			matched = append(matched, res)
+		}
+	}
+	return matched, nil
+}
+
+// apiResources 全部 API 资源，按名称排序
+func (s *permissionServiceImpl) apiResources() ([]entity.Resource, error) {
+	if config.CfgRedis.Enabled {
+		cached, err := global.G_REDIS.Get(context.Background(), routeResourcesCacheKey).Result()
+		if err == nil && cached != "" {
+			var resources []entity.Resource
+			if err := json.Unmarshal([]byte(cached), &resources); err == nil {
+				return resources, nil
+			}
+		}
+	}
+
+	var resources []entity.Resource
+	if err := global.G_DB.Where("type = ?", "api").Order("name").Find(&resources).Error; err != nil {
+		return nil, err
+	}
+
+	if config.CfgRedis.Enabled {
+		data, _ := json.Marshal(resources)
+		global.G_REDIS.Set(context.Background(), routeResourcesCacheKey, data, 0)
+	}
+	return resources, nil
+}
+
+// clearRouteResourcesCache 资源增删改后清除 API 资源缓存
+func clearRouteResourcesCache() error {
+	if !config.CfgRedis.Enabled {
+		return nil
+	}
+	return global.G_REDIS.Del(context.Background(), routeResourcesCacheKey).Err()
+}
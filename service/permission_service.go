@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/condition"
 )
 
 type permissionServiceImpl struct{}
@@ -19,27 +22,43 @@ func NewPermissionService() services.PermissionService {
 	return &permissionServiceImpl{}
 }
 
-func (s *permissionServiceImpl) CheckPermission(userID uint64, resource string, method string) (bool, error) {
+func (s *permissionServiceImpl) CheckPermission(userID uint64, resource string, method string, attrs *services.AccessAttributes) (bool, error) {
+	return s.checkResource(userID, resource, method, attrs, nil)
+}
+
+func (s *permissionServiceImpl) CheckEntityPermission(userID uint64, entityType string, entityID uint64, action string, attrs *services.AccessAttributes) (bool, error) {
+	return s.checkEntity(userID, entityType, entityID, action, attrs, nil)
+}
+
+// checkResource 资源权限判定，trace 不为空时记录匹配的授权与裁决结果
+func (s *permissionServiceImpl) checkResource(userID uint64, resource string, method string, attrs *services.AccessAttributes, trace *services.ResourceTrace) (bool, error) {
 	permissions, err := s.getUserPermissions(userID)
 	if err != nil {
 		return false, err
 	}
 
+	return s.decide(userID, s.matchBindings(permissions, resource, method, trace), attrs, trace)
+}
+
+// matchBindings 找出资源模式匹配 resource 且读写标记适用于 method 的授权，trace 不为空时记录全部模式匹配的授权
+func (s *permissionServiceImpl) matchBindings(permissions []services.PermissionInfo, resource string, method string, trace *services.ResourceTrace) []services.PermissionInfo {
+	isWrite := s.isWriteMethod(method)
+	var matched []services.PermissionInfo
 	for _, perm := range permissions {
-		if s.matchPattern(perm.ResourceName, resource) {
-			isWrite := s.isWriteMethod(method)
-			if isWrite && perm.IsWrite {
-				return true, nil
-			} else if !isWrite && perm.IsRead {
-				return true, nil
-			}
+		if !s.matchPattern(perm.ResourceName, resource) {
+			continue
+		}
+		applies := (isWrite && perm.IsWrite) || (!isWrite && perm.IsRead)
+		if applies {
+			matched = append(matched, perm)
 		}
+		traceBinding(trace, perm, applies)
 	}
-
-	return false, nil
+	return matched
 }
 
-func (s *permissionServiceImpl) CheckEntityPermission(userID uint64, entityType string, entityID uint64, action string) (bool, error) {
+// checkEntity 实体权限判定：先按 entity:<类型>:<操作> 裁决授权，再比较实体所属组织与用户的组织范围
+func (s *permissionServiceImpl) checkEntity(userID uint64, entityType string, entityID uint64, action string, attrs *services.AccessAttributes, trace *services.PermissionTrace) (bool, error) {
 	entityResourceName := fmt.Sprintf("entity:%s:%s", entityType, action)
 
 	permissions, err := s.getUserPermissions(userID)
@@ -47,15 +66,27 @@ func (s *permissionServiceImpl) CheckEntityPermission(userID uint64, entityType
 		return false, err
 	}
 
-	hasPermission := false
+	var resTrace *services.ResourceTrace
+	if trace != nil {
+		trace.Resources = append(trace.Resources, services.ResourceTrace{Resource: entityResourceName})
+		resTrace = &trace.Resources[len(trace.Resources)-1]
+	}
+
+	var matched []services.PermissionInfo
 	for _, perm := range permissions {
 		if perm.ResourceName == "entity:all" || s.matchPattern(perm.ResourceName, entityResourceName) {
-			hasPermission = true
-			break
+			matched = append(matched, perm)
+			traceBinding(resTrace, perm, true)
 		}
 	}
 
+	hasPermission, err := s.decide(userID, matched, attrs, resTrace)
+	if err != nil {
+		return false, err
+	}
+
 	if !hasPermission {
+		traceResult(trace, false, "实体资源 "+entityResourceName+" 未授权")
 		return false, nil
 	}
 
@@ -65,33 +96,89 @@ func (s *permissionServiceImpl) CheckEntityPermission(userID uint64, entityType
 	}
 
 	if len(orgScope) == 0 {
+		traceResult(trace, false, "用户没有任何组织范围")
 		return false, nil
 	}
 
 	var binding entity.OrgEntityBinding
 	err = global.G_DB.Where("entity_type = ? AND entity_id = ?", entityType, entityID).First(&binding).Error
 	if err != nil {
+		traceResult(trace, false, fmt.Sprintf("实体 %s:%d 没有绑定组织", entityType, entityID))
 		return false, nil
 	}
 
+	var orgUnit *entity.OrgUnit
+	loadOrgUnit := func() *entity.OrgUnit {
+		if orgUnit == nil {
+			var unit entity.OrgUnit
+			if err := global.G_DB.First(&unit, binding.OrgUnitID).Error; err != nil {
+				return nil
+			}
+			orgUnit = &unit
+		}
+		return orgUnit
+	}
+
 	for _, scope := range orgScope {
-		if scope.OrgUnitID == binding.OrgUnitID {
-			return true, nil
+		check := services.OrgCheckTrace{
+			ScopeOrgUnitID:     scope.OrgUnitID,
+			ScopePath:          scope.Path,
+			IncludeDescendants: scope.IncludeDescendants,
+			EntityOrgUnitID:    binding.OrgUnitID,
+		}
+		if trace != nil {
+			if unit := loadOrgUnit(); unit != nil {
+				check.EntityPath = unit.Path
+			}
 		}
 
-		if scope.IncludeDescendants {
-			var orgUnit entity.OrgUnit
-			if err := global.G_DB.First(&orgUnit, binding.OrgUnitID).Error; err == nil {
-				if strings.HasPrefix(orgUnit.Path, scope.Path) {
-					return true, nil
-				}
+		switch {
+		case scope.OrgUnitID == binding.OrgUnitID:
+			check.Matched = true
+			check.Reason = "实体所属组织即范围组织"
+		case scope.IncludeDescendants:
+			if unit := loadOrgUnit(); unit == nil {
+				check.Reason = "实体所属组织不存在"
+			} else if strings.HasPrefix(unit.Path, scope.Path) {
+				check.Matched = true
+				check.Reason = fmt.Sprintf("实体组织路径 %s 以范围路径 %s 开头", unit.Path, scope.Path)
+			} else {
+				check.Reason = fmt.Sprintf("实体组织路径 %s 不以范围路径 %s 开头", unit.Path, scope.Path)
 			}
+		default:
+			check.Reason = "组织不同且范围不含下级"
+		}
+
+		if trace != nil {
+			trace.OrgChecks = append(trace.OrgChecks, check)
+		}
+		if check.Matched {
+			traceResult(trace, true, fmt.Sprintf("实体资源已授权，且实体所属组织在范围 %s 内", scope.Path))
+			return true, nil
 		}
 	}
 
+	traceResult(trace, false, "实体所属组织不在用户的组织范围内")
 	return false, nil
 }
 
+func (s *permissionServiceImpl) CheckScopes(scopes []services.PermissionInfo, resource string, method string) bool {
+	isWrite := s.isWriteMethod(method)
+	for _, scope := range scopes {
+		matched := s.matchPattern(scope.ResourceName, resource) ||
+			(scope.ResourceName == "entity:all" && strings.HasPrefix(resource, "entity:"))
+		if !matched {
+			continue
+		}
+		if isWrite && scope.IsWrite {
+			return true
+		} else if !isWrite && scope.IsRead {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *permissionServiceImpl) GetUserPermissions(userID uint64) ([]services.PermissionInfo, error) {
 	return s.getUserPermissions(userID)
 }
@@ -109,16 +196,40 @@ func (s *permissionServiceImpl) ClearUserCache(userID uint64) error {
 	return global.G_REDIS.Del(context.Background(), cacheKey).Err()
 }
 
+// ClearAllPermissionCaches 清除全部用户的权限缓存与 API 资源缓存
+// 供 rbaccli 等不经过服务直接修改资源与角色绑定的工具使用，这些缓存在没有限时分配时不会过期
+func ClearAllPermissionCaches() error {
+	if !config.CfgRedis.Enabled {
+		return nil
+	}
+	if err := clearRouteResourcesCache(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	iter := global.G_REDIS.Scan(ctx, 0, "user:permissions:*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := global.G_REDIS.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
 func (s *permissionServiceImpl) GetUserMenus(userID uint64) ([]services.MenuTreeNode, error) {
-	var userRoles []entity.UserRole
-	err := global.G_DB.Preload("Role.RoleMenus.Menu.Resources").Where("user_id = ?", userID).Find(&userRoles).Error
+	via, _, err := s.resolveUserRoles(userID)
 	if err != nil {
 		return nil, err
 	}
+	var roles []entity.Role
+	if len(via) > 0 {
+		if err := global.G_DB.Preload("RoleMenus.Menu").Where("id IN ?", roleIDsOf(via)).Find(&roles).Error; err != nil {
+			return nil, err
+		}
+	}
 
 	menuMap := make(map[uint64]*entity.Menu)
-	for _, ur := range userRoles {
-		for _, rm := range ur.Role.RoleMenus {
+	for _, role := range roles {
+		for _, rm := range role.RoleMenus {
 			if rm.Menu != nil && rm.Menu.IsVisible && rm.Menu.Status == "enabled" {
 				if _, exists := menuMap[rm.Menu.ID]; !exists {
 					menuMap[rm.Menu.ID] = rm.Menu
@@ -178,31 +289,44 @@ func (s *permissionServiceImpl) getUserPermissions(userID uint64) ([]services.Pe
 		}
 	}
 
-	var userRoles []entity.UserRole
-	err := global.G_DB.
-		Preload("Role.RoleResources.Resource").
-		Preload("Role.RoleMenus.Menu.Resources").
-		Where("user_id = ?", userID).
-		Find(&userRoles).Error
+	via, nextChange, err := s.resolveUserRoles(userID)
 	if err != nil {
 		return nil, err
 	}
+	var roles []entity.Role
+	if len(via) > 0 {
+		err = global.G_DB.
+			Preload("RoleResources.Resource").
+			Preload("RoleMenus.Menu.Resources").
+			Where("id IN ?", roleIDsOf(via)).
+			Find(&roles).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roleNames := make(map[uint64]string, len(roles))
+	for _, role := range roles {
+		roleNames[role.ID] = role.Name
+	}
 
 	permissionMap := make(map[string]*services.PermissionInfo)
+	for _, role := range roles {
+		source := services.PermissionSource{Role: role.Name}
+		if v := via[role.ID]; v != role.ID {
+			source.InheritedVia = roleNames[v]
+		}
 
-	// 1. 收集角色直接绑定的资源
-	for _, userRole := range userRoles {
-		for _, roleResource := range userRole.Role.RoleResources {
-			s.mergePermission(permissionMap, roleResource.Resource.Name, roleResource.IsRead, roleResource.IsWrite)
+		// 1. 收集角色直接绑定的资源
+		for _, roleResource := range role.RoleResources {
+			s.mergePermission(permissionMap, roleResource.Resource.Name, roleResource.IsRead, roleResource.IsWrite, roleResource.Effect, roleResource.Condition, source)
 		}
-	}
 
-	// 2. 收集角色通过菜单绑定的资源
-	for _, userRole := range userRoles {
-		for _, roleMenu := range userRole.Role.RoleMenus {
+		// 2. 收集角色通过菜单绑定的资源
+		for _, roleMenu := range role.RoleMenus {
 			if roleMenu.Menu != nil {
 				for _, res := range roleMenu.Menu.Resources {
-					s.mergePermission(permissionMap, res.Name, true, false)
+					s.mergePermission(permissionMap, res.Name, true, false, entity.RoleResourceEffectAllow, "", source)
 				}
 			}
 		}
@@ -214,44 +338,232 @@ func (s *permissionServiceImpl) getUserPermissions(userID uint64) ([]services.Pe
 	}
 
 	if config.CfgRedis.Enabled {
+		// 存在限时分配时缓存在最近的生效/失效时刻过期，保证到点后重新计算
+		var ttl time.Duration
+		if !nextChange.IsZero() {
+			ttl = time.Until(nextChange)
+			if ttl < time.Second {
+				ttl = time.Second
+			}
+		}
 		data, _ := json.Marshal(permissions)
-		global.G_REDIS.Set(context.Background(), cacheKey, data, 0)
+		global.G_REDIS.Set(context.Background(), cacheKey, data, ttl)
 	}
 
 	return permissions, nil
 }
 
-func (s *permissionServiceImpl) mergePermission(permMap map[string]*services.PermissionInfo, name string, isRead, isWrite bool) {
-	if perm, exists := permMap[name]; exists {
+// mergePermission 按资源名、效果与条件合并，allow 与 deny、条件不同的授权各自保留，由 decide 统一裁决
+func (s *permissionServiceImpl) mergePermission(permMap map[string]*services.PermissionInfo, name string, isRead, isWrite bool, effect, cond string, source services.PermissionSource) {
+	if effect == "" {
+		effect = entity.RoleResourceEffectAllow
+	}
+	key := name + "\x00" + effect + "\x00" + cond
+	if perm, exists := permMap[key]; exists {
 		perm.IsRead = perm.IsRead || isRead
 		perm.IsWrite = perm.IsWrite || isWrite
+		for _, src := range perm.Sources {
+			if src == source {
+				return
+			}
+		}
+		perm.Sources = append(perm.Sources, source)
 	} else {
-		permMap[name] = &services.PermissionInfo{
+		perm := &services.PermissionInfo{
 			ResourceName: name,
 			IsRead:       isRead,
 			IsWrite:      isWrite,
+			Effect:       effect,
+			Condition:    cond,
+			Sources:      []services.PermissionSource{source},
+		}
+		if cond != "" {
+			if expr, err := condition.Cached(cond); err == nil {
+				perm.ConditionText = expr.Explain()
+			} else {
+				perm.ConditionText = "条件无效，授权不生效"
+			}
 		}
+		permMap[key] = perm
 	}
 }
 
-func (s *permissionServiceImpl) getUserOrgScope(userID uint64) ([]services.OrgScopeInfo, error) {
+// decide 按拒绝优先（deny-overrides）裁决命中的授权：任意一条生效的 deny 即拒绝，否则任意一条生效的 allow 即允许
+// 条件表达式无效时 deny 按生效处理、allow 按不生效处理，保证异常数据不会放大权限
+// trace 不为空时其中 Applies 的授权与 matched 按顺序一一对应，记录条件求值结果与起决定作用的授权
+func (s *permissionServiceImpl) decide(userID uint64, matched []services.PermissionInfo, attrs *services.AccessAttributes, trace *services.ResourceTrace) (bool, error) {
+	refs := appliedBindings(trace)
+	eval := s.newConditionEvaluator(userID, attrs)
+	i, err := firstEffective(eval, matched, refs, true)
+	if err != nil {
+		return false, err
+	}
+	if i >= 0 {
+		traceDecision(trace, refs, i, false, "命中拒绝授权 "+describePermission(matched[i]))
+		return false, nil
+	}
+	return decideAllow(eval, matched, trace, refs)
+}
+
+// decideAllow 已确认没有生效的 deny 后，按 allow 授权裁决
+func decideAllow(eval *conditionEvaluator, matched []services.PermissionInfo, trace *services.ResourceTrace, refs []*services.BindingTrace) (bool, error) {
+	if len(matched) == 0 {
+		traceDecision(trace, refs, -1, false, "没有匹配的授权")
+		return false, nil
+	}
+	i, err := firstEffective(eval, matched, refs, false)
+	if err != nil {
+		return false, err
+	}
+	if i >= 0 {
+		traceDecision(trace, refs, i, true, "命中允许授权 "+describePermission(matched[i]))
+		return true, nil
+	}
+	traceDecision(trace, refs, -1, false, "没有条件成立的允许授权")
+	return false, nil
+}
+
+// firstEffective 返回 matched 中第一条条件成立的 deny（deny 为 true 时）或 allow 授权的下标，没有时返回 -1
+func firstEffective(eval *conditionEvaluator, matched []services.PermissionInfo, refs []*services.BindingTrace, deny bool) (int, error) {
+	for i, perm := range matched {
+		if (perm.Effect == entity.RoleResourceEffectDeny) != deny {
+			continue
+		}
+		holds, err := eval.holds(perm.Condition, deny)
+		if err != nil {
+			return -1, err
+		}
+		traceCondition(refs, i, holds)
+		if holds {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// conditionEvaluator 单次鉴权内复用请求属性，用户组织仅在条件引用 org_unit 时加载一次
+type conditionEvaluator struct {
+	svc       *permissionServiceImpl
+	userID    uint64
+	attrs     condition.Attributes
+	orgLoaded bool
+}
+
+func (s *permissionServiceImpl) newConditionEvaluator(userID uint64, attrs *services.AccessAttributes) *conditionEvaluator {
+	evalAttrs := condition.Attributes{Time: time.Now()}
+	if attrs != nil {
+		if !attrs.Time.IsZero() {
+			evalAttrs.Time = attrs.Time
+		}
+		evalAttrs.IP = net.ParseIP(attrs.ClientIP)
+	}
+	return &conditionEvaluator{svc: s, userID: userID, attrs: evalAttrs}
+}
+
+// holds 无条件的授权总是生效，invalid 为表达式无法解析时的返回值
+func (e *conditionEvaluator) holds(cond string, invalid bool) (bool, error) {
+	if cond == "" {
+		return true, nil
+	}
+	expr, err := condition.Cached(cond)
+	if err != nil {
+		return invalid, nil
+	}
+	if expr.UsesOrgUnit() && !e.orgLoaded {
+		orgs, err := e.svc.getUserOrgUnits(e.userID)
+		if err != nil {
+			return false, err
+		}
+		e.attrs.OrgUnits = orgs
+		e.orgLoaded = true
+	}
+	return expr.Eval(e.attrs), nil
+}
+
+// resolveUserRoles 返回用户当前生效的角色分配及沿继承关系可达的全部祖先角色
+// 值为取得该角色所经由的直接分配角色（直接分配的角色为自身），遍历时跳过已访问的角色以防数据中存在环
+// nextChange 为最近一次分配生效或失效的时间，没有限时分配时为零值，调用方据此设置缓存过期时间
+func (s *permissionServiceImpl) resolveUserRoles(userID uint64) (via map[uint64]uint64, nextChange time.Time, err error) {
 	var userRoles []entity.UserRole
-	err := global.G_DB.Preload("Role.RoleOrgScopes.OrgUnit").Where("user_id = ?", userID).Find(&userRoles).Error
+	if err := global.G_DB.Where("user_id = ?", userID).Find(&userRoles).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+
+	now := time.Now()
+	via = make(map[uint64]uint64, len(userRoles))
+	var frontier []uint64
+	for _, ur := range userRoles {
+		for _, boundary := range []*time.Time{ur.ValidFrom, ur.ValidUntil} {
+			if boundary != nil && boundary.After(now) && (nextChange.IsZero() || boundary.Before(nextChange)) {
+				nextChange = *boundary
+			}
+		}
+		if !ur.ActiveAt(now) {
+			continue
+		}
+		if _, ok := via[ur.RoleID]; !ok {
+			via[ur.RoleID] = ur.RoleID
+			frontier = append(frontier, ur.RoleID)
+		}
+	}
+	for len(frontier) > 0 {
+		var links []entity.RoleParent
+		if err := global.G_DB.Where("role_id IN ?", frontier).Find(&links).Error; err != nil {
+			return nil, time.Time{}, err
+		}
+		frontier = nil
+		for _, link := range links {
+			if _, ok := via[link.ParentID]; !ok {
+				via[link.ParentID] = via[link.RoleID]
+				frontier = append(frontier, link.ParentID)
+			}
+		}
+	}
+	return via, nextChange, nil
+}
+
+func roleIDsOf(via map[uint64]uint64) []uint64 {
+	ids := make([]uint64, 0, len(via))
+	for id := range via {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// getUserOrgUnits 用户通过角色分配所在的组织节点，供 org_unit 条件使用
+func (s *permissionServiceImpl) getUserOrgUnits(userID uint64) ([]condition.OrgUnit, error) {
+	var userRoles []entity.UserRole
+	if err := global.G_DB.Preload("OrgUnit").Scopes(entity.UserRoleActiveAt(time.Now())).Where("user_id = ?", userID).Find(&userRoles).Error; err != nil {
+		return nil, err
+	}
+	orgs := make([]condition.OrgUnit, 0, len(userRoles))
+	for _, ur := range userRoles {
+		orgs = append(orgs, condition.OrgUnit{ID: ur.OrgUnitID, Path: ur.OrgUnit.Path})
+	}
+	return orgs, nil
+}
+
+func (s *permissionServiceImpl) getUserOrgScope(userID uint64) ([]services.OrgScopeInfo, error) {
+	via, _, err := s.resolveUserRoles(userID)
 	if err != nil {
 		return nil, err
 	}
+	var roleScopes []entity.RoleOrgScope
+	if len(via) > 0 {
+		if err := global.G_DB.Preload("OrgUnit").Where("role_id IN ?", roleIDsOf(via)).Find(&roleScopes).Error; err != nil {
+			return nil, err
+		}
+	}
 
 	scopeMap := make(map[uint64]*services.OrgScopeInfo)
-	for _, userRole := range userRoles {
-		for _, scope := range userRole.Role.RoleOrgScopes {
-			if info, exists := scopeMap[scope.OrgUnitID]; exists {
-				info.IncludeDescendants = info.IncludeDescendants || scope.IncludeDescendants
-			} else {
-				scopeMap[scope.OrgUnitID] = &services.OrgScopeInfo{
-					OrgUnitID:          scope.OrgUnit.ID,
-					IncludeDescendants: scope.IncludeDescendants,
-					Path:               scope.OrgUnit.Path,
-				}
+	for _, scope := range roleScopes {
+		if info, exists := scopeMap[scope.OrgUnitID]; exists {
+			info.IncludeDescendants = info.IncludeDescendants || scope.IncludeDescendants
+		} else {
+			scopeMap[scope.OrgUnitID] = &services.OrgScopeInfo{
+				OrgUnitID:          scope.OrgUnit.ID,
+				IncludeDescendants: scope.IncludeDescendants,
+				Path:               scope.OrgUnit.Path,
 			}
 		}
 	}
@@ -268,6 +580,7 @@ func (s *permissionServiceImpl) HasSystemRole(userID uint64) (bool, error) {
 	var count int64
 	err := global.G_DB.Model(&entity.UserRole{}).
 		Joins(`JOIN role ON role.id = user_role.role_id AND role.deleted_at IS NULL`).
+		Scopes(entity.UserRoleActiveAt(time.Now())).
 		Where("user_role.user_id = ? AND role.is_system = ?", userID, true).
 		Count(&count).Error
 	return count > 0, err
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+)
+
+// ExplainPermission 复用鉴权时的判定函数记录判定过程，结果与 RBAC 中间件一致
+// 按路径判定时按路由的声明选择中间件使用的判定：声明了资源名时同 CheckPermission，按路由匹配资源时同 CheckRoute
+// 个人访问令牌的授权范围与模拟登录的双重校验不在说明范围内
+func (s *permissionServiceImpl) ExplainPermission(req *services.ExplainRequest) (*services.PermissionTrace, error) {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	attrs := &services.AccessAttributes{}
+	if req.Attrs != nil {
+		*attrs = *req.Attrs
+	}
+	if attrs.Time.IsZero() {
+		attrs.Time = time.Now()
+	}
+
+	trace := &services.PermissionTrace{
+		UserID:   req.UserID,
+		ClientIP: attrs.ClientIP,
+		Time:     attrs.Time.Format("2006-01-02 15:04:05"),
+	}
+	roles, err := s.traceRoles(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	trace.Roles = roles
+
+	switch {
+	case req.EntityType != "":
+		if req.Action == "" {
+			return nil, errors.New("实体判定需要指定操作")
+		}
+		trace.EntityType = req.EntityType
+		trace.EntityID = req.EntityID
+		trace.Action = req.Action
+		if _, err := s.checkEntity(req.UserID, req.EntityType, req.EntityID, req.Action, attrs, trace); err != nil {
+			return nil, err
+		}
+
+	case req.Resource != "":
+		trace.Method = method
+		resTrace := services.ResourceTrace{Resource: req.Resource}
+		allowed, err := s.checkResource(req.UserID, req.Resource, method, attrs, &resTrace)
+		if err != nil {
+			return nil, err
+		}
+		trace.Resources = append(trace.Resources, resTrace)
+		traceResult(trace, allowed, resTrace.Reason)
+
+	case req.Path != "":
+		if req.Route == nil {
+			return nil, errors.New("没有与该路径和方法对应的路由")
+		}
+		trace.Method = method
+		trace.Path = req.Path
+		trace.Route = req.Route.Route
+		switch {
+		case req.Route.Unchecked:
+			traceResult(trace, true, "该路由公开或登录即可访问，不检查资源权限")
+		case req.Route.Resource != "":
+			resTrace := services.ResourceTrace{Resource: req.Route.Resource}
+			allowed, err := s.checkResource(req.UserID, req.Route.Resource, method, attrs, &resTrace)
+			if err != nil {
+				return nil, err
+			}
+			trace.Resources = append(trace.Resources, resTrace)
+			traceResult(trace, allowed, "路由声明的资源 "+req.Route.Resource+" "+resTrace.Reason)
+		default:
+			if _, err := s.checkRoute(req.UserID, req.Route.Route, method, attrs, trace); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, errors.New("需要指定资源名、请求路径或实体类型之一")
+	}
+	return trace, nil
+}
+
+// traceRoles 用户当前生效的角色，按角色 ID 排序
+func (s *permissionServiceImpl) traceRoles(userID uint64) ([]services.TraceRole, error) {
+	via, _, err := s.resolveUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	var roles []entity.Role
+	if len(via) > 0 {
+		if err := global.G_DB.Where("id IN ?", roleIDsOf(via)).Order("id").Find(&roles).Error; err != nil {
+			return nil, err
+		}
+	}
+	names := make(map[uint64]string, len(roles))
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	traced := make([]services.TraceRole, 0, len(roles))
+	for _, role := range roles {
+		item := services.TraceRole{ID: role.ID, Name: role.Name}
+		if v := via[role.ID]; v != role.ID {
+			item.InheritedVia = names[v]
+		}
+		traced = append(traced, item)
+	}
+	return traced, nil
+}
+
+// traceBinding 记录一条资源模式匹配的授权
+func traceBinding(trace *services.ResourceTrace, perm services.PermissionInfo, applies bool) {
+	if trace == nil {
+		return
+	}
+	trace.Bindings = append(trace.Bindings, services.BindingTrace{PermissionInfo: perm, Applies: applies})
+}
+
+// appliedBindings 按顺序返回 trace 中 Applies 的授权，与传给 decide 的 matched 一一对应
+func appliedBindings(trace *services.ResourceTrace) []*services.BindingTrace {
+	if trace == nil {
+		return nil
+	}
+	var refs []*services.BindingTrace
+	for i := range trace.Bindings {
+		if trace.Bindings[i].Applies {
+			refs = append(refs, &trace.Bindings[i])
+		}
+	}
+	return refs
+}
+
+func traceCondition(refs []*services.BindingTrace, i int, holds bool) {
+	if refs != nil {
+		refs[i].ConditionHolds = &holds
+	}
+}
+
+// traceDecision 记录资源判定结果，i 为起决定作用的授权下标，-1 表示没有
+func traceDecision(trace *services.ResourceTrace, refs []*services.BindingTrace, i int, allowed bool, reason string) {
+	if trace == nil {
+		return
+	}
+	trace.Allowed = allowed
+	trace.Reason = reason
+	if i >= 0 && refs != nil {
+		refs[i].Decisive = true
+	}
+}
+
+func traceResult(trace *services.PermissionTrace, allowed bool, reason string) {
+	if trace == nil {
+		return
+	}
+	trace.Allowed = allowed
+	trace.Reason = reason
+}
+
+// describePermission 授权的简要说明：资源模式、读写、条件与来源角色
+func describePermission(perm services.PermissionInfo) string {
+	var access []string
+	if perm.IsRead {
+		access = append(access, "读")
+	}
+	if perm.IsWrite {
+		access = append(access, "写")
+	}
+	text := fmt.Sprintf("%s（%s", perm.ResourceName, strings.Join(access, "、"))
+	if perm.ConditionText != "" {
+		text += "，条件：" + perm.ConditionText
+	}
+	if len(perm.Sources) > 0 {
+		roles := make([]string, 0, len(perm.Sources))
+		for _, src := range perm.Sources {
+			if src.InheritedVia != "" {
+				roles = append(roles, src.InheritedVia+" 继承的 "+src.Role)
+			} else {
+				roles = append(roles, src.Role)
+			}
+		}
+		sort.Strings(roles)
+		text += "，来自角色 " + strings.Join(roles, "、")
+	}
+	return text + "）"
+}
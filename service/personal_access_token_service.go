@@ -0,0 +1,235 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+// tokenHintLength 令牌明文中用于辨认的开头字符数（含 pat_ 前缀）
+const tokenHintLength = 12
+
+var errInvalidPersonalAccessToken = errors.New("personal access token is invalid")
+
+type personalAccessTokenServiceImpl struct {
+	tokenRepo       repositories.PersonalAccessTokenRepository
+	userRepo        repositories.UserRepository
+	permSvc         services.PermissionService
+	auditLogService services.AuditLogService
+}
+
+func NewPersonalAccessTokenService(
+	tokenRepo repositories.PersonalAccessTokenRepository,
+	userRepo repositories.UserRepository,
+	permSvc services.PermissionService,
+	auditLogService services.AuditLogService,
+) services.PersonalAccessTokenService {
+	return &personalAccessTokenServiceImpl{
+		tokenRepo:       tokenRepo,
+		userRepo:        userRepo,
+		permSvc:         permSvc,
+		auditLogService: auditLogService,
+	}
+}
+
+func (s *personalAccessTokenServiceImpl) Create(userID uint64, req *dto.CreatePersonalAccessTokenRequest) (*entity.PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", errors.New("令牌名称不能为空")
+	}
+
+	if limit := config.CfgPersonalAccessToken.MaxPerUser; limit > 0 {
+		count, err := s.tokenRepo.CountActiveByUserID(userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if count >= int64(limit) {
+			return nil, "", fmt.Errorf("有效令牌数量已达上限 %d 个，请先撤销不再使用的令牌", limit)
+		}
+	}
+
+	expiresAt, err := personalAccessTokenExpiry(req.ExpiresInDays)
+	if err != nil {
+		return nil, "", err
+	}
+
+	scopes, err := s.validateScopes(userID, req.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newOneTimeToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := entity.PersonalAccessTokenPrefix + secret
+
+	token := &entity.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashOneTimeToken(plain),
+		TokenHint: plain[:tokenHintLength],
+		Scopes:    string(scopesJSON),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return nil, "", err
+	}
+
+	afterJSON, _ := json.Marshal(map[string]any{"name": token.Name, "scopes": scopes, "expires_at": token.ExpiresAt})
+	if err := s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   userID,
+		OperatorName: getOperatorName(global.G_DB, userID),
+		Action:       "create",
+		TargetType:   "personal_access_token",
+		TargetID:     token.ID,
+		AfterData:    string(afterJSON),
+		Description:  fmt.Sprintf("创建个人访问令牌: %s (%s...)", token.Name, token.TokenHint),
+	}); err != nil {
+		return nil, "", err
+	}
+	return token, plain, nil
+}
+
+func (s *personalAccessTokenServiceImpl) List(userID uint64) ([]entity.PersonalAccessToken, error) {
+	return s.tokenRepo.ListByUserID(userID)
+}
+
+func (s *personalAccessTokenServiceImpl) Revoke(userID, tokenID, operatorID uint64) error {
+	token, err := s.tokenRepo.GetByID(tokenID)
+	if err != nil || token.UserID != userID {
+		return errors.New("令牌不存在")
+	}
+	revoked, err := s.tokenRepo.Revoke(token.ID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return nil
+	}
+
+	return s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(global.G_DB, operatorID),
+		Action:       "revoke",
+		TargetType:   "personal_access_token",
+		TargetID:     token.ID,
+		Description:  fmt.Sprintf("撤销用户 %d 的个人访问令牌: %s (%s...)", token.UserID, token.Name, token.TokenHint),
+	})
+}
+
+func (s *personalAccessTokenServiceImpl) Authenticate(plain, ip string) (*entity.PersonalAccessToken, []services.PermissionInfo, error) {
+	if !strings.HasPrefix(plain, entity.PersonalAccessTokenPrefix) {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+	token, err := s.tokenRepo.GetByTokenHash(hashOneTimeToken(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errInvalidPersonalAccessToken
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return nil, nil, errors.New("personal access token has been revoked")
+	}
+	if !token.Active(now) {
+		return nil, nil, errors.New("personal access token is expired")
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+	if user.Status != entity.UserStatusActive {
+		return nil, nil, errors.New("user is not active")
+	}
+
+	var scopes []services.PermissionInfo
+	if err := json.Unmarshal([]byte(token.Scopes), &scopes); err != nil {
+		return nil, nil, errInvalidPersonalAccessToken
+	}
+
+	// 与会话一致，按最小间隔记录最近使用时间，避免每个请求都写数据库
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= sessionTouchInterval || token.LastUsedIP != ip {
+		s.tokenRepo.Touch(token.ID, now, ip)
+	}
+	return token, scopes, nil
+}
+
+// validateScopes 合并重复的资源名，并检查每项授权都在用户当前权限之内
+func (s *personalAccessTokenServiceImpl) validateScopes(userID uint64, requested []dto.TokenScope) ([]services.PermissionInfo, error) {
+	index := make(map[string]int)
+	scopes := make([]services.PermissionInfo, 0, len(requested))
+	for _, r := range requested {
+		name := strings.TrimSpace(r.ResourceName)
+		if name == "" {
+			return nil, errors.New("授权资源名不能为空")
+		}
+		if !r.IsRead && !r.IsWrite {
+			return nil, fmt.Errorf("授权资源 %s 至少需要读或写权限之一", name)
+		}
+		if r.IsRead {
+			ok, err := s.permSvc.CheckPermission(userID, name, "GET", nil)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("没有资源 %s 的读权限，不能授予令牌", name)
+			}
+		}
+		if r.IsWrite {
+			ok, err := s.permSvc.CheckPermission(userID, name, "POST", nil)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("没有资源 %s 的写权限，不能授予令牌", name)
+			}
+		}
+
+		if i, ok := index[name]; ok {
+			scopes[i].IsRead = scopes[i].IsRead || r.IsRead
+			scopes[i].IsWrite = scopes[i].IsWrite || r.IsWrite
+			continue
+		}
+		index[name] = len(scopes)
+		scopes = append(scopes, services.PermissionInfo{ResourceName: name, IsRead: r.IsRead, IsWrite: r.IsWrite})
+	}
+	return scopes, nil
+}
+
+// personalAccessTokenExpiry 根据请求的有效天数与配置计算过期时间，返回 nil 表示永不过期
+func personalAccessTokenExpiry(days *int) (*time.Time, error) {
+	cfg := config.CfgPersonalAccessToken
+	d := cfg.DefaultExpiryDays
+	if days != nil {
+		d = *days
+	}
+	if d <= 0 {
+		if cfg.MaxExpiryDays > 0 {
+			return nil, fmt.Errorf("令牌有效期不能超过 %d 天", cfg.MaxExpiryDays)
+		}
+		return nil, nil
+	}
+	if cfg.MaxExpiryDays > 0 && d > cfg.MaxExpiryDays {
+		return nil, fmt.Errorf("令牌有效期不能超过 %d 天", cfg.MaxExpiryDays)
+	}
+	expiresAt := time.Now().AddDate(0, 0, d)
+	return &expiresAt, nil
+}
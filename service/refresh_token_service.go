@@ -2,22 +2,54 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/domain"
 	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
 	"github.com/lyj404/gin-api-template/internal/tokenutil"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
+// refreshFamilyRevoked Redis 中标记家族已撤销的取值
+const refreshFamilyRevoked = "revoked"
+
+// rotateFamilyScript 原子地比较并替换家族当前令牌ID：1 成功，0 不匹配（重复使用或已撤销），-1 缓存未命中
+var rotateFamilyScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return -1
+end
+if v == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0
+`)
+
 type refreshTokenService struct {
-	userRepo       domain.UserRepo
-	contextTimeout time.Duration
+	userRepo        domain.UserRepo
+	familyRepo      repositories.RefreshTokenFamilyRepository
+	sessionRepo     repositories.SessionRepository
+	auditLogService services.AuditLogService
+	contextTimeout  time.Duration
 }
 
-func NewRefreshTokenService(userRepo domain.UserRepo, timeout time.Duration) domain.RefreshTokenService {
+func NewRefreshTokenService(userRepo domain.UserRepo, familyRepo repositories.RefreshTokenFamilyRepository, sessionRepo repositories.SessionRepository, auditLogService services.AuditLogService, timeout time.Duration) domain.RefreshTokenService {
 	return &refreshTokenService{
-		userRepo:       userRepo,
-		contextTimeout: timeout,
+		userRepo:        userRepo,
+		familyRepo:      familyRepo,
+		sessionRepo:     sessionRepo,
+		auditLogService: auditLogService,
+		contextTimeout:  timeout,
 	}
 }
 
@@ -27,14 +59,162 @@ func (rtu *refreshTokenService) GetUserByID(c context.Context, id string) (entit
 	return rtu.userRepo.GetByID(ctx, id)
 }
 
-func (rtu *refreshTokenService) CreateAccessToken(user *entity.User, secret string, expiry int) (accessToken string, err error) {
-	return tokenutil.CreateAccessToken(user, secret, expiry)
+func (rtu *refreshTokenService) CreateAccessToken(user *entity.User, sessionID string, secret string, expiry int) (accessToken string, err error) {
+	return tokenutil.CreateAccessToken(user, sessionID, secret, expiry)
 }
 
-func (rtu *refreshTokenService) CreateRefreshToken(user *entity.User, secret string, expiry int) (refreshToken string, err error) {
-	return tokenutil.CreateRefreshToken(user, secret, expiry)
+func (rtu *refreshTokenService) CreateRefreshToken(user *entity.User, sessionID string, secret string, expiry int) (refreshToken string, err error) {
+	// 令牌家族与登录会话一一对应，直接复用会话ID
+	familyID := sessionID
+	tokenID := uuid.NewString()
+	expiresAt := time.Now().Add(time.Duration(expiry) * time.Hour)
+
+	family := &entity.RefreshTokenFamily{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		CurrentJTI: tokenID,
+		ExpiresAt:  expiresAt,
+	}
+	if err := rtu.familyRepo.Create(family); err != nil {
+		return "", err
+	}
+	rtu.cacheFamily(familyID, tokenID, expiresAt)
+
+	return tokenutil.CreateRefreshToken(user, familyID, tokenID, secret, expiry)
+}
+
+func (rtu *refreshTokenService) RotateRefreshToken(c context.Context, requestToken string) (accessToken string, refreshToken string, err error) {
+	claims, err := tokenutil.ParseRefreshToken(requestToken, config.CfgToken.RefreshTokenSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, err := strconv.ParseUint(claims.ID, 16, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid user id in token")
+	}
+	user, err := rtu.GetUserByID(c, strconv.FormatUint(userID, 10))
+	if err != nil {
+		return "", "", err
+	}
+	if user.Status != entity.UserStatusActive {
+		return "", "", errors.New("account is not active")
+	}
+
+	newTokenID := uuid.NewString()
+	expiresAt := time.Now().Add(time.Duration(config.CfgToken.RefreshTokenExpiryHour) * time.Hour)
+
+	rotated, err := rtu.rotate(c, claims.FamilyID, claims.RegisteredClaims.ID, newTokenID, expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+	if !rotated {
+		return "", "", rtu.handleRotateFailure(&user, claims.FamilyID)
+	}
+	if err := rtu.sessionRepo.Extend(claims.FamilyID, time.Now(), expiresAt); err != nil {
+		return "", "", err
+	}
+
+	accessToken, err = tokenutil.CreateAccessToken(&user, claims.FamilyID, config.CfgToken.AccessTokenSecret, config.CfgToken.AccessTokenExpiryHour)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = tokenutil.CreateRefreshToken(&user, claims.FamilyID, newTokenID, config.CfgToken.RefreshTokenSecret, config.CfgToken.RefreshTokenExpiryHour)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
 }
 
 func (rtu *refreshTokenService) ExtractIDFromToken(requestToken string, secret string) (string, error) {
 	return tokenutil.ExtractIDFromToken(requestToken, secret)
 }
+
+// rotate 将家族当前令牌从 oldTokenID 替换为 newTokenID，启用 Redis 时优先在 Redis 中原子比较
+func (rtu *refreshTokenService) rotate(c context.Context, familyID, oldTokenID, newTokenID string, expiresAt time.Time) (bool, error) {
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		res, err := rotateFamilyScript.Run(c, global.G_REDIS, []string{refreshFamilyCacheKey(familyID)},
+			oldTokenID, newTokenID, time.Until(expiresAt).Milliseconds()).Int()
+		if err == nil {
+			switch res {
+			case 1:
+				// Redis 已完成原子替换，同步落库；以数据库为准，落库失败（家族已撤销或当前令牌不一致）时
+				// 删除缓存，由调用方按轮换失败处理，下次刷新回退到数据库判定
+				rotated, err := rtu.familyRepo.Rotate(familyID, oldTokenID, newTokenID, expiresAt)
+				if err != nil || !rotated {
+					global.G_REDIS.Del(c, refreshFamilyCacheKey(familyID))
+				}
+				return rotated, err
+			case 0:
+				return false, nil
+			}
+		}
+		// 缓存未命中或 Redis 异常时回退到数据库
+	}
+
+	rotated, err := rtu.familyRepo.Rotate(familyID, oldTokenID, newTokenID, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	if rotated {
+		rtu.cacheFamily(familyID, newTokenID, expiresAt)
+	}
+	return rotated, nil
+}
+
+// handleRotateFailure 区分令牌家族不存在、已撤销与重复使用，重复使用时撤销整个家族及其会话并记录审计日志
+func (rtu *refreshTokenService) handleRotateFailure(user *entity.User, familyID string) error {
+	family, err := rtu.familyRepo.GetByFamilyID(familyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("invalid token")
+		}
+		return err
+	}
+	if family.UserID != user.ID {
+		return fmt.Errorf("invalid token")
+	}
+	if family.Revoked {
+		return fmt.Errorf("token has been revoked")
+	}
+
+	if err := rtu.familyRepo.Revoke(global.G_DB, familyID); err != nil {
+		return err
+	}
+	if err := rtu.sessionRepo.Revoke(global.G_DB, familyID); err != nil {
+		return err
+	}
+	rtu.cacheFamily(familyID, refreshFamilyRevoked, family.ExpiresAt)
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		if ttl := time.Until(family.ExpiresAt); ttl > 0 {
+			global.G_REDIS.Set(context.Background(), sessionCacheKey(familyID), sessionRevoked, ttl)
+		}
+	}
+
+	rtu.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   user.ID,
+		OperatorName: user.Name,
+		Action:       "revoke",
+		TargetType:   "refresh_token_family",
+		TargetID:     family.ID,
+		Description:  "检测到刷新令牌重复使用，已撤销令牌家族: " + familyID,
+	})
+
+	return domain.ErrRefreshTokenReused
+}
+
+// cacheFamily 将家族当前令牌ID写入 Redis（未启用 Redis 时忽略）
+func (rtu *refreshTokenService) cacheFamily(familyID, value string, expiresAt time.Time) {
+	if !config.CfgRedis.Enabled || global.G_REDIS == nil {
+		return
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+	global.G_REDIS.Set(context.Background(), refreshFamilyCacheKey(familyID), value, ttl)
+}
+
+func refreshFamilyCacheKey(familyID string) string {
+	return "refresh_token_family:" + familyID
+}
@@ -22,7 +22,7 @@ func NewResourceService(resourceRepo repositories.ResourceRepository) services.R
 }
 
 func (s *resourceServiceImpl) CreateResource(resource *entity.Resource, operatorID uint64) error {
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(resource).Error; err != nil {
 			return err
 		}
@@ -31,10 +31,14 @@ func (s *resourceServiceImpl) CreateResource(resource *entity.Resource, operator
 		description := fmt.Sprintf("创建资源: %s", resource.Name)
 		return s.createAuditLog(tx, operatorID, "create", "resource", resource.ID, "", string(resourceJSON), description)
 	})
+	if err != nil {
+		return err
+	}
+	return clearRouteResourcesCache()
 }
 
 func (s *resourceServiceImpl) UpdateResource(resource *entity.Resource, operatorID uint64) error {
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		oldResource, err := s.resourceRepo.GetByID(resource.ID)
 		if err != nil {
 			return err
@@ -49,10 +53,14 @@ func (s *resourceServiceImpl) UpdateResource(resource *entity.Resource, operator
 		description := fmt.Sprintf("更新资源: %s", resource.Name)
 		return s.createAuditLog(tx, operatorID, "update", "resource", resource.ID, string(oldJSON), string(newJSON), description)
 	})
+	if err != nil {
+		return err
+	}
+	return clearRouteResourcesCache()
 }
 
 func (s *resourceServiceImpl) DeleteResource(id uint64, operatorID uint64) error {
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		resource, err := s.resourceRepo.GetByID(id)
 		if err != nil {
 			return err
@@ -66,6 +74,10 @@ func (s *resourceServiceImpl) DeleteResource(id uint64, operatorID uint64) error
 		description := fmt.Sprintf("删除资源: %s", resource.Name)
 		return s.createAuditLog(tx, operatorID, "delete", "resource", id, string(resourceJSON), "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return clearRouteResourcesCache()
 }
 
 func (s *resourceServiceImpl) GetResourceByID(id uint64) (*entity.Resource, error) {
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// defaultRoleExpirySweepInterval 未配置清理间隔时使用的默认值
+const defaultRoleExpirySweepInterval = time.Minute
+
+// roleExpirySweepBatch 单次清理处理的最大分配数，剩余的留到下一轮
+const roleExpirySweepBatch = 500
+
+// RoleExpirySweeper 定期删除到期的限时角色分配，记录审计并清除对应用户的权限缓存
+// 权限计算本身已忽略到期分配，清理只负责留下审计记录和回收数据，多实例同时运行时按删除结果去重
+type RoleExpirySweeper struct {
+	permSvc services.PermissionService
+	logger  *zap.Logger
+}
+
+func NewRoleExpirySweeper(permSvc services.PermissionService, logger *zap.Logger) *RoleExpirySweeper {
+	return &RoleExpirySweeper{permSvc: permSvc, logger: logger}
+}
+
+// Start 在后台按配置的间隔执行清理，随进程退出
+func (s *RoleExpirySweeper) Start() {
+	interval := time.Duration(config.CfgRoleAssignment.ExpirySweepIntervalSecond) * time.Second
+	if interval <= 0 {
+		interval = defaultRoleExpirySweepInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := s.Sweep(); err != nil {
+				s.logger.Error("清理到期角色分配失败", zap.Error(err))
+			} else if n > 0 {
+				s.logger.Info("已清理到期角色分配", zap.Int("count", n))
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Sweep 执行一次清理，返回本实例实际删除的分配数
+func (s *RoleExpirySweeper) Sweep() (int, error) {
+	var expired []entity.UserRole
+	err := global.G_DB.Preload("Role").
+		Where("valid_until IS NOT NULL AND valid_until <= ?", time.Now()).
+		Order("valid_until").
+		Limit(roleExpirySweepBatch).
+		Find(&expired).Error
+	if err != nil {
+		return 0, err
+	}
+
+	swept := 0
+	affected := make(map[uint64]bool)
+	for _, ur := range expired {
+		deleted := false
+		err := global.G_DB.Transaction(func(tx *gorm.DB) error {
+			res := tx.Where("id = ?", ur.ID).Delete(&entity.UserRole{})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			deleted = true
+			if err := tx.Create(&entity.AuditLog{
+				OperatorID:   0,
+				OperatorName: "系统",
+				Action:       "expire",
+				TargetType:   "user_role",
+				TargetID:     ur.ID,
+				Description: fmt.Sprintf("用户 %d 的角色 %s(%d) 已于 %s 到期 (组织: %d)",
+					ur.UserID, ur.Role.Name, ur.RoleID, ur.ValidUntil.Format("2006-01-02 15:04:05"), ur.OrgUnitID),
+			}).Error; err != nil {
+				return err
+			}
+			return expireAccessRequest(tx, &ur)
+		})
+		if err != nil {
+			return swept, err
+		}
+		if deleted {
+			swept++
+			affected[ur.UserID] = true
+		}
+	}
+
+	for userID := range affected {
+		if err := s.permSvc.ClearUserCache(userID); err != nil {
+			s.logger.Warn("清除权限缓存失败", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+	}
+	return swept, nil
+}
+
+// expireAccessRequest 分配来自访问申请时，将对应申请标记为已到期并记录审计
+func expireAccessRequest(tx *gorm.DB, ur *entity.UserRole) error {
+	var request entity.AccessRequest
+	err := tx.Where("user_role_id = ? AND status = ?", ur.ID, entity.AccessRequestApproved).First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&request).Update("status", entity.AccessRequestExpired).Error; err != nil {
+		return err
+	}
+	return tx.Create(&entity.AuditLog{
+		OperatorID:   0,
+		OperatorName: "系统",
+		Action:       "expire",
+		TargetType:   "access_request",
+		TargetID:     request.ID,
+		Description:  fmt.Sprintf("访问申请 %d 授予的角色 %s(%d) 已到期 (用户: %d，组织: %d)", request.ID, ur.Role.Name, ur.RoleID, ur.UserID, ur.OrgUnitID),
+	}).Error
+}
@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/repositories"
 	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/pkg/lib/condition"
 	"github.com/lyj404/gin-api-template/pkg/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type roleServiceImpl struct {
@@ -66,7 +70,9 @@ func (s *roleServiceImpl) DeleteRole(id uint64, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(id, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	// 删除前记录受影响的用户：角色的持有者及继承它的角色的持有者，提交后清除其权限缓存
+	var affected []uint64
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		role, err := s.roleRepo.GetByID(id)
 		if err != nil {
 			return err
@@ -76,6 +82,10 @@ func (s *roleServiceImpl) DeleteRole(id uint64, operatorID uint64) error {
 			return fmt.Errorf("系统角色不能删除")
 		}
 
+		if affected, err = roleUserIDs(tx, id); err != nil {
+			return err
+		}
+
 		// 清理关联记录
 		if err := tx.Where("role_id = ?", id).Delete(&entity.RoleResource{}).Error; err != nil {
 			return err
@@ -89,6 +99,9 @@ func (s *roleServiceImpl) DeleteRole(id uint64, operatorID uint64) error {
 		if err := tx.Where("role_id = ?", id).Delete(&entity.UserRole{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("role_id = ? OR parent_id = ?", id, id).Delete(&entity.RoleParent{}).Error; err != nil {
+			return err
+		}
 
 		if err := tx.Delete(&entity.Role{}, id).Error; err != nil {
 			return err
@@ -98,6 +111,10 @@ func (s *roleServiceImpl) DeleteRole(id uint64, operatorID uint64) error {
 		description := fmt.Sprintf("删除角色: %s", role.Name)
 		return s.createAuditLog(tx, operatorID, "delete", "role", id, string(roleJSON), "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return clearUsersCache(s.permSvc, affected)
 }
 
 func (s *roleServiceImpl) GetRoleByID(id uint64, userID uint64) (*entity.Role, error) {
@@ -119,7 +136,10 @@ func (s *roleServiceImpl) GetAllRoles(userID uint64) ([]entity.Role, error) {
 	if err != nil {
 		return nil, err
 	}
-	orgIDs := CollectOrgIDs(orgScope)
+	orgIDs, err := CollectOrgIDs(orgScope)
+	if err != nil {
+		return nil, err
+	}
 	if len(orgIDs) == 0 {
 		return nil, nil
 	}
@@ -140,16 +160,38 @@ func (s *roleServiceImpl) GetRoleResources(roleID uint64, userID uint64) ([]enti
 	return s.roleRepo.GetRoleResources(roleID)
 }
 
-func (s *roleServiceImpl) BindResource(roleID, resourceID uint64, isWrite bool, operatorID uint64) error {
+func (s *roleServiceImpl) BindResource(roleID, resourceID uint64, isWrite bool, effect, cond string, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	cond = strings.TrimSpace(cond)
+	if cond != "" {
+		if _, err := condition.Compile(cond); err != nil {
+			return fmt.Errorf("%w: %v", services.ErrInvalidCondition, err)
+		}
+	}
+	if effect == "" {
+		effect = entity.RoleResourceEffectAllow
+	}
+	if effect != entity.RoleResourceEffectAllow && effect != entity.RoleResourceEffectDeny {
+		return fmt.Errorf("无效的绑定效果 %s", effect)
+	}
+
+	// allow 默认授予读权限，isWrite 追加写权限；deny 时 isWrite 表示仅拒绝写操作，否则读写均拒绝
+	isRead := true
+	if effect == entity.RoleResourceEffectDeny {
+		isRead = !isWrite
+		isWrite = true
+	}
+
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		roleResource := entity.RoleResource{
 			RoleID:     roleID,
 			ResourceID: resourceID,
-			IsRead:     true,
+			IsRead:     isRead,
 			IsWrite:    isWrite,
+			Effect:     effect,
+			Condition:  cond,
 		}
 
 		if err := tx.Create(&roleResource).Error; err != nil {
@@ -157,15 +199,25 @@ func (s *roleServiceImpl) BindResource(roleID, resourceID uint64, isWrite bool,
 		}
 
 		description := fmt.Sprintf("角色 %d 绑定资源 %d (写权限: %v)", roleID, resourceID, isWrite)
+		if effect == entity.RoleResourceEffectDeny {
+			description = fmt.Sprintf("角色 %d 拒绝资源 %d (读: %v, 写: %v)", roleID, resourceID, isRead, isWrite)
+		}
+		if cond != "" {
+			description += fmt.Sprintf("，条件: %s", cond)
+		}
 		return s.createAuditLog(tx, operatorID, "bind", "role_resource", roleResource.ID, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.clearRoleUsersCache(roleID)
 }
 
 func (s *roleServiceImpl) UnbindResource(roleID, resourceID uint64, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		description := fmt.Sprintf("角色 %d 解绑资源 %d", roleID, resourceID)
 		if err := tx.Where("role_id = ? AND resource_id = ?", roleID, resourceID).Delete(&entity.RoleResource{}).Error; err != nil {
 			return err
@@ -173,6 +225,10 @@ func (s *roleServiceImpl) UnbindResource(roleID, resourceID uint64, operatorID u
 
 		return s.createAuditLog(tx, operatorID, "unbind", "role_resource", 0, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.clearRoleUsersCache(roleID)
 }
 
 func (s *roleServiceImpl) BindOrgScope(roleID, orgUnitID uint64, includeDescendants bool, operatorID uint64) error {
@@ -209,15 +265,26 @@ func (s *roleServiceImpl) UnbindOrgScope(roleID, orgUnitID uint64, operatorID ui
 	})
 }
 
-func (s *roleServiceImpl) AssignRoleToUser(userID, roleID, orgUnitID uint64, operatorID uint64) error {
+func (s *roleServiceImpl) AssignRoleToUser(userID, roleID, orgUnitID uint64, validFrom, validUntil *time.Time, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	if validUntil != nil {
+		if !validUntil.After(time.Now()) {
+			return errors.New("失效时间必须晚于当前时间")
+		}
+		if validFrom != nil && !validUntil.After(*validFrom) {
+			return errors.New("失效时间必须晚于生效时间")
+		}
+	}
+
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		userRole := entity.UserRole{
-			UserID:    userID,
-			RoleID:    roleID,
-			OrgUnitID: orgUnitID,
+			UserID:     userID,
+			RoleID:     roleID,
+			OrgUnitID:  orgUnitID,
+			ValidFrom:  validFrom,
+			ValidUntil: validUntil,
 		}
 
 		if err := tx.Create(&userRole).Error; err != nil {
@@ -225,15 +292,22 @@ func (s *roleServiceImpl) AssignRoleToUser(userID, roleID, orgUnitID uint64, ope
 		}
 
 		description := fmt.Sprintf("用户 %d 分配角色 %d (组织: %d)", userID, roleID, orgUnitID)
+		if userRole.IsTemporary() {
+			description += fmt.Sprintf("，有效期: %s ~ %s", formatValidity(validFrom, "立即"), formatValidity(validUntil, "永久"))
+		}
 		return s.createAuditLog(tx, operatorID, "assign", "user_role", userRole.ID, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.permSvc.ClearUserCache(userID)
 }
 
 func (s *roleServiceImpl) RevokeRoleFromUser(userID, roleID, orgUnitID uint64, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		description := fmt.Sprintf("用户 %d 撤销角色 %d (组织: %d)", userID, roleID, orgUnitID)
 		if err := tx.Where("user_id = ? AND role_id = ? AND org_unit_id = ?", userID, roleID, orgUnitID).Delete(&entity.UserRole{}).Error; err != nil {
 			return err
@@ -241,13 +315,53 @@ func (s *roleServiceImpl) RevokeRoleFromUser(userID, roleID, orgUnitID uint64, o
 
 		return s.createAuditLog(tx, operatorID, "revoke", "user_role", 0, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.permSvc.ClearUserCache(userID)
+}
+
+// ListUserRoles 列出用户的全部角色分配（含未生效的限时分配），非系统管理员只能看到组织范围内的分配
+func (s *roleServiceImpl) ListUserRoles(userID uint64, operatorID uint64) ([]entity.UserRole, error) {
+	query := global.G_DB.Preload("Role").Preload("OrgUnit").Where("user_id = ?", userID)
+
+	isSuper, err := s.permSvc.HasSystemRole(operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isSuper {
+		orgScope, err := s.permSvc.GetUserOrgScope(operatorID)
+		if err != nil {
+			return nil, err
+		}
+		orgIDs, err := CollectOrgIDs(orgScope)
+		if err != nil {
+			return nil, err
+		}
+		if len(orgIDs) == 0 {
+			return nil, nil
+		}
+		query = query.Where("org_unit_id IN ?", orgIDs)
+	}
+
+	var userRoles []entity.UserRole
+	err = query.Order("id").Find(&userRoles).Error
+	return userRoles, err
+}
+
+// formatValidity 审计描述中的有效期端点，为空时使用 fallback
+func formatValidity(t *time.Time, fallback string) string {
+	if t == nil {
+		return fallback
+	}
+	return t.Format("2006-01-02 15:04:05")
 }
 
 func (s *roleServiceImpl) BindMenu(roleID, menuID uint64, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		rm := entity.RoleMenu{RoleID: roleID, MenuID: menuID}
 		if err := tx.Create(&rm).Error; err != nil {
 			return err
@@ -255,19 +369,28 @@ func (s *roleServiceImpl) BindMenu(roleID, menuID uint64, operatorID uint64) err
 		description := fmt.Sprintf("角色 %d 绑定菜单 %d", roleID, menuID)
 		return s.createAuditLog(tx, operatorID, "bind", "role_menu", rm.ID, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	// 菜单关联的资源并入用户权限，与直接绑定资源一样需要清除缓存
+	return s.clearRoleUsersCache(roleID)
 }
 
 func (s *roleServiceImpl) UnbindMenu(roleID, menuID uint64, operatorID uint64) error {
 	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
 		return err
 	}
-	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
 		description := fmt.Sprintf("角色 %d 解绑菜单 %d", roleID, menuID)
 		if err := tx.Where("role_id = ? AND menu_id = ?", roleID, menuID).Delete(&entity.RoleMenu{}).Error; err != nil {
 			return err
 		}
 		return s.createAuditLog(tx, operatorID, "unbind", "role_menu", 0, "", "", description)
 	})
+	if err != nil {
+		return err
+	}
+	return s.clearRoleUsersCache(roleID)
 }
 
 func (s *roleServiceImpl) GetRoleMenus(roleID uint64, userID uint64) ([]entity.RoleMenu, error) {
@@ -277,6 +400,171 @@ func (s *roleServiceImpl) GetRoleMenus(roleID uint64, userID uint64) ([]entity.R
 	return s.roleRepo.GetRoleMenus(roleID)
 }
 
+func (s *roleServiceImpl) GetParents(roleID uint64, userID uint64) ([]entity.Role, error) {
+	if err := s.checkRoleOrgScope(roleID, userID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetParents(roleID)
+}
+
+func (s *roleServiceImpl) AddParent(roleID, parentID uint64, operatorID uint64) error {
+	if roleID == parentID {
+		return services.ErrRoleInheritanceCycle
+	}
+	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
+		return err
+	}
+	// 继承父角色等同于获得其全部权限，父角色也必须在操作者的管理范围内
+	if err := s.checkRoleOrgScope(parentID, operatorID); err != nil {
+		return err
+	}
+	parent, err := s.roleRepo.GetByID(parentID)
+	if err != nil {
+		return err
+	}
+	if parent.IsSystem {
+		return errors.New("系统角色不能作为父角色")
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		// 先锁定子角色，再在遍历时逐层锁定祖先角色，并发添加继承的事务只要可能连成环就会在共同的角色上串行化
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&entity.Role{}, roleID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&entity.RoleParent{}).Where("role_id = ? AND parent_id = ?", roleID, parentID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("已继承该角色")
+		}
+
+		ancestors, err := collectRoleAncestors(tx, parentID)
+		if err != nil {
+			return err
+		}
+		if _, ok := ancestors[roleID]; ok {
+			return services.ErrRoleInheritanceCycle
+		}
+
+		link := entity.RoleParent{RoleID: roleID, ParentID: parentID}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		description := fmt.Sprintf("角色 %d 继承角色 %s(%d)", roleID, parent.Name, parentID)
+		return s.createAuditLog(tx, operatorID, "bind", "role_parent", link.ID, "", "", description)
+	})
+	if err != nil {
+		return err
+	}
+	return s.clearRoleUsersCache(roleID)
+}
+
+func (s *roleServiceImpl) RemoveParent(roleID, parentID uint64, operatorID uint64) error {
+	if err := s.checkRoleOrgScope(roleID, operatorID); err != nil {
+		return err
+	}
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
+		description := fmt.Sprintf("角色 %d 取消继承角色 %d", roleID, parentID)
+		if err := tx.Where("role_id = ? AND parent_id = ?", roleID, parentID).Delete(&entity.RoleParent{}).Error; err != nil {
+			return err
+		}
+		return s.createAuditLog(tx, operatorID, "unbind", "role_parent", 0, "", "", description)
+	})
+	if err != nil {
+		return err
+	}
+	// 继承链上的子孙角色也经由 roleID 获得父角色的授权，一并清除
+	return s.clearRoleUsersCache(roleID)
+}
+
+// clearRoleUsersCache 角色的授权变化后清除持有该角色或继承它的角色的用户的权限缓存
+// 权限缓存在没有限时分配时不过期，不清除的话拒绝授权、条件变更等要等缓存被手动清理才生效
+func (s *roleServiceImpl) clearRoleUsersCache(roleID uint64) error {
+	userIDs, err := roleUserIDs(global.G_DB, roleID)
+	if err != nil {
+		return err
+	}
+	return clearUsersCache(s.permSvc, userIDs)
+}
+
+func clearUsersCache(permSvc services.PermissionService, userIDs []uint64) error {
+	for _, userID := range userIDs {
+		if err := permSvc.ClearUserCache(userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleUserIDs 持有这些角色或继承它们的角色的用户
+func roleUserIDs(tx *gorm.DB, roleIDs ...uint64) ([]uint64, error) {
+	descendants, err := collectRoleDescendants(tx, roleIDs...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(descendants))
+	for id := range descendants {
+		ids = append(ids, id)
+	}
+	var userIDs []uint64
+	if err := tx.Model(&entity.UserRole{}).Where("role_id IN ?", ids).Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// collectRoleDescendants 按层向下遍历继承关系，返回 starts 及所有直接或间接继承它们的角色
+func collectRoleDescendants(tx *gorm.DB, starts ...uint64) (map[uint64]struct{}, error) {
+	visited := make(map[uint64]struct{}, len(starts))
+	frontier := make([]uint64, 0, len(starts))
+	for _, id := range starts {
+		if _, ok := visited[id]; !ok {
+			visited[id] = struct{}{}
+			frontier = append(frontier, id)
+		}
+	}
+	for len(frontier) > 0 {
+		var childIDs []uint64
+		if err := tx.Model(&entity.RoleParent{}).Where("parent_id IN ?", frontier).Pluck("role_id", &childIDs).Error; err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, id := range childIDs {
+			if _, ok := visited[id]; !ok {
+				visited[id] = struct{}{}
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return visited, nil
+}
+
+// collectRoleAncestors 按层向上遍历继承关系，返回 start 及其所有祖先角色
+// 遍历时锁定经过的角色并以加锁读读取继承关系，等锁之后读到的是其他事务已提交的最新数据
+func collectRoleAncestors(tx *gorm.DB, start uint64) (map[uint64]struct{}, error) {
+	visited := map[uint64]struct{}{start: {}}
+	frontier := []uint64{start}
+	for len(frontier) > 0 {
+		var locked []uint64
+		if err := tx.Model(&entity.Role{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", frontier).Pluck("id", &locked).Error; err != nil {
+			return nil, err
+		}
+		var parentIDs []uint64
+		if err := tx.Model(&entity.RoleParent{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("role_id IN ?", frontier).Pluck("parent_id", &parentIDs).Error; err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, id := range parentIDs {
+			if _, ok := visited[id]; !ok {
+				visited[id] = struct{}{}
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return visited, nil
+}
+
 func (s *roleServiceImpl) ListRoles(req *dto.PaginationRequest, userID uint64) ([]entity.Role, int64, error) {
 	hasSystemRole, err := s.permSvc.HasSystemRole(userID)
 	if err != nil {
@@ -303,7 +591,10 @@ func (s *roleServiceImpl) ListRoles(req *dto.PaginationRequest, userID uint64) (
 		if err != nil {
 			return nil, 0, fmt.Errorf("获取组织范围失败: %w", err)
 		}
-		orgIDs := CollectOrgIDs(orgScope)
+		orgIDs, err := CollectOrgIDs(orgScope)
+		if err != nil {
+			return nil, 0, fmt.Errorf("获取组织范围失败: %w", err)
+		}
 		builder = builder.
 			Distinct().
 			Joins(`JOIN role_org_scope ON role_org_scope.role_id = "role".id AND role_org_scope.deleted_at IS NULL`).
@@ -337,7 +628,10 @@ func (s *roleServiceImpl) checkRoleOrgScope(roleID, operatorID uint64) error {
 	if err != nil {
 		return err
 	}
-	orgIDs := CollectOrgIDs(orgScope)
+	orgIDs, err := CollectOrgIDs(orgScope)
+	if err != nil {
+		return err
+	}
 	if len(orgIDs) == 0 {
 		return errors.New("无权操作该角色")
 	}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval 最近活跃时间的最小落库间隔，避免每个请求都写数据库
+const sessionTouchInterval = time.Minute
+
+// sessionRevoked Redis 中标记会话已撤销的取值
+const sessionRevoked = "revoked"
+
+// sessionLimitReject 超出并发会话上限时拒绝新登录，其余取值均下线最早登录的会话
+const sessionLimitReject = "reject"
+
+type sessionServiceImpl struct {
+	sessionRepo repositories.SessionRepository
+	familyRepo  repositories.RefreshTokenFamilyRepository
+}
+
+func NewSessionService(sessionRepo repositories.SessionRepository, familyRepo repositories.RefreshTokenFamilyRepository) services.SessionService {
+	return &sessionServiceImpl{
+		sessionRepo: sessionRepo,
+		familyRepo:  familyRepo,
+	}
+}
+
+func (s *sessionServiceImpl) Create(user *entity.User, ip, userAgent, device string) (*entity.UserSession, error) {
+	if device == "" {
+		device = parseDevice(userAgent)
+	}
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	now := time.Now()
+	session := &entity.UserSession{
+		UserID:      user.ID,
+		SessionID:   uuid.NewString(),
+		Device:      device,
+		IP:          ip,
+		UserAgent:   userAgent,
+		Fingerprint: deviceFingerprint(userAgent),
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(time.Duration(config.CfgToken.RefreshTokenExpiryHour) * time.Hour),
+	}
+	var evicted []entity.UserSession
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if evicted, err = s.enforceSessionLimit(tx, user.ID); err != nil {
+			return err
+		}
+		return s.sessionRepo.Create(tx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range evicted {
+		s.cacheRevoked(&evicted[i])
+	}
+	s.cacheSession(session.SessionID, strconv.FormatUint(user.ID, 10), session.ExpiresAt)
+	return session, nil
+}
+
+// enforceSessionLimit 检查并发会话上限，为新会话腾出一个名额，返回被下线的会话
+// 在锁定用户记录的事务内执行，同一用户的并发登录依次判断，不会同时突破上限
+// 下线与新会话的创建在同一事务内，登录失败时一并回滚；Redis 中的撤销标记由调用方在提交后写入
+func (s *sessionServiceImpl) enforceSessionLimit(tx *gorm.DB, userID uint64) ([]entity.UserSession, error) {
+	limit, err := s.sessionLimit(userID)
+	if err != nil || limit <= 0 {
+		return nil, err
+	}
+	active, err := s.sessionRepo.ListActiveForUpdate(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	excess := len(active) - limit + 1
+	if excess <= 0 {
+		return nil, nil
+	}
+	if config.CfgSessionLimit.Policy == sessionLimitReject {
+		return nil, services.ErrSessionLimitExceeded
+	}
+	evicted := active[:excess]
+	for i := range evicted {
+		if err := s.revokeRecords(tx, &evicted[i], userID); err != nil {
+			return nil, err
+		}
+	}
+	return evicted, nil
+}
+
+// sessionLimit 用户的并发会话上限：任一角色设置了上限时取各角色中最严格（最小）的值，否则使用全局配置
+func (s *sessionServiceImpl) sessionLimit(userID uint64) (int, error) {
+	roleLimit, err := s.sessionRepo.GetRoleSessionLimit(userID)
+	if err != nil {
+		return 0, err
+	}
+	if roleLimit > 0 {
+		return roleLimit, nil
+	}
+	return config.CfgSessionLimit.MaxConcurrent, nil
+}
+
+func (s *sessionServiceImpl) Validate(sessionID string, userID uint64) error {
+	if sessionID == "" {
+		return errors.New("session not found")
+	}
+
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		cached, err := global.G_REDIS.Get(context.Background(), sessionCacheKey(sessionID)).Result()
+		if err == nil {
+			if cached == sessionRevoked {
+				return errors.New("session has been revoked")
+			}
+			if cached != strconv.FormatUint(userID, 10) {
+				return errors.New("session not found")
+			}
+			// 借助 SETNX 控制活跃时间的落库频率
+			if ok, err := global.G_REDIS.SetNX(context.Background(), sessionSeenCacheKey(sessionID), 1, sessionTouchInterval).Result(); err == nil && ok {
+				s.sessionRepo.Touch(sessionID, time.Now())
+			}
+			return nil
+		}
+	}
+
+	session, err := s.sessionRepo.GetBySessionID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return errors.New("session not found")
+	}
+	if session.Revoked {
+		s.cacheSession(sessionID, sessionRevoked, session.ExpiresAt)
+		return errors.New("session has been revoked")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return errors.New("session is expired")
+	}
+
+	if time.Since(session.LastSeenAt) >= sessionTouchInterval {
+		s.sessionRepo.Touch(sessionID, time.Now())
+	}
+	s.cacheSession(sessionID, strconv.FormatUint(userID, 10), session.ExpiresAt)
+	return nil
+}
+
+func (s *sessionServiceImpl) ListSessions(userID uint64) ([]entity.UserSession, error) {
+	return s.sessionRepo.ListActiveByUserID(userID)
+}
+
+func (s *sessionServiceImpl) Revoke(userID uint64, sessionID string, operatorID uint64) error {
+	session, err := s.sessionRepo.GetBySessionID(sessionID)
+	if err != nil || session.UserID != userID {
+		return errors.New("会话不存在")
+	}
+	if session.Revoked {
+		return nil
+	}
+	return s.revoke(session, operatorID)
+}
+
+func (s *sessionServiceImpl) RevokeAll(userID uint64, exceptSessionID string, operatorID uint64) (int, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range sessions {
+		if sessions[i].SessionID == exceptSessionID {
+			continue
+		}
+		if err := s.revoke(&sessions[i], operatorID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// revoke 撤销会话及其刷新令牌家族，并记录审计日志
+func (s *sessionServiceImpl) revoke(session *entity.UserSession, operatorID uint64) error {
+	err := global.G_DB.Transaction(func(tx *gorm.DB) error {
+		return s.revokeRecords(tx, session, operatorID)
+	})
+	if err != nil {
+		return err
+	}
+	s.cacheRevoked(session)
+	return nil
+}
+
+// revokeRecords 在事务内将会话及其刷新令牌家族标记为撤销，并记录审计日志
+func (s *sessionServiceImpl) revokeRecords(tx *gorm.DB, session *entity.UserSession, operatorID uint64) error {
+	if err := s.sessionRepo.Revoke(tx, session.SessionID); err != nil {
+		return err
+	}
+	if err := s.familyRepo.Revoke(tx, session.SessionID); err != nil {
+		return err
+	}
+	return tx.Create(&entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(tx, operatorID),
+		Action:       "revoke",
+		TargetType:   "user_session",
+		TargetID:     session.ID,
+		Description:  fmt.Sprintf("撤销用户 %d 的会话: %s (%s, %s)", session.UserID, session.SessionID, session.Device, session.IP),
+	}).Error
+}
+
+// cacheRevoked 事务提交后在 Redis 中标记会话及其刷新令牌家族已撤销
+func (s *sessionServiceImpl) cacheRevoked(session *entity.UserSession) {
+	s.cacheSession(session.SessionID, sessionRevoked, session.ExpiresAt)
+	if config.CfgRedis.Enabled && global.G_REDIS != nil {
+		if ttl := time.Until(session.ExpiresAt); ttl > 0 {
+			global.G_REDIS.Set(context.Background(), refreshFamilyCacheKey(session.SessionID), refreshFamilyRevoked, ttl)
+		}
+	}
+}
+
+// cacheSession 将会话状态写入 Redis（未启用 Redis 时忽略）
+func (s *sessionServiceImpl) cacheSession(sessionID, value string, expiresAt time.Time) {
+	if !config.CfgRedis.Enabled || global.G_REDIS == nil {
+		return
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+	global.G_REDIS.Set(context.Background(), sessionCacheKey(sessionID), value, ttl)
+}
+
+func sessionCacheKey(sessionID string) string {
+	return "user_session:" + sessionID
+}
+
+func sessionSeenCacheKey(sessionID string) string {
+	return "user_session_seen:" + sessionID
+}
+
+// fingerprintVersionPattern User-Agent 中的版本号，计算设备指纹时去掉，浏览器升级不会被视为新设备
+var fingerprintVersionPattern = regexp.MustCompile(`[0-9][0-9._]*`)
+
+// deviceFingerprint 根据去掉版本号的 User-Agent 计算设备指纹，User-Agent 为空时返回空字符串
+func deviceFingerprint(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	return hashOneTimeToken(fingerprintVersionPattern.ReplaceAllString(userAgent, ""))
+}
+
+// parseDevice 根据 User-Agent 粗略推断设备名称，如 "Chrome on Windows"
+func parseDevice(userAgent string) string {
+	if userAgent == "" {
+		return "未知设备"
+	}
+
+	browser := "Unknown"
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(userAgent, "curl/"):
+		browser = "curl"
+	}
+
+	os := "Unknown"
+	switch {
+	case strings.Contains(userAgent, "Windows"):
+		os = "Windows"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		os = "iOS"
+	case strings.Contains(userAgent, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(userAgent, "Android"):
+		os = "Android"
+	case strings.Contains(userAgent, "Linux"):
+		os = "Linux"
+	}
+
+	return browser + " on " + os
+}
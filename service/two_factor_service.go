@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"image/png"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lyj404/gin-api-template/config"
+	"github.com/lyj404/gin-api-template/domain/dto"
+	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/domain/repositories"
+	"github.com/lyj404/gin-api-template/domain/services"
+	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/internal/tokenutil"
+	"github.com/lyj404/gin-api-template/util"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpPeriod TOTP 时间步长（秒）
+	totpPeriod = 30
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// maxTwoFactorFailures 挑战有效期内允许的最大连续验证失败次数
+	maxTwoFactorFailures = 5
+	// recoveryCodeAlphabet 恢复码字符集，去除了易混淆的 0/o/1/l/i
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type twoFactorServiceImpl struct {
+	twoFactorRepo   repositories.TwoFactorRepository
+	userRepo        repositories.UserRepository
+	auditLogService services.AuditLogService
+}
+
+func NewTwoFactorService(twoFactorRepo repositories.TwoFactorRepository, userRepo repositories.UserRepository, auditLogService services.AuditLogService) services.TwoFactorService {
+	return &twoFactorServiceImpl{
+		twoFactorRepo:   twoFactorRepo,
+		userRepo:        userRepo,
+		auditLogService: auditLogService,
+	}
+}
+
+func (s *twoFactorServiceImpl) Status(userID uint64) (*dto.TwoFactorStatusResponse, error) {
+	resp := &dto.TwoFactorStatusResponse{}
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resp, nil
+		}
+		return nil, err
+	}
+	if !tf.Enabled {
+		return resp, nil
+	}
+
+	codes, err := s.twoFactorRepo.ListUnusedRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Enabled = true
+	resp.RecoveryCodesRemaining = len(codes)
+	if tf.EnabledAt != nil {
+		resp.EnabledAt = tf.EnabledAt.Format("2006-01-02 15:04:05")
+	}
+	return resp, nil
+}
+
+func (s *twoFactorServiceImpl) Setup(userID uint64) (*dto.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, errors.New("双因素认证已启用")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      config.CfgTwoFactor.Issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复初始化时覆盖尚未确认的密钥
+	if tf == nil {
+		tf = &entity.UserTwoFactor{UserID: userID}
+	}
+	tf.Secret = key.Secret()
+	tf.LastUsedStep = 0
+	tf.FailedAttempts = 0
+	if err := s.twoFactorRepo.Save(tf); err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret:     key.Secret(),
+		OtpauthURI: key.URL(),
+	}, nil
+}
+
+func (s *twoFactorServiceImpl) QRCode(userID uint64) ([]byte, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil || tf.Enabled {
+		return nil, errors.New("请先初始化双因素认证")
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	key, err := buildTOTPKey(tf.Secret, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *twoFactorServiceImpl) Confirm(userID uint64, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("请先初始化双因素认证")
+	}
+	if tf.Enabled {
+		return nil, errors.New("双因素认证已启用")
+	}
+
+	ok, err := s.verifyTOTP(tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("验证码错误")
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Enable(userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "enable_2fa", userID, "启用双因素认证")
+	return codes, nil
+}
+
+func (s *twoFactorServiceImpl) Disable(userID uint64, password string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if util.ComparePassword(user.PassWord, password) != nil {
+		return errors.New("密码错误")
+	}
+	if enabled, err := s.IsEnabled(userID); err != nil {
+		return err
+	} else if !enabled {
+		return errors.New("双因素认证未启用")
+	}
+
+	if err := s.twoFactorRepo.Delete(userID); err != nil {
+		return err
+	}
+	s.audit(userID, "disable_2fa", userID, "关闭双因素认证")
+	return nil
+}
+
+func (s *twoFactorServiceImpl) RegenerateRecoveryCodes(userID uint64, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil || !tf.Enabled {
+		return nil, errors.New("双因素认证未启用")
+	}
+
+	ok, err := s.verifyTOTP(tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("验证码错误")
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(userID, "regenerate_recovery_codes", userID, "重新生成双因素认证恢复码")
+	return codes, nil
+}
+
+func (s *twoFactorServiceImpl) IsEnabled(userID uint64) (bool, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+func (s *twoFactorServiceImpl) CreateChallenge(user *entity.User) (string, error) {
+	return tokenutil.CreateChallengeToken(user, config.CfgToken.RefreshTokenSecret, config.CfgTwoFactor.ChallengeExpiryMinute)
+}
+
+func (s *twoFactorServiceImpl) VerifyChallenge(challengeToken, code string) (*entity.User, error) {
+	hexID, err := tokenutil.ParseChallengeToken(challengeToken, config.CfgToken.RefreshTokenSecret)
+	if err != nil {
+		return nil, errors.New("挑战令牌无效或已过期，请重新登录")
+	}
+	userID, err := strconv.ParseUint(hexID, 16, 64)
+	if err != nil {
+		return nil, errors.New("挑战令牌无效或已过期，请重新登录")
+	}
+
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil || !tf.Enabled {
+		return nil, errors.New("双因素认证未启用")
+	}
+
+	// 挑战有效期内连续失败次数过多时拒绝继续尝试，超过有效期后重新计数
+	if tf.FailedAttempts >= maxTwoFactorFailures {
+		window := time.Duration(config.CfgTwoFactor.ChallengeExpiryMinute) * time.Minute
+		if tf.LastFailedAt != nil && time.Since(*tf.LastFailedAt) < window {
+			return nil, errors.New("验证失败次数过多，请稍后再试")
+		}
+		if err := s.twoFactorRepo.ResetFailures(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	var ok bool
+	if isTOTPCode(code) {
+		ok, err = s.verifyTOTP(tf, code)
+	} else {
+		ok, err = s.useRecoveryCode(userID, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.twoFactorRepo.RecordFailure(userID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("验证码错误")
+	}
+	if err := s.twoFactorRepo.ResetFailures(userID); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(userID)
+}
+
+func (s *twoFactorServiceImpl) Reset(userID uint64, operatorID uint64) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := s.twoFactorRepo.Delete(userID); err != nil {
+		return err
+	}
+	s.audit(operatorID, "reset_2fa", userID, "重置用户双因素认证: "+user.Email)
+	return nil
+}
+
+// verifyTOTP 在前后各一个时间步的容差内校验验证码，同一时间步的验证码只能使用一次
+func (s *twoFactorServiceImpl) verifyTOTP(tf *entity.UserTwoFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	current := time.Now().Unix() / totpPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		expected, err := totp.GenerateCodeCustom(tf.Secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return s.twoFactorRepo.UseStep(tf.UserID, step)
+		}
+	}
+	return false, nil
+}
+
+// useRecoveryCode 校验并消耗一个恢复码
+func (s *twoFactorServiceImpl) useRecoveryCode(userID uint64, code string) (bool, error) {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	codes, err := s.twoFactorRepo.ListUnusedRecoveryCodes(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, rc := range codes {
+		if util.ComparePassword(rc.CodeHash, normalized) == nil {
+			used, err := s.twoFactorRepo.UseRecoveryCode(rc.ID)
+			if err != nil || !used {
+				return false, err
+			}
+			s.audit(userID, "use_recovery_code", userID, "使用双因素认证恢复码登录")
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes 生成新的恢复码，哈希后入库并返回明文
+func (s *twoFactorServiceImpl) replaceRecoveryCodes(userID uint64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashed, err := util.HashPassword(raw)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashed
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorServiceImpl) audit(operatorID uint64, action string, targetID uint64, description string) {
+	s.auditLogService.Create(&entity.AuditLog{
+		OperatorID:   operatorID,
+		OperatorName: getOperatorName(global.G_DB, operatorID),
+		Action:       action,
+		TargetType:   "user",
+		TargetID:     targetID,
+		Description:  description,
+	})
+}
+
+// buildTOTPKey 根据已保存的 Base32 密钥重建 otpauth 密钥
+func buildTOTPKey(secret, accountName string) (*otp.Key, error) {
+	raw, err := totpSecretEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, err
+	}
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      config.CfgTwoFactor.Issuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Secret:      raw,
+	})
+}
+
+func randomRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = recoveryCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// normalizeRecoveryCode 去除恢复码中的分隔符与空白并转为小写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// isTOTPCode 判断输入是否为 6 位数字验证码
+func isTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -4,39 +4,41 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/lyj404/gin-api-template/config"
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/entity"
 	"github.com/lyj404/gin-api-template/domain/repositories"
 	"github.com/lyj404/gin-api-template/domain/services"
 	"github.com/lyj404/gin-api-template/global"
+	"github.com/lyj404/gin-api-template/internal/tokenutil"
 	"github.com/lyj404/gin-api-template/util"
 	"strconv"
 	"gorm.io/gorm"
 )
 
 type userManagementServiceImpl struct {
-	userRepo repositories.UserRepository
-	permSvc  services.PermissionService
+	userRepo      repositories.UserRepository
+	permSvc       services.PermissionService
+	sessionSvc    services.SessionService
+	twoFactorSvc  services.TwoFactorService
+	loginGuardSvc services.LoginGuardService
+	verifySvc     services.EmailVerificationService
+	tokenSvc      services.PersonalAccessTokenService
+	routes        services.RouteResolver
 }
 
-func NewUserManagementService(userRepo repositories.UserRepository, permSvc services.PermissionService) services.UserManagementService {
-	return &userManagementServiceImpl{userRepo: userRepo, permSvc: permSvc}
+func NewUserManagementService(userRepo repositories.UserRepository, permSvc services.PermissionService, sessionSvc services.SessionService, twoFactorSvc services.TwoFactorService, loginGuardSvc services.LoginGuardService, verifySvc services.EmailVerificationService, tokenSvc services.PersonalAccessTokenService, routes services.RouteResolver) services.UserManagementService {
+	return &userManagementServiceImpl{userRepo: userRepo, permSvc: permSvc, sessionSvc: sessionSvc, twoFactorSvc: twoFactorSvc, loginGuardSvc: loginGuardSvc, verifySvc: verifySvc, tokenSvc: tokenSvc, routes: routes}
 }
 
 func (s *userManagementServiceImpl) List(page, pageSize int, keyword string, userID uint64) ([]entity.User, map[uint64][]uint64, map[uint64][]string, int64, error) {
-	isSuper, err := s.userRepo.HasSystemRole(userID)
+	scope, err := s.permSvc.GetDataScopeWithFallback(userID)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
-	var orgIDs []uint64
-	if !isSuper {
-		orgIDs, err = s.getOrgIDs(userID)
-		if err != nil {
-			return nil, nil, nil, 0, err
-		}
-	}
-	users, total, err := s.userRepo.List(page, pageSize, keyword, orgIDs)
+	users, total, err := s.userRepo.List(page, pageSize, keyword, scope)
 	if err != nil {
 		return nil, nil, nil, 0, err
 	}
@@ -90,15 +92,21 @@ func (s *userManagementServiceImpl) Create(req *dto.CreateUserRequest, operatorI
 		return nil, err
 	}
 
+	if err := util.ValidatePassword(req.Password, req.Email); err != nil {
+		return nil, err
+	}
+
 	hashed, err := util.HashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("密码加密失败: %w", err)
 	}
 
+	now := time.Now()
 	user := &entity.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		PassWord: hashed,
+		Name:              req.Name,
+		Email:             req.Email,
+		PassWord:          hashed,
+		PasswordChangedAt: &now,
 	}
 
 	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
@@ -112,6 +120,9 @@ func (s *userManagementServiceImpl) Create(req *dto.CreateUserRequest, operatorI
 		if err := s.userRepo.Create(tx, user); err != nil {
 			return err
 		}
+		if err := savePasswordHistory(tx, user.ID, hashed); err != nil {
+			return err
+		}
 
 		orgUnitID := req.OrgUnitID
 		if orgUnitID == 0 {
@@ -164,6 +175,11 @@ func (s *userManagementServiceImpl) Update(id uint64, req *dto.UpdateUserRequest
 	if req.Email != "" {
 		updated.Email = req.Email
 	}
+	if req.Password != "" {
+		if err := util.ValidatePassword(req.Password, updated.Email); err != nil {
+			return nil, err
+		}
+	}
 
 	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
 		if err := s.userRepo.Update(tx, &updated); err != nil {
@@ -171,6 +187,9 @@ func (s *userManagementServiceImpl) Update(id uint64, req *dto.UpdateUserRequest
 		}
 
 		if req.Password != "" {
+			if err := checkPasswordReuse(tx, old, req.Password); err != nil {
+				return err
+			}
 			hashed, err := util.HashPassword(req.Password)
 			if err != nil {
 				return err
@@ -178,6 +197,9 @@ func (s *userManagementServiceImpl) Update(id uint64, req *dto.UpdateUserRequest
 			if err := s.userRepo.UpdatePassword(tx, id, hashed); err != nil {
 				return err
 			}
+			if err := savePasswordHistory(tx, id, hashed); err != nil {
+				return err
+			}
 		}
 
 		if req.RoleIDs != nil {
@@ -242,38 +264,139 @@ func (s *userManagementServiceImpl) Delete(id uint64, operatorID uint64) error {
 	})
 }
 
-func (s *userManagementServiceImpl) getOrgIDs(userID uint64) ([]uint64, error) {
-	scope, err := s.permSvc.GetUserOrgScope(userID)
+func (s *userManagementServiceImpl) ListSessions(id uint64, operatorID uint64) ([]entity.UserSession, error) {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return nil, err
+	}
+	return s.sessionSvc.ListSessions(id)
+}
+
+// RevokeSessions 撤销用户会话，sessionID 为空时撤销该用户的全部会话
+func (s *userManagementServiceImpl) RevokeSessions(id uint64, sessionID string, operatorID uint64) (int, error) {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return 0, err
+	}
+	if sessionID == "" {
+		return s.sessionSvc.RevokeAll(id, "", operatorID)
+	}
+	if err := s.sessionSvc.Revoke(id, sessionID, operatorID); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func (s *userManagementServiceImpl) ListTokens(id uint64, operatorID uint64) ([]entity.PersonalAccessToken, error) {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return nil, err
+	}
+	return s.tokenSvc.List(id)
+}
+
+// RevokeToken 撤销用户的个人访问令牌，用于离职交接或令牌疑似泄露
+func (s *userManagementServiceImpl) RevokeToken(id, tokenID uint64, operatorID uint64) error {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return err
+	}
+	return s.tokenSvc.Revoke(id, tokenID, operatorID)
+}
+
+// defaultImpersonationExpiryMinute 未配置时模拟登录令牌的有效期（分钟）
+const defaultImpersonationExpiryMinute = 15
+
+// Impersonate 以目标用户身份签发模拟登录令牌，用于客服排查用户看到的界面与数据
+// 非系统管理员不能模拟系统管理员，避免借模拟提升权限
+func (s *userManagementServiceImpl) Impersonate(id uint64, operatorID uint64, sessionID string) (string, time.Time, error) {
+	if id == operatorID {
+		return "", time.Time{}, errors.New("不能模拟自己")
+	}
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return "", time.Time{}, errors.New("用户不存在")
+	}
+	if user.Status != entity.UserStatusActive {
+		return "", time.Time{}, errors.New("只能模拟状态正常的用户")
+	}
+
+	targetIsSuper, err := s.userRepo.HasSystemRole(id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if targetIsSuper {
+		operatorIsSuper, err := s.userRepo.HasSystemRole(operatorID)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if !operatorIsSuper {
+			return "", time.Time{}, errors.New("无权模拟系统管理员")
+		}
+	}
+
+	expire := config.CfgToken.ImpersonationExpiryMinute
+	if expire <= 0 {
+		expire = defaultImpersonationExpiryMinute
+	}
+	token, expiresAt, err := tokenutil.CreateImpersonationToken(user, operatorID, sessionID, config.CfgToken.AccessTokenSecret, expire)
 	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	afterJSON, _ := json.Marshal(map[string]any{"session_id": sessionID, "expires_at": expiresAt})
+	if err := s.audit(global.G_DB, operatorID, "impersonate", id, "", string(afterJSON), fmt.Sprintf("模拟登录用户: %s", user.Email)); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ResetTwoFactor 强制重置用户的双因素认证，用户丢失身份验证器与恢复码时使用
+func (s *userManagementServiceImpl) ResetTwoFactor(id uint64, operatorID uint64) error {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return err
+	}
+	return s.twoFactorSvc.Reset(id, operatorID)
+}
+
+// Unlock 解除因连续登录失败导致的账号锁定
+func (s *userManagementServiceImpl) Unlock(id uint64, operatorID uint64) error {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return err
+	}
+	return s.loginGuardSvc.Unlock(id, operatorID)
+}
+
+func (s *userManagementServiceImpl) ExplainPermission(req *services.ExplainRequest, operatorID uint64) (*services.PermissionTrace, error) {
+	if err := s.checkUserOrgScope(req.UserID, operatorID); err != nil {
 		return nil, err
 	}
-	orgIDs := CollectOrgIDs(scope)
-	if len(orgIDs) == 0 {
-		var userRole entity.UserRole
-		if err := global.G_DB.Where("user_id = ?", userID).First(&userRole).Error; err == nil {
-			orgIDs = []uint64{userRole.OrgUnitID}
+	if req.Path != "" {
+		route, ok := s.routes.ResolveRoute(req.Method, req.Path)
+		if !ok {
+			return nil, errors.New("没有与该路径和方法对应的路由")
 		}
+		req.Route = route
 	}
-	return orgIDs, nil
+	return s.permSvc.ExplainPermission(req)
 }
 
 // checkUserOrgScope 检查目标用户是否在操作者的组织范围内
 func (s *userManagementServiceImpl) checkUserOrgScope(targetUserID, operatorID uint64) error {
-	isSuper, err := s.userRepo.HasSystemRole(operatorID)
+	scope, err := s.permSvc.GetDataScopeWithFallback(operatorID)
 	if err != nil {
 		return err
 	}
-	if isSuper {
+	if scope.All {
 		return nil
 	}
-	operatorOrgIDs, err := s.getOrgIDs(operatorID)
-	if err != nil {
+	var count int64
+	if err := global.G_DB.Model(&entity.UserRole{}).
+		Scopes(entity.InDataScope(scope)).
+		Where("user_id = ?", targetUserID).
+		Count(&count).Error; err != nil {
 		return err
 	}
-	var count int64
-	global.G_DB.Model(&entity.UserRole{}).
-		Where("user_id = ? AND org_unit_id IN ?", targetUserID, operatorOrgIDs).
-		Count(&count)
 	if count == 0 {
 		return errors.New("无权操作该组织范围外的用户")
 	}
@@ -285,23 +408,74 @@ func (s *userManagementServiceImpl) checkOrgUnitInScope(orgUnitID, operatorID ui
 	if orgUnitID == 0 {
 		return nil
 	}
-	isSuper, err := s.userRepo.HasSystemRole(operatorID)
+	scope, err := s.permSvc.GetDataScopeWithFallback(operatorID)
 	if err != nil {
 		return err
 	}
-	if isSuper {
-		return nil
+	if !scope.Contains(orgUnitID) {
+		return errors.New("无权操作该组织范围")
+	}
+	return nil
+}
+
+func (s *userManagementServiceImpl) ResendVerification(id uint64, operatorID uint64) error {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return err
+	}
+	return s.verifySvc.Resend(id, operatorID)
+}
+
+func (s *userManagementServiceImpl) Activate(id uint64, operatorID uint64) error {
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
+		return err
 	}
-	operatorOrgIDs, err := s.getOrgIDs(operatorID)
+	user, err := s.userRepo.GetByID(id)
 	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Status == entity.UserStatusActive {
+		return errors.New("用户已是正常状态")
+	}
+
+	return global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.userRepo.UpdateStatus(tx, id, entity.UserStatusActive); err != nil {
+			return err
+		}
+		beforeJSON, _ := json.Marshal(map[string]any{"status": user.Status})
+		afterJSON, _ := json.Marshal(map[string]any{"status": entity.UserStatusActive})
+		return s.audit(tx, operatorID, "activate", id, string(beforeJSON), string(afterJSON), fmt.Sprintf("激活用户: %s", user.Email))
+	})
+}
+
+func (s *userManagementServiceImpl) Disable(id uint64, operatorID uint64) error {
+	if id == operatorID {
+		return errors.New("不能禁用自己")
+	}
+	if err := s.checkUserOrgScope(id, operatorID); err != nil {
 		return err
 	}
-	for _, id := range operatorOrgIDs {
-		if id == orgUnitID {
-			return nil
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Status == entity.UserStatusDisabled {
+		return errors.New("用户已被禁用")
+	}
+
+	err = global.G_DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.userRepo.UpdateStatus(tx, id, entity.UserStatusDisabled); err != nil {
+			return err
 		}
+		beforeJSON, _ := json.Marshal(map[string]any{"status": user.Status})
+		afterJSON, _ := json.Marshal(map[string]any{"status": entity.UserStatusDisabled})
+		return s.audit(tx, operatorID, "disable", id, string(beforeJSON), string(afterJSON), fmt.Sprintf("禁用用户: %s", user.Email))
+	})
+	if err != nil {
+		return err
 	}
-	return errors.New("无权操作该组织范围")
+
+	_, err = s.sessionSvc.RevokeAll(id, "", operatorID)
+	return err
 }
 
 func (s *userManagementServiceImpl) audit(tx *gorm.DB, operatorID uint64, action string, targetID uint64, before, after, description string) error {
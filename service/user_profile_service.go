@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/lyj404/gin-api-template/domain/dto"
 	"github.com/lyj404/gin-api-template/domain/entity"
@@ -69,13 +70,26 @@ func (s *userProfileServiceImpl) ChangePassword(userID uint64, req *dto.ChangePa
 		return errors.New("原密码错误")
 	}
 
+	if err := util.ValidatePassword(req.NewPassword, user.Email); err != nil {
+		return err
+	}
+
 	hashed, err := util.HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
 	return global.G_DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&entity.User{}).Where("id = ?", userID).Update("password", hashed).Error; err != nil {
+		if err := checkPasswordReuse(tx, &user, req.NewPassword); err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"password":            hashed,
+			"password_changed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		if err := savePasswordHistory(tx, userID, hashed); err != nil {
 			return err
 		}
 		return tx.Create(&entity.AuditLog{
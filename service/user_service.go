@@ -6,6 +6,7 @@ import (
 
 	"github.com/lyj404/gin-api-template/domain"
 	"github.com/lyj404/gin-api-template/domain/entity"
+	"github.com/lyj404/gin-api-template/global"
 )
 
 type userService struct {
@@ -29,5 +30,8 @@ func (u *userService) GetUserByEmail(c context.Context, email string) (entity.Us
 func (u *userService) Create(c context.Context, user *entity.User) error {
 	ctx, cancel := context.WithTimeout(c, u.contextTimeOut)
 	defer cancel()
-	return u.repo.Create(ctx, user)
+	if err := u.repo.Create(ctx, user); err != nil {
+		return err
+	}
+	return savePasswordHistory(global.G_DB.WithContext(ctx), user.ID, user.PassWord)
 }
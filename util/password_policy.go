@@ -0,0 +1,131 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lyj404/gin-api-template/config"
+)
+
+// PasswordViolation 单条密码策略违规
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError 密码不满足策略时返回，包含全部违规的规则
+type PasswordPolicyError struct {
+	Violations []PasswordViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "；")
+}
+
+// bannedPasswords 禁用密码集合（小写）
+var bannedPasswords = map[string]struct{}{}
+
+// LoadBannedPasswords 加载禁用密码列表文件，未配置时跳过
+func LoadBannedPasswords() error {
+	path := config.CfgPassword.BannedListFile
+	if path == "" {
+		return nil
+	}
+
+	// 与配置文件相同，找不到时向上一级查找
+	if _, err := os.Stat(path); os.IsNotExist(err) && !filepath.IsAbs(path) {
+		path = filepath.Join("..", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("禁用密码列表读取失败: %w", err)
+	}
+	defer file.Close()
+
+	banned := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		banned[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("禁用密码列表读取失败: %w", err)
+	}
+	bannedPasswords = banned
+	return nil
+}
+
+// ValidatePassword 按密码策略校验密码，不满足时返回 *PasswordPolicyError
+func ValidatePassword(password, email string) error {
+	cfg := config.CfgPassword
+	var violations []PasswordViolation
+
+	if cfg.MinLength > 0 && len([]rune(password)) < cfg.MinLength {
+		violations = append(violations, PasswordViolation{"min_length", fmt.Sprintf("密码长度不能少于 %d 位", cfg.MinLength)})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if cfg.RequireUpper && !hasUpper {
+		violations = append(violations, PasswordViolation{"require_upper", "密码必须包含大写字母"})
+	}
+	if cfg.RequireLower && !hasLower {
+		violations = append(violations, PasswordViolation{"require_lower", "密码必须包含小写字母"})
+	}
+	if cfg.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{"require_digit", "密码必须包含数字"})
+	}
+	if cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{"require_symbol", "密码必须包含特殊字符"})
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := bannedPasswords[lower]; ok {
+		violations = append(violations, PasswordViolation{"banned", "密码过于常见，请更换"})
+	}
+
+	if cfg.DisallowEmail && email != "" {
+		email = strings.ToLower(strings.TrimSpace(email))
+		localPart, _, _ := strings.Cut(email, "@")
+		if strings.Contains(lower, email) || (len(localPart) >= 3 && strings.Contains(lower, localPart)) {
+			violations = append(violations, PasswordViolation{"contains_email", "密码不能包含邮箱或邮箱用户名"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// IsPasswordExpired 判断密码是否超过最长有效期
+func IsPasswordExpired(changedAt time.Time) bool {
+	maxAge := config.CfgPassword.MaxAgeDays
+	if maxAge <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return time.Since(changedAt) > time.Duration(maxAge)*24*time.Hour
+}
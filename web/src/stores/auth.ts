@@ -9,6 +9,7 @@ export const useAuthStore = defineStore('auth', () => {
   const token = ref<string>(localStorage.getItem('accessToken') || '')
   const refreshToken = ref<string>(localStorage.getItem('refreshToken') || '')
   const captchaUrl = ref<string>('')
+  const captchaId = ref<string>('')
 
   const setToken = (access: string, refresh: string) => {
     token.value = access
@@ -26,7 +27,7 @@ export const useAuthStore = defineStore('auth', () => {
   }
 
   const login = async (data: LoginRequest): Promise<LoginResponse> => {
-    const res = await loginApi(data)
+    const res = await loginApi({ ...data, captcha_id: captchaId.value })
     const result = res.data as any
     if (result?.data) {
       setToken(result.data.accessToken, result.data.refreshToken)
@@ -35,7 +36,8 @@ export const useAuthStore = defineStore('auth', () => {
   }
 
   const refreshCaptcha = async (): Promise<string> => {
-    const res = await fetch('/api/captcha', { credentials: 'include' })
+    const res = await fetch('/api/captcha')
+    captchaId.value = res.headers.get('X-Captcha-Id') || ''
     const url = URL.createObjectURL(await res.blob())
     captchaUrl.value = url
     return url
@@ -29,6 +29,7 @@ export interface LoginRequest {
   email: string
   password: string
   captcha: string
+  captcha_id?: string
 }
 
 export interface LoginResponse {
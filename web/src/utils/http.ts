@@ -20,6 +20,28 @@ api.interceptors.request.use((config: InternalAxiosRequestConfig) => {
   return config
 })
 
+// 刷新令牌每次使用后即失效，并发的 401 必须共用同一次刷新请求，否则会被判定为重复使用
+let refreshing: Promise<string> | null = null
+
+const refreshAccessToken = (refreshToken: string): Promise<string> => {
+  if (!refreshing) {
+    refreshing = axios
+      .post<ResponseResult>(`${baseURL}/refresh-token`, { refreshToken })
+      .then(({ data }) => {
+        if (!data.data) {
+          throw new Error('refresh failed')
+        }
+        localStorage.setItem('accessToken', data.data.accessToken)
+        localStorage.setItem('refreshToken', data.data.refreshToken)
+        return data.data.accessToken as string
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
 api.interceptors.response.use(
   (response) => response,
   async (error: AxiosError<ResponseResult>) => {
@@ -30,17 +52,11 @@ api.interceptors.response.use(
       if (refreshToken && !originalRequest._retry) {
         originalRequest._retry = true
         try {
-          const { data } = await axios.post<ResponseResult>(`${baseURL}/refresh-token`, {
-            refreshToken
-          })
-          if (data.data) {
-            localStorage.setItem('accessToken', data.data.accessToken)
-            localStorage.setItem('refreshToken', data.data.refreshToken)
-            if (originalRequest.headers) {
-              originalRequest.headers.Authorization = `Bearer ${data.data.accessToken}`
-            }
-            return api(originalRequest)
+          const accessToken = await refreshAccessToken(refreshToken)
+          if (originalRequest.headers) {
+            originalRequest.headers.Authorization = `Bearer ${accessToken}`
           }
+          return api(originalRequest)
         } catch {
           localStorage.removeItem('accessToken')
           localStorage.removeItem('refreshToken')